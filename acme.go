@@ -0,0 +1,415 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// acmeEvent is an event of an acme window, read from its event file.
+// Origin is E (body or tag written by a file), F (by other), K (keyboard)
+// or M (mouse). Type is x or X for execute, l or L for look, and others
+// for text inserted or deleted.
+type acmeEvent struct {
+	Origin byte
+	Type   byte
+	Q0, Q1 int
+	Flag   int
+	Text   string
+}
+
+// readAcmeEvent read an event in the format of the event file:
+// "%c%c%d %d %d %d %s\n" with the number of runes of the text. Expansion
+// and chorded argument which follow some events are merged into it.
+func readAcmeEvent(r *bufio.Reader) (acmeEvent, error) {
+	e, err := readAcmeEventOnce(r)
+	if err != nil {
+		return e, err
+	}
+	if e.Flag&2 != 0 {
+		// the text is expanded by acme; the next event has it.
+		x, err := readAcmeEventOnce(r)
+		if err != nil {
+			return e, err
+		}
+		e.Text = x.Text
+	}
+	if e.Flag&8 != 0 {
+		// chorded argument and its origin follow.
+		for i := 0; i < 2; i++ {
+			x, err := readAcmeEventOnce(r)
+			if err != nil {
+				return e, err
+			}
+			if i == 0 && x.Text != "" {
+				e.Text += " " + x.Text
+			}
+		}
+	}
+	return e, nil
+}
+
+func readAcmeEventOnce(r *bufio.Reader) (acmeEvent, error) {
+	var e acmeEvent
+	var err error
+	if e.Origin, err = r.ReadByte(); err != nil {
+		return e, err
+	}
+	if e.Type, err = r.ReadByte(); err != nil {
+		return e, err
+	}
+	var n [4]int
+	for i := range n {
+		s, err := r.ReadString(' ')
+		if err != nil {
+			return e, err
+		}
+		if n[i], err = strconv.Atoi(strings.TrimSpace(s)); err != nil {
+			return e, errors.New("invalid acme event")
+		}
+	}
+	e.Q0, e.Q1, e.Flag = n[0], n[1], n[2]
+	var text []rune
+	for i := 0; i < n[3]; i++ {
+		c, _, err := r.ReadRune()
+		if err != nil {
+			return e, err
+		}
+		text = append(text, c)
+	}
+	e.Text = string(text)
+	if c, err := r.ReadByte(); err != nil || c != '\n' {
+		return e, errors.New("invalid acme event")
+	}
+	return e, nil
+}
+
+// acmeWin is a window of acme, controlled through "9p" of plan9port.
+type acmeWin struct {
+	id string
+}
+
+// acmePath return path of the file of the window in the acme file server.
+func (w *acmeWin) acmePath(file string) string {
+	return "acme/" + w.id + "/" + file
+}
+
+// newAcmeWin open a window of the name with the commands in the tag.
+func newAcmeWin(name, tag string) (*acmeWin, error) {
+	out, err := exec.Command("9p", "read", "acme/new/ctl").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open acme window (is acme running?): %v", err)
+	}
+	f := strings.Fields(string(out))
+	if len(f) == 0 {
+		return nil, errors.New("failed to open acme window")
+	}
+	w := &acmeWin{id: f[0]}
+	if err := w.ctl("name " + name); err != nil {
+		return nil, err
+	}
+	return w, w.write("tag", tag)
+}
+
+// write write data to the file of the window.
+func (w *acmeWin) write(file, data string) error {
+	cmd := exec.Command("9p", "write", w.acmePath(file))
+	cmd.Stdin = strings.NewReader(data)
+	return cmd.Run()
+}
+
+// ctl write the control message to the window.
+func (w *acmeWin) ctl(msg string) error {
+	return w.write("ctl", msg+"\n")
+}
+
+// setBody replace the body of the window with text, and mark it clean.
+func (w *acmeWin) setBody(text string) error {
+	if err := w.write("addr", ","); err != nil {
+		return err
+	}
+	if err := w.write("data", text); err != nil {
+		return err
+	}
+	// the address persist until addr is opened again.
+	if err := w.write("addr", "0"); err != nil {
+		return err
+	}
+	if err := w.ctl("dot=addr"); err != nil {
+		return err
+	}
+	return w.ctl("clean")
+}
+
+// body return the text of the body of the window.
+func (w *acmeWin) body() (string, error) {
+	b, err := exec.Command("9p", "read", w.acmePath("body")).Output()
+	return string(b), err
+}
+
+// errorf show the message in the +Errors window.
+func (w *acmeWin) errorf(format string, v ...interface{}) {
+	w.write("errors", fmt.Sprintf(tr(format), v...)+"\n")
+}
+
+// unhandled hand the event back to acme for the default action.
+func (w *acmeWin) unhandled(e acmeEvent) {
+	w.write("event", fmt.Sprintf("%c%c%d %d\n", e.Origin, e.Type, e.Q0, e.Q1))
+}
+
+// events call handle for events of the window until it is deleted.
+func (w *acmeWin) events(handle func(e acmeEvent)) error {
+	cmd := exec.Command("9p", "read", w.acmePath("event"))
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	defer cmd.Wait()
+	r := bufio.NewReader(out)
+	for {
+		e, err := readAcmeEvent(r)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		handle(e)
+	}
+}
+
+// acmeSession is windows of goissue opened in acme.
+type acmeSession struct {
+	ctx    context.Context
+	config *Config
+	c      *Client
+	query  filter
+	status string // status set by Close
+	wg     sync.WaitGroup
+}
+
+// run handle events of the window in background. Executed commands are
+// given to execute, and looked texts to look; events they don't handle are
+// handed back to acme.
+func (s *acmeSession) run(w *acmeWin, execute func(cmd string) bool, look func(text string) bool) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		err := w.events(func(e acmeEvent) {
+			switch e.Type {
+			case 'x', 'X':
+				if f := strings.Fields(e.Text); len(f) > 0 && execute(f[0]) {
+					return
+				}
+			case 'l', 'L':
+				if look != nil && look(strings.TrimSpace(e.Text)) {
+					return
+				}
+			default:
+				return
+			}
+			w.unhandled(e)
+		})
+		if err != nil {
+			w.errorf("failed to read acme events: %v", err)
+		}
+	}()
+}
+
+// listText return the text of the list window.
+func (s *acmeSession) listText() (string, error) {
+	entries, err := s.c.Entries(s.ctx, s.query.values("open"))
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	for _, entry := range dropSpam(s.c, s.query.narrow(entries)) {
+		fmt.Fprintf(&b, "%s: %s\n", issueId(entry), entry.Title)
+	}
+	return b.String(), nil
+}
+
+// openList open the window listing issues; looking at an issue number (with
+// button 3) open the issue.
+func (s *acmeSession) openList() error {
+	w, err := newAcmeWin("/goissue/"+s.c.Project+"/", " Get ")
+	if err != nil {
+		return err
+	}
+	get := func() {
+		text, err := s.listText()
+		if err != nil {
+			w.errorf("failed to get issues: %v", err)
+			return
+		}
+		w.setBody(text)
+	}
+	get()
+	s.run(w, func(cmd string) bool {
+		if cmd != "Get" {
+			return false
+		}
+		get()
+		return true
+	}, func(text string) bool {
+		id := strings.TrimSuffix(text, ":")
+		if _, err := strconv.Atoi(id); err != nil {
+			return false
+		}
+		if err := s.openIssue(id); err != nil {
+			w.errorf("failed to open issue %s: %v", id, err)
+		}
+		return true
+	})
+	return nil
+}
+
+// issueText return the text of the issue window, and the issue.
+func (s *acmeSession) issueText(id string) (string, Entry, error) {
+	t, err := fetchThread(s.ctx, s.c, id)
+	if err != nil {
+		return "", Entry{}, err
+	}
+	text, err := renderEntry(s.c, t.Issue)
+	if err != nil {
+		return "", Entry{}, err
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s\nstatus: %s (%s)\nowner: %s\nlabels: %s\n%s\n\n%s\n", t.Issue.Title,
+		strings.Join(t.Issue.IssuesStatus, ","), entryState(t.Issue), ownerName(t.Issue),
+		strings.Join(t.Issue.IssuesLabel, " "), t.URL, text)
+	for _, comment := range t.Comments {
+		b.WriteString("\n")
+		if err := writeIssue(&b, s.c, comment); err != nil {
+			return "", Entry{}, err
+		}
+	}
+	markSeen(s.c.Project, t.Issue)
+	return b.String(), t.Issue, nil
+}
+
+// openIssue open the window of the issue, with Get, Comment and Close in
+// the tag.
+func (s *acmeSession) openIssue(id string) error {
+	w, err := newAcmeWin("/goissue/"+s.c.Project+"/"+id, " Get Comment Close ")
+	if err != nil {
+		return err
+	}
+	var mu sync.Mutex
+	var entry Entry
+	get := func() {
+		text, e, err := s.issueText(id)
+		if err != nil {
+			w.errorf("failed to get issue: %v", err)
+			return
+		}
+		mu.Lock()
+		entry = e
+		mu.Unlock()
+		w.setBody(text)
+	}
+	get()
+	s.run(w, func(cmd string) bool {
+		switch cmd {
+		case "Get":
+			get()
+		case "Comment":
+			if err := s.openComment(id, get); err != nil {
+				w.errorf("failed to open comment window: %v", err)
+			}
+		case "Close":
+			mu.Lock()
+			e := entry
+			mu.Unlock()
+			// the etag refuses to close the issue changed since Get.
+			if err := s.c.PostComment(s.ctx, id, s.config.Email, e.Etag, "", &Updates{Status: s.status}); err != nil {
+				w.errorf("failed to close issue %s: %v", id, err)
+				return true
+			}
+			get()
+		default:
+			return false
+		}
+		return true
+	}, nil)
+	return nil
+}
+
+// openComment open the window to write a comment to the issue; Post send
+// it and refresh the issue window by done. Post! send it even if it may
+// contain secrets.
+func (s *acmeSession) openComment(id string, done func()) error {
+	w, err := newAcmeWin("/goissue/"+s.c.Project+"/"+id+"/+comment", " Post ")
+	if err != nil {
+		return err
+	}
+	s.run(w, func(cmd string) bool {
+		if cmd != "Post" && cmd != "Post!" {
+			return false
+		}
+		body, err := w.body()
+		if err != nil {
+			w.errorf("failed to read comment: %v", err)
+			return true
+		}
+		if strings.TrimSpace(body) == "" {
+			w.errorf("the comment is empty")
+			return true
+		}
+		if found := findSecrets(s.config, body); len(found) > 0 && cmd != "Post!" {
+			w.errorf("the text may contain secrets or private paths: %s; execute Post! to send it anyway", strings.Join(found, ", "))
+			return true
+		}
+		if err := s.c.PostComment(s.ctx, id, s.config.Email, "", body, nil); err != nil {
+			w.errorf("failed to post comment: %v", err)
+			return true
+		}
+		w.ctl("clean")
+		w.ctl("del")
+		done()
+		return true
+	}, nil)
+	return nil
+}
+
+// acmeFlags is flags of acme.
+type acmeFlags struct {
+	status string
+	query  filter
+}
+
+// register add flags of acme to the flag set.
+func (o *acmeFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.status, "close-status", "Fixed", "status set by Close")
+	o.query.register(fs)
+}
+
+// acmeIssues open windows of issues in acme of plan9port: the list of
+// issues, and an issue when its number is looked (button 3). Get, Comment
+// and Close in the tag are executed (button 2). It returns when all the
+// windows are deleted.
+func acmeIssues(ctx context.Context, config *Config, c *Client, args []string) {
+	var o acmeFlags
+	fs := newFlagSet("acme")
+	o.register(fs)
+	parseFlags(fs, args)
+	s := &acmeSession{ctx: ctx, config: config, c: c, query: o.query, status: o.status}
+	if _, err := exec.LookPath("9p"); err != nil {
+		fatal("failed to start acme mode: 9p of plan9port is required:", err)
+	}
+	if err := s.openList(); err != nil {
+		fatal("failed to start acme mode:", err)
+	}
+	s.wg.Wait()
+}
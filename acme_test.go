@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadAcmeEvent(t *testing.T) {
+	// execute of Comment, look expanded from 123 to "123:", chorded
+	// execute of Post with an argument, and a multibyte insert.
+	r := bufio.NewReader(strings.NewReader("Mx10 17 0 7 Comment\n" +
+		"Ml5 5 2 0 \nMl3 7 0 4 123:\n" +
+		"Mx0 4 8 4 Post\nMx10 13 0 3 now\nMx0 0 0 6 +Error\n" +
+		"KI0 2 0 2 日本\n"))
+	for _, want := range []acmeEvent{
+		{'M', 'x', 10, 17, 0, "Comment"},
+		{'M', 'l', 5, 5, 2, "123:"},
+		{'M', 'x', 0, 4, 8, "Post now"},
+		{'K', 'I', 0, 2, 0, "日本"},
+	} {
+		got, err := readAcmeEvent(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("readAcmeEvent = %+v, want %+v", got, want)
+		}
+	}
+	if _, err := readAcmeEvent(r); err != io.EOF {
+		t.Errorf("readAcmeEvent at the end = %v, want EOF", err)
+	}
+	if _, err := readAcmeEvent(bufio.NewReader(strings.NewReader("Mxa b c d\n"))); err == nil {
+		t.Error("readAcmeEvent must fail on broken event")
+	}
+}
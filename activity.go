@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// byUpdated sort entries by updated time, older first.
+type byUpdated []Entry
+
+func (e byUpdated) Len() int           { return len(e) }
+func (e byUpdated) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+func (e byUpdated) Less(i, j int) bool { return e[i].Updated < e[j].Updated }
+
+// isUser return true if the author is the user. The user may be given as
+// the name or the email address.
+func isUser(authors []Author, user string) bool {
+	for _, a := range authors {
+		if strings.EqualFold(a.Name, user) || strings.EqualFold(a.Email, user) {
+			return true
+		}
+		if i := strings.Index(user, "@"); i >= 0 && strings.EqualFold(a.Name, user[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// userEvent is an activity of the user.
+type userEvent struct {
+	Time  string
+	Kind  string // "filed", "commented" or "closed"
+	Entry Entry
+}
+
+// byTime sort events in chronological order.
+type byTime []userEvent
+
+func (e byTime) Len() int           { return len(e) }
+func (e byTime) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+func (e byTime) Less(i, j int) bool { return e[i].Time < e[j].Time }
+
+// closedStatuses return statuses meaning closed; defaults and statuses of
+// closed issues in the store.
+func closedStatuses(s *store) map[string]bool {
+	closed := map[string]bool{}
+	// default statuses after "Started" are closed ones.
+	for _, status := range defaultStatuses[3:] {
+		closed[status] = true
+	}
+	for _, entry := range s.Entries {
+		if entryState(entry) == "closed" {
+			for _, status := range entry.IssuesStatus {
+				closed[status] = true
+			}
+		}
+	}
+	return closed
+}
+
+// showUserActivity print issues filed, commented and closed by the user
+// since the time, from the local store. Comments are stored by sync
+// -comments.
+func showUserActivity(c *Client, user string, since time.Time) {
+	s := loadStore(c.Project)
+	if len(s.Comments) == 0 {
+		warnf("no comments in the store; run sync -comments to see them")
+	}
+	closed := closedStatuses(s)
+	after := func(t string) bool {
+		tt, err := time.Parse(time.RFC3339, t)
+		return err == nil && !tt.Before(since)
+	}
+	var events []userEvent
+	for id, entry := range s.Entries {
+		if isUser(entry.Author, user) && after(entry.Published) {
+			events = append(events, userEvent{entry.Published, "filed", entry})
+		}
+		for _, comment := range s.Comments[id] {
+			if !isUser(comment.Author, user) || !after(comment.Published) {
+				continue
+			}
+			kind := "commented"
+			if u := comment.IssuesUpdates; u != nil && closed[u.IssuesStatus] {
+				kind = "closed"
+			}
+			events = append(events, userEvent{comment.Published, kind, entry})
+		}
+	}
+	sort.Sort(byTime(events))
+	count := map[string]int{}
+	for _, ev := range events {
+		t, _ := time.Parse(time.RFC3339, ev.Time)
+		fmt.Printf("%s %-9s %s: %s\n", t.Local().Format("2006-01-02 15:04"), ev.Kind, issueId(ev.Entry), ev.Entry.Title)
+		count[ev.Kind]++
+	}
+	fmt.Printf("%d filed, %d commented, %d closed\n", count["filed"], count["commented"], count["closed"])
+}
+
+// activityFlags is flags of activity.
+type activityFlags struct {
+	since string
+	user  string
+}
+
+// register add flags of activity to the flag set.
+func (o *activityFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.since, "since", "1d", "show activity in the period like 1d or 12h")
+	fs.StringVar(&o.user, "user", "", "show issues filed, commented and closed by the user, from the local store")
+}
+
+// showActivity print issue updates and comments of the project since the
+// time, in chronological order. With -user, activity of the user is shown
+// instead.
+func showActivity(ctx context.Context, config *Config, c *Client, args []string) {
+	var o activityFlags
+	fs := newFlagSet("activity")
+	o.register(fs)
+	fs.Parse(args)
+
+	age, err := parseAge(o.since)
+	if err != nil {
+		fatal("invalid -since:", err)
+	}
+	since := time.Now().Add(-age)
+	if o.user != "" {
+		showUserActivity(c, o.user, since)
+		return
+	}
+
+	// the feed is changed by every activity, so don't cache it.
+	ac := *c
+	ac.Cache = nil
+	feed, err := ac.Feed(ctx, ac.UpdatesURL())
+	if err != nil {
+		fatal("failed to get activity:", err)
+	}
+	muted := loadMuted(c.Project)
+	var entries []Entry
+	for _, entry := range feed.Entry {
+		t, err := time.Parse(time.RFC3339, entry.Updated)
+		if err != nil || t.Before(since) || muted[updateId(entry)] {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.Sort(byUpdated(entries))
+	for _, entry := range entries {
+		t, _ := time.Parse(time.RFC3339, entry.Updated)
+		fmt.Println(t.Local().Format("2006-01-02 15:04") + " " + entry.Title)
+	}
+}
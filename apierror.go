@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/xml"
+	"exp/html"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// APIError is an error response from the tracker.
+type APIError struct {
+	StatusCode int
+	Status     string
+	Message    string // explanation the server returned in the body
+}
+
+func (e *APIError) Error() string {
+	s := e.Status
+	switch {
+	case e.Permission():
+		s = "permission denied (are you a member of the project?): " + s
+	case e.Validation():
+		s = "invalid request: " + s
+	}
+	if e.Message != "" {
+		s += ": " + e.Message
+	}
+	return s
+}
+
+// Permission return true if the request was rejected for lack of
+// permission, e.g. non-member tried to set labels.
+func (e *APIError) Permission() bool {
+	return e.StatusCode == 401 || e.StatusCode == 403
+}
+
+// Validation return true if the request was rejected as invalid.
+func (e *APIError) Validation() bool {
+	return e.StatusCode == 400
+}
+
+// newAPIError return error for the failed response. The body is read to
+// find the message.
+func newAPIError(res *http.Response) *APIError {
+	b, _ := ioutil.ReadAll(res.Body)
+	return &APIError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Message:    errorMessage(res.Header.Get("Content-Type"), b),
+	}
+}
+
+// errorMessage extract human readable message from error body which is
+// GData error XML, HTML or plain text.
+func errorMessage(contentType string, b []byte) string {
+	var msg string
+	switch {
+	case strings.Contains(contentType, "xml"):
+		var errs struct {
+			Error []struct {
+				Reason string `xml:"internalReason"`
+			} `xml:"error"`
+		}
+		if xml.Unmarshal(b, &errs) == nil {
+			var reasons []string
+			for _, e := range errs.Error {
+				reasons = append(reasons, e.Reason)
+			}
+			msg = strings.Join(reasons, "; ")
+		}
+	case strings.Contains(contentType, "html"):
+		doc, err := html.Parse(strings.NewReader(string(b)))
+		if err == nil {
+			msg = nodeText(doc)
+		}
+	default:
+		msg = string(b)
+	}
+	msg = strings.Join(strings.Fields(stripControls(msg)), " ")
+	if len(msg) > 500 {
+		// cut at a rune boundary, not in a multi-byte character.
+		n := 500
+		for n > 0 && !utf8.RuneStart(msg[n]) {
+			n--
+		}
+		msg = msg[:n] + "..."
+	}
+	return msg
+}
+
+// nodeText return text in the node except scripts and styles.
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data + " "
+	}
+	if hiddenElement(n) {
+		return ""
+	}
+	s := ""
+	for _, c := range n.Child {
+		s += nodeText(c)
+	}
+	return s
+}
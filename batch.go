@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// splitArgs split the line into words like the shell. Double quotes group
+// words and are removed.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var cur []rune
+	quoted, inWord := false, false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inWord = true
+		case !quoted && (r == ' ' || r == '\t'):
+			if inWord {
+				args = append(args, string(cur))
+				cur, inWord = nil, false
+			}
+		default:
+			cur = append(cur, r)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, errors.New("unterminated quote")
+	}
+	if inWord {
+		args = append(args, string(cur))
+	}
+	return args, nil
+}
+
+// batchOp is an operation in the batch file.
+type batchOp struct {
+	line int
+	text string
+	id   string
+	body string
+	u    *Updates
+}
+
+// parseBatchLine parse a line of the batch file like "label 124 +Go1.1".
+// Comment of the update is given by -m.
+func parseBatchLine(args []string) (op batchOp, err error) {
+	if len(args) < 2 {
+		return op, errors.New("command and issue number are required")
+	}
+	if _, err := strconv.Atoi(args[1]); err != nil {
+		return op, errors.New("invalid issue number: " + args[1])
+	}
+	op.id = args[1]
+	var rest []string
+	for i := 2; i < len(args); i++ {
+		if args[i] == "-m" {
+			if i+1 == len(args) {
+				return op, errors.New("-m needs message")
+			}
+			op.body = args[i+1]
+			i++
+			continue
+		}
+		rest = append(rest, args[i])
+	}
+	switch args[0] {
+	case "close":
+		status := "Fixed"
+		if len(rest) > 1 {
+			return op, errors.New("usage: close ID [STATUS] [-m MESSAGE]")
+		} else if len(rest) == 1 {
+			status = rest[0]
+		}
+		op.u = &Updates{Status: status}
+	case "status", "owner":
+		if len(rest) != 1 {
+			return op, errors.New("usage: " + args[0] + " ID VALUE [-m MESSAGE]")
+		}
+		if args[0] == "status" {
+			op.u = &Updates{Status: rest[0]}
+		} else {
+			op.u = &Updates{Owner: rest[0]}
+		}
+	case "label":
+		if len(rest) == 0 {
+			return op, errors.New("usage: label ID +LABEL|-LABEL... [-m MESSAGE]")
+		}
+		op.u = &Updates{}
+		for _, l := range rest {
+			op.u.Labels = append(op.u.Labels, strings.TrimPrefix(l, "+"))
+		}
+	case "comment":
+		if len(rest) != 0 || op.body == "" {
+			return op, errors.New("usage: comment ID -m MESSAGE")
+		}
+	default:
+		return op, errors.New("unknown command: " + args[0])
+	}
+	return op, nil
+}
+
+// readBatch read operations from the batch file. Empty lines and lines
+// starting with "#" are ignored.
+func readBatch(file string) ([]batchOp, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var ops []batchOp
+	var errs []string
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		args, err := splitArgs(text)
+		if err == nil {
+			var op batchOp
+			op, err = parseBatchLine(args)
+			op.line, op.text = n, text
+			ops = append(ops, op)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("line %d: %v", n, err))
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, "\n"))
+	}
+	return ops, nil
+}
+
+// batchFlags is flags of batch.
+type batchFlags struct {
+	cont   bool
+	dryRun bool
+}
+
+// register add flags of batch to the flag set.
+func (o *batchFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.cont, "continue-on-error", false, "run the rest of lines after a failure")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "check the file without posting")
+}
+
+// runBatch run operations written in the file. The whole file is checked
+// before anything is posted, and the result of every line is reported.
+// Without -continue-on-error, it stops at the first failure.
+func runBatch(ctx context.Context, config *Config, c *Client, args []string) {
+	var o batchFlags
+	fs := newFlagSet("batch")
+	o.register(fs)
+	rest := parseFlags(fs, args)
+	if len(rest) != 1 {
+		fmt.Fprint(os.Stderr, "Usage: goissue batch [-continue-on-error] [-dry-run] FILE\n")
+		fs.PrintDefaults()
+		exit(exitUsage)
+	}
+	ops, err := readBatch(rest[0])
+	if err != nil {
+		fatal("failed to read batch file:\n", err)
+	}
+	if o.dryRun {
+		for _, op := range ops {
+			fmt.Printf("line %d: %s\n", op.line, op.text)
+		}
+		notef("%d operations are valid\n", len(ops))
+		return
+	}
+
+	done, failed := 0, 0
+	for i, op := range ops {
+		c.Cache.forget(c.IssueURL(op.id))
+		entry, err := c.Entry(ctx, op.id)
+		if err == nil {
+			err = c.PostComment(ctx, op.id, config.Email, entry.Etag, op.body, op.u)
+		}
+		if err == nil {
+			done++
+			fmt.Printf("ok     line %d: %s\n", op.line, op.text)
+			continue
+		}
+		failed++
+		fmt.Printf("FAILED line %d: %s: %v\n", op.line, op.text, err)
+		if !o.cont {
+			for _, op := range ops[i+1:] {
+				fmt.Printf("skip   line %d: %s\n", op.line, op.text)
+			}
+			break
+		}
+	}
+	notef("%d done, %d failed, %d skipped\n", done, failed, len(ops)-done-failed)
+	if failed > 0 {
+		exit(1)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// benchSizes is numbers of issues in fixtures, a small project and one as
+// large as the go tracker.
+var benchSizes = []int{1000, 20000}
+
+// benchFeed return feed of n issues made from testdata/render.xml.
+func benchFeed(b *testing.B, n int) []byte {
+	src, err := ioutil.ReadFile("testdata/render.xml")
+	if err != nil {
+		b.Fatal(err)
+	}
+	entry := string(src[bytes.Index(src, []byte("<entry")):])
+	var buf bytes.Buffer
+	buf.WriteString("<?xml version='1.0' encoding='UTF-8'?>\n<feed xmlns='http://www.w3.org/2005/Atom'>\n")
+	for i := 1; i <= n; i++ {
+		buf.WriteString(strings.Replace(entry, "/full/4321<", fmt.Sprintf("/full/%d<", i), 1))
+	}
+	buf.WriteString("</feed>\n")
+	return buf.Bytes()
+}
+
+func BenchmarkDecodeFeed(b *testing.B) {
+	for _, n := range benchSizes {
+		data := benchFeed(b, n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				var feed Feed
+				if err := xml.Unmarshal(data, &feed); err != nil {
+					b.Fatal(err)
+				}
+				if len(feed.Entry) != n {
+					b.Fatalf("got %d entries, want %d", len(feed.Entry), n)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRender(b *testing.B) {
+	var entry Entry
+	readFixture(b, "render.xml", &entry)
+	b.SetBytes(int64(len(entry.Content)))
+	for i := 0; i < b.N; i++ {
+		if _, err := render(entry.Content, Options{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchCache return cache in a temporary directory that has n responses.
+func benchCache(b *testing.B, n int, body []byte) (*Cache, func()) {
+	dir, err := ioutil.TempDir("", "goissue-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	c := &Cache{Dir: dir, TTL: time.Hour}
+	for i := 0; i < n; i++ {
+		c.put(fmt.Sprint("issue/", i), body)
+	}
+	return c, func() { os.RemoveAll(dir) }
+}
+
+func BenchmarkCacheGet(b *testing.B) {
+	body, _ := ioutil.ReadFile("testdata/render.xml")
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			c, cleanup := benchCache(b, n, body)
+			defer cleanup()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, ok := c.get(fmt.Sprint("issue/", i%n)); !ok {
+					b.Fatal("cache miss")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCachePut(b *testing.B) {
+	body, _ := ioutil.ReadFile("testdata/render.xml")
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			c, cleanup := benchCache(b, n, body)
+			defer cleanup()
+			// with a size limit, the directory is scanned only by the first put.
+			c.MaxSize = 1 << 40
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.put(fmt.Sprint("issue/", i%n), body)
+			}
+		})
+	}
+}
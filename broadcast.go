@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// broadcastFlags is flags of broadcast.
+type broadcastFlags struct {
+	message string
+	wait    time.Duration
+	dryRun  bool
+	yes     bool
+	filter  filter
+}
+
+// register add flags of broadcast to the flag set.
+func (o *broadcastFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.message, "m", "", "comment to post")
+	fs.DurationVar(&o.wait, "wait", 2*time.Second, "wait between comments")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "print issues to comment on without posting")
+	fs.BoolVar(&o.yes, "y", false, "post without confirmation")
+	o.filter.register(fs)
+}
+
+// broadcastComment post the same comment to every matched issue, like
+// "This is fixed at tip, please retest." The list is shown and confirmed
+// before posting, and comments are posted with wait between them.
+func broadcastComment(ctx context.Context, config *Config, c *Client, args []string) {
+	var o broadcastFlags
+	fs := newFlagSet("broadcast")
+	o.register(fs)
+	parseFlags(fs, args)
+	if o.message == "" {
+		fmt.Fprint(os.Stderr, "Usage: goissue broadcast -m MESSAGE [-wait 2s] [-dry-run] [-y] [filters]\n")
+		fs.PrintDefaults()
+		exit(exitUsage)
+	}
+
+	entries, err := c.Entries(ctx, o.filter.values("open"))
+	if err != nil {
+		fatal("failed to get issues:", err)
+	}
+	entries = o.filter.narrow(entries)
+	if len(entries) == 0 {
+		notef("no issues matched\n")
+		exit(exitNotFound)
+	}
+	for _, entry := range entries {
+		fmt.Printf("%s: %s\n", issueId(entry), entry.Title)
+	}
+	if o.dryRun {
+		notef("%d issues would be commented on\n", len(entries))
+		return
+	}
+	if !checkSecrets(config, o.message) {
+		fatal("canceled")
+	}
+	if !o.yes && !confirm(fmt.Sprintf(tr("post the comment to these %d issues?"), len(entries))) {
+		fatal("canceled")
+	}
+
+	failed := 0
+	for i, entry := range entries {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				fatal("canceled:", ctx.Err())
+			case <-time.After(o.wait):
+			}
+		}
+		id := issueId(entry)
+		if err := c.PostComment(ctx, id, config.Email, "", o.message, nil); err != nil {
+			item := &outboxItem{Kind: "comment", Project: c.Project, Id: id, From: config.Email, Body: o.message}
+			warnf("failed to comment on issue %s: %v%s", id, err, queueOnFailure(item, err))
+			failed++
+			continue
+		}
+		progressf(c, "[%d/%d] commented on %s\n", i+1, len(entries), id)
+	}
+	notef("%d commented, %d failed\n", len(entries)-failed, failed)
+	if failed > 0 {
+		exit(1)
+	}
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha1"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache store responses of the tracker in files. Methods of nil Cache do
+// nothing, so that caching can be disabled by leaving Client.Cache nil.
+type Cache struct {
+	Dir     string        // directory that store cache files
+	TTL     time.Duration // duration that cached responses are used for; zero means always fetch
+	MaxSize int64         // maximum total bytes; least recently used files are removed over it
+
+	mu      sync.Mutex // serialize writes and eviction
+	size    int64      // total bytes of files as of the last eviction, and put since
+	counted bool       // size is counted by evict
+}
+
+// file return path of the cache file for the uri.
+func (c *Cache) file(uri string) string {
+	h := sha1.New()
+	io.WriteString(h, uri)
+	return filepath.Join(c.Dir, fmt.Sprintf("%x", h.Sum(nil)))
+}
+
+// get return cached response of the uri if it is fresh enough. Cache
+// file start with a line of the time it was fetched followed by gzipped
+// body, and its modification time is updated on every use for LRU eviction.
+// Broken files are removed, so that the response is fetched again.
+func (c *Cache) get(uri string) ([]byte, bool) {
+	if c == nil || c.TTL <= 0 {
+		return nil, false
+	}
+	body, fetched, ok := c.read(uri)
+	if !ok || time.Since(fetched) > c.TTL {
+		metricCacheMisses.Add(1)
+		return nil, false
+	}
+	metricCacheHits.Add(1)
+	return body, true
+}
+
+// read return the cached body of the uri and when it was stored, however
+// old it is.
+func (c *Cache) read(uri string) ([]byte, time.Time, bool) {
+	file := c.file(uri)
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	nl := bytes.IndexByte(b, '\n')
+	fetched, err := int64(0), errors.New("no header")
+	if nl >= 0 {
+		fetched, err = strconv.ParseInt(string(b[:nl]), 10, 64)
+	}
+	var body []byte
+	if err == nil {
+		body, err = decompress(b[nl+1:])
+	}
+	if err != nil {
+		// broken by a crash of old goissue or a full disk; fetch it again.
+		debugf("removing broken cache file %s: %v", file, err)
+		os.Remove(file)
+		return nil, time.Time{}, false
+	}
+	now := time.Now()
+	os.Chtimes(file, now, now)
+	return body, time.Unix(fetched, 0), true
+}
+
+// rendererVersion is the version of render, in keys of rendered texts.
+// Bump it whenever render changes its output, so that texts rendered by
+// older versions are not used.
+const rendererVersion = 3
+
+// renderedKey is the key of the text rendered from the entry with the
+// options. The text is the same while the entry is not updated, but
+// differ by -plain, -raw-bytes and the version of render.
+func renderedKey(entry Entry, o Options) string {
+	return fmt.Sprintf("rendered:v%d:%s:%s:%v:%v", rendererVersion, entry.Id, entry.Updated, o.Plain, o.RawBytes)
+}
+
+// rendered return the text rendered from the content of the entry as of
+// its last update. It is used for any TTL since it never changes.
+func (c *Cache) rendered(entry Entry, o Options) (string, bool) {
+	if c == nil || entry.Id == "" || entry.Updated == "" {
+		return "", false
+	}
+	body, _, ok := c.read(renderedKey(entry, o))
+	return string(body), ok
+}
+
+// putRendered store the text rendered from the content of the entry.
+func (c *Cache) putRendered(entry Entry, o Options, text string) {
+	if c == nil || entry.Id == "" || entry.Updated == "" {
+		return
+	}
+	c.put(renderedKey(entry, o), []byte(text))
+}
+
+// put store response of the uri. Failing to write the cache is not an
+// error since it only make next command slow.
+func (c *Cache) put(uri string, b []byte) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	file := c.file(uri)
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return
+	}
+	// other goissue may write or evict at once.
+	unlock, err := lockFile(c.Dir)
+	if err != nil {
+		return
+	}
+	defer unlock()
+	var old int64
+	if fi, err := os.Stat(file); err == nil {
+		old = fi.Size()
+	}
+	data := append([]byte(fmt.Sprintf("%d\n", time.Now().Unix())), compress(b)...)
+	if replaceFile(file, data, 0600) != nil {
+		return
+	}
+	c.size += int64(len(data)) - old
+	// the files are counted once, and again only when the size is over;
+	// writes of other goissue are found then.
+	if c.MaxSize > 0 && (!c.counted || c.size > c.MaxSize) {
+		c.evict(c.MaxSize)
+	}
+}
+
+// compress return gzipped b.
+func compress(b []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(b)
+	w.Close()
+	return buf.Bytes()
+}
+
+// decompress return b uncompressed. b which is not gzipped is returned as
+// is, so that files written before compression was introduced are readable.
+func decompress(b []byte) ([]byte, error) {
+	if len(b) < 2 || b[0] != 0x1f || b[1] != 0x8b {
+		return b, nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// forget remove cached response of the uri.
+func (c *Cache) forget(uri string) {
+	if c == nil {
+		return
+	}
+	os.Remove(c.file(uri))
+}
+
+// files return cache files sorted by last used time, oldest first.
+// Temporary files being written by replaceFile are not included.
+func (c *Cache) files() []os.FileInfo {
+	all, err := ioutil.ReadDir(c.Dir)
+	if err != nil {
+		return nil
+	}
+	var fis []os.FileInfo
+	for _, fi := range all {
+		if !strings.HasPrefix(fi.Name(), ".") {
+			fis = append(fis, fi)
+		}
+	}
+	sort.Sort(byModTime(fis))
+	return fis
+}
+
+type byModTime []os.FileInfo
+
+func (f byModTime) Len() int           { return len(f) }
+func (f byModTime) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+func (f byModTime) Less(i, j int) bool { return f[i].ModTime().Before(f[j].ModTime()) }
+
+// evict remove least recently used files until total size of the cache
+// become no more than max, and keep the size for put.
+func (c *Cache) evict(max int64) (removed int) {
+	fis := c.files()
+	var total int64
+	for _, fi := range fis {
+		total += fi.Size()
+	}
+	for _, fi := range fis {
+		if total <= max {
+			break
+		}
+		if os.Remove(filepath.Join(c.Dir, fi.Name())) == nil {
+			total -= fi.Size()
+			removed++
+		}
+	}
+	c.size, c.counted = total, true
+	return removed
+}
+
+// parseAge parse duration like "30d", "1w" or "12h".
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.Atoi(s[:len(s)-1])
+			if err != nil {
+				return 0, err
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
+
+// parseSize parse size like "100M" or "1G" into bytes.
+func parseSize(s string) (int64, error) {
+	unit := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		unit = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		unit = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		unit = 1 << 30
+	}
+	if unit > 1 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return n * unit, nil
+}
+
+// cacheFlags is flags of cache.
+type cacheFlags struct {
+	olderThan string
+}
+
+// register add flags of cache to the flag set.
+func (o *cacheFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.olderThan, "older-than", "30d", "prune files not used for the duration")
+}
+
+// manageCache handle cache sub commands: stats, clear and prune.
+func manageCache(ctx context.Context, config *Config, c *Client, args []string) {
+	var o cacheFlags
+	fs := newFlagSet("cache")
+	o.register(fs)
+	rest := parseFlags(fs, args)
+	if len(rest) != 1 {
+		fmt.Fprint(os.Stderr, "Usage: goissue cache stats|clear|prune [-older-than 30d]\n")
+		fs.PrintDefaults()
+		exit(exitUsage)
+	}
+
+	cache := c.Cache
+	fis := cache.files()
+	switch rest[0] {
+	case "stats":
+		var total int64
+		for _, fi := range fis {
+			total += fi.Size()
+		}
+		fmt.Printf("files: %d\n", len(fis))
+		fmt.Printf("size:  %d bytes\n", total)
+		if cache.MaxSize > 0 {
+			fmt.Printf("limit: %d bytes\n", cache.MaxSize)
+		}
+		if len(fis) > 0 {
+			fmt.Printf("least recently used: %s\n", fis[0].ModTime().Format(time.RFC3339))
+			fmt.Printf("most recently used:  %s\n", fis[len(fis)-1].ModTime().Format(time.RFC3339))
+		}
+	case "clear":
+		err := os.RemoveAll(cache.Dir)
+		if err != nil {
+			fatal("failed to clear cache:", err)
+		}
+		infof("%d files removed", len(fis))
+	case "prune":
+		age, err := parseAge(o.olderThan)
+		if err != nil {
+			fatal("invalid -older-than:", err)
+		}
+		removed := 0
+		for _, fi := range fis {
+			if time.Since(fi.ModTime()) > age && os.Remove(filepath.Join(cache.Dir, fi.Name())) == nil {
+				removed++
+			}
+		}
+		if cache.MaxSize > 0 {
+			removed += cache.evict(cache.MaxSize)
+		}
+		infof("%d files removed", removed)
+	default:
+		fatal("unknown cache command: " + rest[0])
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// changelogFlags is flags of changelog.
+type changelogFlags struct {
+	since  string
+	until  string
+	format string
+	filter filter
+}
+
+// register add flags of changelog to the flag set.
+func (o *changelogFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.since, "since", "", "start date (YYYY-MM-DD)")
+	fs.StringVar(&o.until, "until", "", "end date (YYYY-MM-DD)")
+	fs.StringVar(&o.format, "format", "md", "output format: md or text")
+	o.filter.register(fs)
+}
+
+// showChangelog print release notes of issues closed in the period, grouped
+// by Type label.
+func showChangelog(ctx context.Context, config *Config, c *Client, args []string) {
+	var o changelogFlags
+	fs := newFlagSet("changelog")
+	o.register(fs)
+	fs.Parse(args)
+	if o.format != "md" && o.format != "text" {
+		fatal("-format must be md or text")
+	}
+	from, to := parseDate(o.since), parseDate(o.until)
+
+	groups := map[string][]Entry{}
+	for _, entry := range loadStore(c.Project).Entries {
+		if !o.filter.match(entry) {
+			continue
+		}
+		if t, ok := closedAt(entry); !ok || !inRange(t, from, to) {
+			continue
+		}
+		typ := "Other"
+		for _, label := range entry.IssuesLabel {
+			if strings.HasPrefix(label, "Type-") {
+				typ = label[5:]
+				break
+			}
+		}
+		groups[typ] = append(groups[typ], entry)
+	}
+
+	var types []string
+	for typ := range groups {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	for i, typ := range types {
+		if i > 0 {
+			fmt.Println()
+		}
+		if o.format == "md" {
+			fmt.Println("## " + typ)
+			fmt.Println()
+		} else {
+			fmt.Println(typ + ":")
+		}
+		entries := groups[typ]
+		sort.Sort(byId(entries))
+		for _, entry := range entries {
+			if o.format == "md" {
+				fmt.Println("* " + entry.Title + " (issue " + issueId(entry) + ")")
+			} else {
+				fmt.Println("  " + issueId(entry) + ": " + entry.Title)
+			}
+		}
+	}
+}
+
+// byId sort entries by issue number.
+type byId []Entry
+
+func (e byId) Len() int      { return len(e) }
+func (e byId) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+func (e byId) Less(i, j int) bool {
+	a, _ := strconv.Atoi(issueId(e[i]))
+	b, _ := strconv.Atoi(issueId(e[j]))
+	return a < b
+}
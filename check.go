@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// sections split body of issue into answers keyed by questions, which are
+// lines ending with "?".
+func sections(body string) (questions []string, answers map[string]string) {
+	answers = map[string]string{}
+	q := ""
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasSuffix(strings.TrimSpace(line), "?") {
+			q = strings.TrimSpace(line)
+			questions = append(questions, q)
+			continue
+		}
+		if q != "" {
+			answers[q] += line + "\n"
+		}
+	}
+	return questions, answers
+}
+
+// unanswered return questions of the template whose answers in body are
+// empty or left as the placeholder of the template.
+func unanswered(template, body string) []string {
+	if i := strings.Index(template, "\n---"); i >= 0 {
+		template = template[i+1:]
+		template = template[strings.Index(template, "\n")+1:]
+	}
+	questions, placeholders := sections(template)
+	_, answers := sections(body)
+	var missing []string
+	for _, q := range questions {
+		answer, ok := answers[q]
+		if !ok {
+			continue
+		}
+		answer = strings.TrimSpace(answer)
+		if answer == "" || answer == strings.TrimSpace(placeholders[q]) {
+			missing = append(missing, q)
+		}
+	}
+	return missing
+}
+
+// misspelled return words reported by "spell_command" in config, like
+// "aspell list", which read text from stdin and print misspelled words.
+func misspelled(config *Config, text string) []string {
+	args := strings.Fields(config.SpellCommand)
+	if len(args) == 0 {
+		return nil
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	out, err := cmd.Output()
+	if err != nil {
+		warnf("failed to run spell_command: %v", err)
+		return nil
+	}
+	return strings.Fields(string(out))
+}
+
+// lintRule is a rule in "lint" of config checked before posting. Exactly
+// one of MinLength, Require, Forbid and If is given:
+//
+//	{"min_length": 80}              the body has at least 80 characters
+//	{"require": "(?i)steps"}        the body matches the regular expression
+//	{"forbid": "(?i)doesn't work"}  the body doesn't match it
+//	{"if": "(?i)screenshot"}        remind Message when the body matches it
+//
+// Level "error" stop posting, otherwise the rule is a warning.
+type lintRule struct {
+	MinLength int    `json:"min_length"`
+	Require   string `json:"require"`
+	Forbid    string `json:"forbid"`
+	If        string `json:"if"`
+	Message   string `json:"message"`
+	Level     string `json:"level"`
+}
+
+// loadLintRules return "lint" in config.
+func loadLintRules(config *Config) []lintRule {
+	var rules []lintRule
+	if config.Lint != nil {
+		if err := json.Unmarshal(config.Lint, &rules); err != nil {
+			fatal("invalid lint in your settings.json:", err)
+		}
+	}
+	return rules
+}
+
+// lintIssue return messages of rules the body breaks, as warnings or
+// failures by their level.
+func lintIssue(rules []lintRule, body string) (warnings, failures []string) {
+	body = strings.TrimSpace(body)
+	match := func(pattern string) bool {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			fatal("invalid lint pattern "+pattern+" in your settings.json:", err)
+		}
+		return re.MatchString(body)
+	}
+	for _, r := range rules {
+		msg := ""
+		switch {
+		case r.MinLength > 0:
+			if n := len([]rune(body)); n < r.MinLength {
+				msg = fmt.Sprintf("the body is too short (%d characters, want %d)", n, r.MinLength)
+			}
+		case r.Require != "":
+			if !match(r.Require) {
+				msg = "the body doesn't match " + r.Require
+			}
+		case r.Forbid != "":
+			if match(r.Forbid) {
+				msg = "the body matches " + r.Forbid
+			}
+		case r.If != "":
+			if match(r.If) {
+				msg = "the body matches " + r.If
+			}
+		}
+		if msg == "" {
+			continue
+		}
+		if r.Message != "" {
+			msg = r.Message
+		}
+		if r.Level == "error" {
+			failures = append(failures, msg)
+		} else {
+			warnings = append(warnings, msg)
+		}
+	}
+	return warnings, failures
+}
+
+// checkIssue warn about questions of the template left unanswered,
+// misspelled words and lint warnings, and return true if the user want to
+// post anyway.
+func checkIssue(config *Config, template string, issue *NewIssue, lints []string) bool {
+	missing := unanswered(template, issue.Body)
+	words := misspelled(config, issue.Title+"\n"+issue.Body)
+	if len(missing) == 0 && len(words) == 0 && len(lints) == 0 {
+		return true
+	}
+	for _, q := range missing {
+		warnf("not answered: %s", q)
+	}
+	if len(words) > 0 {
+		warnf("misspelled: %s", strings.Join(words, " "))
+	}
+	for _, msg := range lints {
+		warnf("lint: %s", msg)
+	}
+	return confirm("post anyway?")
+}
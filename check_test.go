@@ -0,0 +1,29 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLintIssue(t *testing.T) {
+	rules := []lintRule{
+		{MinLength: 20, Level: "error"},
+		{Require: "(?i)steps to reproduce", Message: "tell how to reproduce it"},
+		{Forbid: "(?i)doesn't work"},
+		{If: "(?i)screenshot", Message: "attach the screenshot"},
+	}
+	for _, tt := range []struct {
+		body     string
+		warnings []string
+		failures []string
+	}{
+		{"short", []string{"tell how to reproduce it"}, []string{"the body is too short (5 characters, want 20)"}},
+		{"Steps to reproduce: run go build", nil, nil},
+		{"Steps to reproduce: it doesn't work, see the screenshot", []string{"the body matches (?i)doesn't work", "attach the screenshot"}, nil},
+	} {
+		warnings, failures := lintIssue(rules, tt.body)
+		if !reflect.DeepEqual(warnings, tt.warnings) || !reflect.DeepEqual(failures, tt.failures) {
+			t.Errorf("lintIssue(%q) = %q, %q, want %q, %q", tt.body, warnings, failures, tt.warnings, tt.failures)
+		}
+	}
+}
@@ -0,0 +1,477 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultBaseURL is the base URL of the API and the web pages of Google
+// Code. Trackers compatible with it can be used with "base_url" and
+// "web_url" in config.
+const defaultBaseURL = "https://code.google.com"
+
+// gdataVersion is the version of GData API that goissue speak.
+const gdataVersion = "2"
+
+// defaultMaxBody is the maximum size of responses when MaxBody of the
+// client is 0.
+const defaultMaxBody = 32 << 20
+
+// Client is a client of the issue tracker of a project. Client has no
+// mutable state after creation except the auth code got by Login, which is
+// guarded, so it is safe for concurrent use by multiple goroutines.
+type Client struct {
+	Project string       // project name like "go"
+	Auth    string       // auth code returned by login; empty means Login is used
+	BaseURL string       // base URL of the API of the tracker
+	WebURL  string       // base URL of the web pages of the tracker
+	HTTP    *http.Client // nil means http.DefaultClient
+	Cache   *Cache       // nil means responses are not cached
+	MaxBody int64        // maximum size of responses; 0 means defaultMaxBody
+	Options Options      // how issues are printed and rendered
+
+	// Login is called to get the auth code when Auth is empty and a request
+	// is sent to the tracker first, so that commands answered from the
+	// cache don't need to log in. nil means anonymous.
+	Login func(ctx context.Context) (string, error)
+
+	// Expired is called when the tracker rejected the auth code got by
+	// Login, so that a saved one is not used again. It may be nil.
+	Expired func()
+
+	// Trace is called with elapsed time of each request ("fetch" or
+	// "post" with the URL), cache hit ("cache" with the URL) and of
+	// parsing feeds ("parse") if not nil.
+	Trace func(phase, detail string, d time.Duration)
+
+	lazy *lazyAuth // shared by copies of the client
+}
+
+// lazyAuth keep the auth code got by Login.
+type lazyAuth struct {
+	mu   sync.Mutex
+	auth string
+	err  error
+	done bool
+}
+
+// NewClient return client for the project at code.google.com.
+func NewClient(project, auth string) *Client {
+	return &Client{Project: project, Auth: auth, BaseURL: defaultBaseURL, WebURL: defaultBaseURL, lazy: &lazyAuth{}}
+}
+
+// LoggedIn return true if the client can log in, or already did.
+func (c *Client) LoggedIn() bool {
+	return c.Auth != "" || c.Login != nil
+}
+
+// authCode return the auth code, logging in if not yet. Empty means
+// anonymous.
+func (c *Client) authCode(ctx context.Context) (string, error) {
+	if c.Auth != "" || c.Login == nil {
+		return c.Auth, nil
+	}
+	if c.lazy == nil {
+		return c.Login(ctx)
+	}
+	c.lazy.mu.Lock()
+	defer c.lazy.mu.Unlock()
+	if !c.lazy.done {
+		c.lazy.auth, c.lazy.err = c.Login(ctx)
+		if c.lazy.err != nil {
+			c.lazy.err = &authError{c.lazy.err}
+		}
+		c.lazy.done = true
+	}
+	return c.lazy.auth, c.lazy.err
+}
+
+// IssueWebURL return URL of the web page of the issue.
+func (c *Client) IssueWebURL(id string) string {
+	return c.WebURL + "/p/" + c.Project + "/issues/detail?id=" + id
+}
+
+func (c *Client) trace(phase, detail string, t time.Time) {
+	if c.Trace != nil {
+		c.Trace(phase, detail, time.Since(t))
+	}
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTP != nil {
+		return c.HTTP
+	}
+	return http.DefaultClient
+}
+
+// do send the request, and warn if the server say the API is deprecated.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	res, err := c.httpClient().Do(req)
+	if err == nil {
+		checkDeprecation(res.Header)
+		if res.StatusCode == 401 && c.Auth == "" && c.Login != nil && c.Expired != nil {
+			c.Expired()
+		}
+	}
+	return res, err
+}
+
+// deprecationOnce make the deprecation warning shown once in a run.
+var deprecationOnce sync.Once
+
+// checkDeprecation warn once when response headers say the API goissue use
+// is deprecated; by Deprecation or Sunset header, or warning 299.
+func checkDeprecation(h http.Header) {
+	msg := ""
+	if w := h.Get("Warning"); strings.HasPrefix(w, "299 ") {
+		msg = w
+	} else if d := h.Get("Deprecation"); d != "" {
+		msg = "deprecated since " + d
+	}
+	if s := h.Get("Sunset"); s != "" {
+		if msg == "" {
+			msg = "deprecated"
+		}
+		msg += ", removed at " + s
+	}
+	if msg == "" {
+		return
+	}
+	deprecationOnce.Do(func() {
+		warnf("the tracker API is deprecated (%s); update goissue with: goissue selfupdate", msg)
+	})
+}
+
+// IssuesURL return URL of the issues feed.
+func (c *Client) IssuesURL() string {
+	return c.BaseURL + "/feeds/issues/p/" + c.Project + "/issues/full"
+}
+
+// IssueURL return URL of the issue.
+func (c *Client) IssueURL(id string) string {
+	return c.IssuesURL() + "/" + id
+}
+
+// CommentsURL return URL of the comments feed of the issue.
+func (c *Client) CommentsURL(id string) string {
+	return c.BaseURL + "/feeds/issues/p/" + c.Project + "/issues/" + id + "/comments/full"
+}
+
+// UpdatesURL return URL of the feed of recent issue updates and comments
+// of the project.
+func (c *Client) UpdatesURL() string {
+	return c.BaseURL + "/feeds/p/" + c.Project + "/issueupdates/basic"
+}
+
+// Get return body of the uri. Responses are cached to make successive
+// commands fast.
+func (c *Client) Get(ctx context.Context, uri string) ([]byte, error) {
+	b, _, err := c.get(ctx, uri)
+	return b, err
+}
+
+// get return body of the uri, and whether it came from the cache.
+func (c *Client) get(ctx context.Context, uri string) ([]byte, bool, error) {
+	if b, ok := c.Cache.get(uri); ok {
+		debugf("cache hit %s", uri)
+		c.trace("cache", uri, time.Now())
+		return b, true, nil
+	}
+	debugf("GET %s", uri)
+	defer c.trace("fetch", uri, time.Now())
+	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	auth, err := c.authCode(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if auth != "" {
+		req.Header.Set("Authorization", "GoogleLogin "+auth)
+	}
+	req.Header.Set("GData-Version", gdataVersion)
+	res, err := c.do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return nil, false, newAPIError(res)
+	}
+	b, err := c.readBody(res, uri)
+	if err != nil {
+		return nil, false, err
+	}
+	c.Cache.put(uri, b)
+	return b, false, nil
+}
+
+// readBody return the body of the XML response. Responses of other types
+// are refused before parsing, as they are usually error pages of proxies or
+// captive portals; so are responses larger than MaxBody.
+func (c *Client) readBody(res *http.Response, uri string) ([]byte, error) {
+	if ct := res.Header.Get("Content-Type"); ct != "" && !strings.Contains(ct, "xml") {
+		b, _ := ioutil.ReadAll(io.LimitReader(res.Body, 64<<10))
+		msg := errorMessage(ct, b)
+		if msg != "" {
+			msg = ": " + msg
+		}
+		return nil, fmt.Errorf("unexpected %s response from %s (is a proxy or a login page in the way?)%s", ct, uri, msg)
+	}
+	max := c.MaxBody
+	if max <= 0 {
+		max = defaultMaxBody
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(res.Body, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > max {
+		return nil, fmt.Errorf("response from %s is larger than %d bytes; raise max_response_size in your settings.json", uri, max)
+	}
+	return b, nil
+}
+
+// getXML fetch the uri and parse it with decode. A response which can't be
+// parsed is removed from the cache; if it came from the cache, it is taken
+// as broken by a crash and fetched again.
+func (c *Client) getXML(ctx context.Context, uri string, decode func([]byte) error) error {
+	b, cached, err := c.get(ctx, uri)
+	if err != nil {
+		return err
+	}
+	defer c.trace("parse", "", time.Now())
+	err = decode(b)
+	if err == nil {
+		return nil
+	}
+	c.Cache.forget(uri)
+	if !cached {
+		return err
+	}
+	debugf("broken cache of %s: %v", uri, err)
+	if b, _, err = c.get(ctx, uri); err != nil {
+		return err
+	}
+	if err = decode(b); err != nil {
+		c.Cache.forget(uri)
+	}
+	return err
+}
+
+// errAuthRequired is returned when anonymous client try to change issues.
+var errAuthRequired = errors.New("authentication required; set email and password in your settings.json")
+
+// Post send atom entry to the uri.
+func (c *Client) Post(ctx context.Context, uri, body string) (*http.Response, error) {
+	return c.post(ctx, uri, body, "")
+}
+
+// post send atom entry to the uri. If etag is not empty, it is sent as
+// If-Match, so that the server refuse the entry with 412 if the issue is
+// changed since the etag was fetched.
+func (c *Client) post(ctx context.Context, uri, body, etag string) (*http.Response, error) {
+	auth, err := c.authCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if auth == "" {
+		return nil, errAuthRequired
+	}
+	debugf("POST %s", uri)
+	defer c.trace("post", uri, time.Now())
+	req, err := http.NewRequestWithContext(ctx, "POST", uri, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "GoogleLogin "+auth)
+	req.Header.Set("Content-Type", "application/atom+xml")
+	req.Header.Set("GData-Version", gdataVersion)
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+	req.ContentLength = int64(len([]byte(body)))
+	return c.do(req)
+}
+
+// Feed return feed that fetched from uri. Control characters are
+// stripped from fields of entries except contents.
+func (c *Client) Feed(ctx context.Context, uri string) (feed Feed, err error) {
+	err = c.getXML(ctx, uri, func(b []byte) error {
+		feed = Feed{}
+		return xml.Unmarshal(b, &feed)
+	})
+	for i := range feed.Entry {
+		feed.Entry[i].sanitize()
+	}
+	return feed, err
+}
+
+// Entries return all issues that matched to query. The feed is fetched
+// page by page since the server return only part of the results at once.
+// If fetching a page failed, entries fetched so far are returned with the
+// error.
+func (c *Client) Entries(ctx context.Context, query url.Values) ([]Entry, error) {
+	return c.pages(ctx, c.IssuesURL(), query)
+}
+
+// Comments return all comments of the issue, fetched page by page like
+// Entries.
+func (c *Client) Comments(ctx context.Context, id string) ([]Entry, error) {
+	return c.pages(ctx, c.CommentsURL(id), nil)
+}
+
+// pages return entries of the feed at uri, fetching pages with start-index
+// until a page is short.
+func (c *Client) pages(ctx context.Context, uri string, query url.Values) (entries []Entry, err error) {
+	const perPage = 100
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	for start := 1; ; start += perPage {
+		q.Set("start-index", fmt.Sprint(start))
+		q.Set("max-results", fmt.Sprint(perPage))
+		feed, err := c.Feed(ctx, uri+"?"+q.Encode())
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, feed.Entry...)
+		if len(feed.Entry) < perPage {
+			break
+		}
+	}
+	return entries, nil
+}
+
+// Entry return the issue.
+func (c *Client) Entry(ctx context.Context, id string) (entry Entry, err error) {
+	err = c.getXML(ctx, c.IssueURL(id), func(b []byte) error {
+		entry = Entry{}
+		return xml.Unmarshal(b, &entry)
+	})
+	entry.sanitize()
+	return entry, err
+}
+
+// Updates is changes of the issue that is sent with a comment.
+type Updates struct {
+	Status string
+	Owner  string
+	Labels []string // label prefixed with "-" remove the label
+	Cc     []string
+}
+
+// xml return issues:updates element of the changes.
+func (u *Updates) xml() string {
+	if u == nil {
+		return ""
+	}
+	s := "<issues:updates>\n"
+	if u.Status != "" {
+		s += "<issues:status>" + xmlEscape(u.Status) + "</issues:status>\n"
+	}
+	if u.Owner != "" {
+		s += "<issues:ownerUpdate>" + xmlEscape(u.Owner) + "</issues:ownerUpdate>\n"
+	}
+	for _, label := range u.Labels {
+		s += "<issues:label>" + xmlEscape(label) + "</issues:label>\n"
+	}
+	for _, cc := range u.Cc {
+		s += "<issues:ccUpdate>" + xmlEscape(cc) + "</issues:ccUpdate>\n"
+	}
+	return s + "</issues:updates>\n"
+}
+
+// errChanged is returned when the issue was changed since its etag was
+// fetched.
+var errChanged = errors.New("issue changed upstream, refetch and retry")
+
+// PostComment post comment to the issue. If u is not nil, the issue is
+// updated with it. If etag is not empty, the comment is posted only when
+// the issue is not changed since the etag was fetched; the server check it
+// with If-Match, and errChanged is returned for 412 Precondition Failed.
+func (c *Client) PostComment(ctx context.Context, id, from, etag, body string, u *Updates) error {
+	str := fmt.Sprintf("<?xml version='1.0' encoding='UTF-8'?>\n"+
+		"<entry xmlns='http://www.w3.org/2005/Atom' xmlns:issues='http://schemas.google.com/projecthosting/issues/2009'>\n"+
+		"<content type='html'>%s</content>\n"+
+		"<author><name>%s</name></author>\n"+
+		"%s"+
+		"</entry>",
+		xmlEscape(body),
+		xmlEscape(from),
+		u.xml())
+	res, err := c.post(ctx, c.CommentsURL(id), str, etag)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == 412 {
+		return errChanged
+	}
+	if res.StatusCode != 201 {
+		return newAPIError(res)
+	}
+	c.Cache.forget(c.IssueURL(id))
+	c.Cache.forget(c.CommentsURL(id))
+	return nil
+}
+
+// NewIssue is an issue to be created. Empty fields are not sent, so that
+// the project defaults are used for them.
+type NewIssue struct {
+	From   string
+	Title  string
+	Body   string
+	Status string
+	Owner  string
+	Labels []string
+}
+
+// xml return atom entry to create the issue.
+func (issue *NewIssue) xml() string {
+	s := "<?xml version='1.0' encoding='UTF-8'?>\n" +
+		"<entry xmlns='http://www.w3.org/2005/Atom' xmlns:issues='http://schemas.google.com/projecthosting/issues/2009'>\n" +
+		"<title>" + xmlEscape(issue.Title) + "</title>\n" +
+		"<content type='html'>" + xmlEscape(issue.Body) + "</content>\n" +
+		"<author><name>" + xmlEscape(issue.From) + "</name></author>\n"
+	if issue.Status != "" {
+		s += "<issues:status>" + xmlEscape(issue.Status) + "</issues:status>\n"
+	}
+	if issue.Owner != "" {
+		s += "<issues:owner><issues:username>" + xmlEscape(issue.Owner) + "</issues:username></issues:owner>\n"
+	}
+	for _, label := range issue.Labels {
+		s += "<issues:label>" + xmlEscape(label) + "</issues:label>\n"
+	}
+	return s + "</entry>"
+}
+
+// CreateIssue create the issue and return the created entry.
+func (c *Client) CreateIssue(ctx context.Context, issue *NewIssue) (entry Entry, err error) {
+	res, err := c.Post(ctx, c.IssuesURL(), issue.xml())
+	if err != nil {
+		return entry, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 201 {
+		return entry, newAPIError(res)
+	}
+	b, err := c.readBody(res, c.IssuesURL())
+	if err != nil {
+		return entry, err
+	}
+	err = xml.Unmarshal(b, &entry)
+	entry.sanitize()
+	return entry, err
+}
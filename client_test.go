@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+const testFeed = `<?xml version='1.0' encoding='UTF-8'?>
+<feed xmlns='http://www.w3.org/2005/Atom'>
+<entry><id>http://code.google.com/feeds/issues/p/go/issues/full/1</id><title>first</title></entry>
+<entry><id>http://code.google.com/feeds/issues/p/go/issues/full/2</id><title>second</title></entry>
+</feed>`
+
+const testEntry = `<?xml version='1.0' encoding='UTF-8'?>
+<entry xmlns='http://www.w3.org/2005/Atom'>
+<id>http://code.google.com/feeds/issues/p/go/issues/full/1</id><title>first</title>
+</entry>`
+
+func newTestClient(t *testing.T) (*Client, func()) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "GoogleLogin token" {
+			w.WriteHeader(403)
+			return
+		}
+		switch r.URL.Path {
+		case "/feeds/issues/p/go/issues/full":
+			fmt.Fprint(w, testFeed)
+		case "/feeds/issues/p/go/issues/full/1":
+			fmt.Fprint(w, testEntry)
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	dir, err := ioutil.TempDir("", "goissue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient("go", "token")
+	c.BaseURL = ts.URL
+	c.Cache = &Cache{Dir: dir, TTL: time.Minute, MaxSize: 1 << 20}
+	return c, func() {
+		ts.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestClientConcurrent(t *testing.T) {
+	c, done := newTestClient(t)
+	defer done()
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entries, err := c.Entries(ctx, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if len(entries) != 2 {
+				t.Errorf("want 2 entries, got %d", len(entries))
+			}
+			entry, err := c.Entry(ctx, "1")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if entry.Title != "first" {
+				t.Errorf("want %q, got %q", "first", entry.Title)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestClientComments(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.FormValue("start-index"))
+		max, _ := strconv.Atoi(r.FormValue("max-results"))
+		fmt.Fprint(w, "<?xml version='1.0' encoding='UTF-8'?><feed xmlns='http://www.w3.org/2005/Atom'>")
+		for i := start; i < start+max && i <= 250; i++ {
+			fmt.Fprintf(w, "<entry><title>%d</title></entry>", i)
+		}
+		fmt.Fprint(w, "</feed>")
+	}))
+	defer ts.Close()
+	c := NewClient("go", "token")
+	c.BaseURL = ts.URL
+
+	comments, err := c.Comments(context.Background(), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 250 {
+		t.Fatalf("want 250 comments, got %d", len(comments))
+	}
+	if comments[249].Title != "250" {
+		t.Errorf("want %q, got %q", "250", comments[249].Title)
+	}
+}
+
+func TestClientNotFound(t *testing.T) {
+	c, done := newTestClient(t)
+	defer done()
+
+	if _, err := c.Entry(context.Background(), "999"); err == nil {
+		t.Error("want error for missing issue")
+	}
+}
+
+func TestClientBrokenCache(t *testing.T) {
+	c, done := newTestClient(t)
+	defer done()
+
+	ctx := context.Background()
+	if _, err := c.Entry(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	file := c.Cache.file(c.IssueURL("1"))
+	header := fmt.Sprintf("%d\n", time.Now().Unix())
+	for _, broken := range []string{
+		"",
+		header + "\x1f\x8b\x08",               // truncated gzip
+		header + testEntry[:len(testEntry)/2], // truncated body
+	} {
+		if err := ioutil.WriteFile(file, []byte(broken), 0600); err != nil {
+			t.Fatal(err)
+		}
+		entry, err := c.Entry(ctx, "1")
+		if err != nil {
+			t.Errorf("Entry with cache %q: %v", broken, err)
+			continue
+		}
+		if entry.Title != "first" {
+			t.Errorf("want %q, got %q", "first", entry.Title)
+		}
+		if b, ok := c.Cache.get(c.IssueURL("1")); !ok || string(b) != testEntry {
+			t.Errorf("cache is not restored from %q", broken)
+		}
+	}
+}
+
+func TestRenderedCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goissue-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c := &Client{Cache: &Cache{Dir: dir, TTL: time.Minute}}
+
+	entry := Entry{Id: "1", Updated: "2012-05-01T00:00:00Z", Content: "hello"}
+	want, err := renderEntry(c, entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the cached text is used while the entry is not updated.
+	c.Cache.putRendered(entry, c.Options, "cached")
+	if got, _ := renderEntry(c, entry); got != "cached" {
+		t.Errorf("renderEntry of the same entry = %q, want the cached text", got)
+	}
+	entry.Updated = "2012-05-02T00:00:00Z"
+	if got, _ := renderEntry(c, entry); got != want {
+		t.Errorf("renderEntry of the updated entry = %q, want %q", got, want)
+	}
+}
+
+func TestCommentsFeed(t *testing.T) {
+	var queries []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		fmt.Fprint(w, `<?xml version='1.0' encoding='UTF-8'?>
+<feed xmlns='http://www.w3.org/2005/Atom' xmlns:openSearch='http://a9.com/-/spec/opensearch/1.1/'>
+<openSearch:totalResults>300</openSearch:totalResults>
+<entry><id>http://code.google.com/feeds/issues/p/go/issues/1/comments/full/300</id></entry>
+</feed>`)
+	}))
+	defer ts.Close()
+	c := NewClient("go", "token")
+	c.BaseURL = ts.URL
+
+	if _, err := commentsFeed(context.Background(), c, "1", 5); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"max-results=1", "start-index=296&max-results=5"}
+	if fmt.Sprint(queries) != fmt.Sprint(want) {
+		t.Errorf("commentsFeed requested %q, want %q", queries, want)
+	}
+}
+
+func TestClientUnexpectedResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/feeds/issues/p/go/issues/full":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, "<html><body><h1>Sign in to the network</h1></body></html>")
+		default:
+			fmt.Fprint(w, testEntry)
+		}
+	}))
+	defer ts.Close()
+	c := NewClient("go", "token")
+	c.BaseURL = ts.URL
+
+	_, err := c.Feed(context.Background(), c.IssuesURL())
+	if err == nil || !strings.Contains(err.Error(), "Sign in to the network") {
+		t.Errorf("Feed of html response = %v, want error with the page text", err)
+	}
+	c.MaxBody = 10
+	if _, err := c.Entry(context.Background(), "1"); err == nil || !strings.Contains(err.Error(), "larger than 10 bytes") {
+		t.Errorf("Entry of large response = %v, want error about the size", err)
+	}
+	c.MaxBody = 0
+	if _, err := c.Entry(context.Background(), "1"); err != nil {
+		t.Errorf("Entry = %v", err)
+	}
+}
+
+func TestClientSanitize(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<?xml version='1.0' encoding='UTF-8'?>\n"+
+			"<entry xmlns='http://www.w3.org/2005/Atom' xmlns:issues='http://schemas.google.com/projecthosting/issues/2009'>\n"+
+			"<id>http://code.google.com/feeds/issues/p/go/issues/full/1</id><title>\u009b2Jhostile\u007f title</title>\n"+
+			"<author><name>mal\u009d0;x\u009clory</name></author><issues:label>Type-\u009b31mDefect</issues:label>\n"+
+			"</entry>")
+	}))
+	defer ts.Close()
+	c := NewClient("go", "token")
+	c.BaseURL = ts.URL
+
+	entry, err := c.Entry(context.Background(), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Title != "hostile title" {
+		t.Errorf("title = %q, want %q", entry.Title, "hostile title")
+	}
+	if got := authorName(entry); got != "mal0;xlory" {
+		t.Errorf("author = %q, want %q", got, "mal0;xlory")
+	}
+	if got := entry.IssuesLabel[0]; got != "Type-Defect" {
+		t.Errorf("label = %q, want %q", got, "Type-Defect")
+	}
+}
+
+func TestPostCommentEtag(t *testing.T) {
+	var posts []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts = append(posts, r.Header.Get("If-Match"))
+		if m := r.Header.Get("If-Match"); m != "" && m != `W/"current"` {
+			w.WriteHeader(412)
+			return
+		}
+		w.WriteHeader(201)
+	}))
+	defer ts.Close()
+	c := NewClient("go", "token")
+	c.BaseURL = ts.URL
+
+	ctx := context.Background()
+	if err := c.PostComment(ctx, "1", "gopher", `W/"old"`, "", &Updates{Status: "Fixed"}); err != errChanged {
+		t.Errorf("PostComment with old etag = %v, want errChanged", err)
+	}
+	if err := c.PostComment(ctx, "1", "gopher", `W/"current"`, "", &Updates{Status: "Fixed"}); err != nil {
+		t.Errorf("PostComment with current etag = %v", err)
+	}
+	if err := c.PostComment(ctx, "1", "gopher", "", "hi", nil); err != nil {
+		t.Errorf("PostComment without etag = %v", err)
+	}
+	if want := []string{`W/"old"`, `W/"current"`, ""}; strings.Join(posts, ",") != strings.Join(want, ",") {
+		t.Errorf("If-Match of posts = %q, want %q", posts, want)
+	}
+}
+
+func TestErrorMessageTruncate(t *testing.T) {
+	msg := errorMessage("text/plain", []byte("x"+strings.Repeat("権限", 200)))
+	if !utf8.ValidString(msg) || !strings.HasSuffix(msg, "...") || len(msg) > 503 {
+		t.Errorf("errorMessage = %q", msg)
+	}
+}
+
+func TestCacheMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goissue-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c := &Cache{Dir: dir, TTL: time.Minute}
+	hits, misses := metricCacheHits.Value(), metricCacheMisses.Value()
+	c.get("http://example.com/a")
+	c.put("http://example.com/a", []byte("a"))
+	c.get("http://example.com/a")
+	c.get("http://example.com/a")
+	if h, m := metricCacheHits.Value()-hits, metricCacheMisses.Value()-misses; h != 2 || m != 1 {
+		t.Errorf("hits, misses = %d, %d; want 2, 1", h, m)
+	}
+	if r := cacheHitRatio().(float64); r <= 0 || r > 1 {
+		t.Errorf("cacheHitRatio = %v", r)
+	}
+}
+
+func TestCacheEvict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goissue-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	body := []byte(strings.Repeat("x", 100))
+	c := &Cache{Dir: dir, TTL: time.Minute}
+	c.put("a", body)
+	size := c.size
+	c.MaxSize = size * 2
+	c.put("b", body)
+	if !c.counted || c.size != size*2 {
+		t.Fatalf("size = %d, counted = %v; want %d counted", c.size, c.counted, size*2)
+	}
+	// a file written by other goissue is found when the size is over.
+	other, _ := ioutil.ReadFile(c.file("a"))
+	ioutil.WriteFile(filepath.Join(dir, "other"), other, 0600)
+	if c.put("c", body); len(c.files()) != 2 {
+		t.Errorf("%d files are left, want 2", len(c.files()))
+	}
+	if c.size > c.MaxSize {
+		t.Errorf("size = %d after eviction, over %d", c.size, c.MaxSize)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// draftDir return directory that keep comments in progress, in the
+// directory of files edited in the text editor.
+func draftDir(config *Config) string {
+	return filepath.Join(tempDir(config), "drafts")
+}
+
+// draftFile return path of the comment draft for the issue.
+func draftFile(config *Config, project, id string) string {
+	return filepath.Join(draftDir(config), project+"-"+id+".txt")
+}
+
+// commentFlags is flags of comment.
+type commentFlags struct {
+	message string
+	split   bool
+}
+
+// register add flags of comment to the flag set.
+func (o *commentFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.message, "m", "", "comment text")
+	fs.BoolVar(&o.split, "split", false, "split too long comment into sequential comments without asking")
+}
+
+// commentIssue post comment to the issue. Without -m, the comment is
+// written in the text editor and kept as a draft until it is posted, so
+// that long comments can be written over multiple sessions.
+func commentIssue(ctx context.Context, config *Config, c *Client, args []string) {
+	var o commentFlags
+	fs := newFlagSet("comment")
+	o.register(fs)
+	rest := parseFlags(fs, args)
+	if len(rest) != 1 {
+		fmt.Fprint(os.Stderr, "Usage: goissue comment [-m MESSAGE] [-split] ID\n")
+		fs.PrintDefaults()
+		exit(exitUsage)
+	}
+	id := rest[0]
+
+	text := o.message
+	draft := draftFile(config, c.Project, id)
+	drafted := text == ""
+	if drafted {
+		if b, err := ioutil.ReadFile(draft); err == nil {
+			infof("resuming draft %s", draft)
+			text = toLF(string(b))
+		}
+		edited, file, err := editText(config, text)
+		if file != "" {
+			defer os.Remove(file)
+		}
+		if err != nil {
+			fatal("failed to edit comment:", err)
+		}
+		if err := os.MkdirAll(draftDir(config), 0700); err != nil {
+			fatal("failed to save draft:", err)
+		}
+		if err := ioutil.WriteFile(draft, []byte(edited), 0600); err != nil {
+			fatal("failed to save draft:", err)
+		}
+		if strings.TrimSpace(edited) == "" {
+			os.Remove(draft)
+			fatal("comment is empty")
+		}
+		if !confirm("post the comment now? (no keeps it as a draft)") {
+			infof("draft saved in %s", draft)
+			return
+		}
+		text = edited
+	}
+
+	text = formatTraces(text)
+	if !checkSecrets(config, text) {
+		fatal("canceled")
+	}
+	if strings.HasPrefix(id, provisionalPrefix) {
+		// the issue is created offline; post with it.
+		item := &outboxItem{Kind: "comment", Project: c.Project, Id: id, From: config.Email, Body: text}
+		if _, err := item.queue(); err != nil {
+			if drafted {
+				fatalf("failed to save comment: %v (draft is kept in %s)", err, draft)
+			}
+			fatal("failed to save comment:", err)
+		}
+		if drafted {
+			os.Remove(draft)
+		}
+		infof("comment to %s saved in outbox", id)
+		return
+	}
+	parts, err := guardSize(config, text, o.split)
+	if err != nil {
+		if drafted {
+			fatalf("failed to post comment: %v (draft is kept in %s)", err, draft)
+		}
+		fatal("failed to post comment:", err)
+	}
+	for i, part := range parts {
+		if err := c.PostComment(ctx, id, config.Email, "", part, nil); err != nil {
+			// parts not posted yet are queued together.
+			item := &outboxItem{Kind: "comment", Project: c.Project, Id: id, From: config.Email, Body: part}
+			if msg := queueOnFailure(item, err); msg != "" {
+				for _, p := range parts[i+1:] {
+					if _, err := (&outboxItem{Kind: "comment", Project: c.Project, Id: id, From: config.Email, Body: p}).queue(); err != nil {
+						if drafted {
+							fatal("failed to save to outbox:", err, " (draft is kept in "+draft+")")
+						}
+						fatal("failed to save to outbox:", err)
+					}
+				}
+				if drafted {
+					os.Remove(draft)
+				}
+				fatal("failed to post comment:", err, msg)
+			}
+			if drafted {
+				fatalf("failed to post comment: %v (%d of %d parts posted; parts not posted are kept in %s)", err, i, len(parts), draft)
+			}
+			fatalf("failed to post comment: %v (%d of %d parts posted)", err, i, len(parts))
+		}
+		if drafted {
+			// the draft keep only parts not posted yet, so that resuming it
+			// doesn't post the posted parts again.
+			if err := ioutil.WriteFile(draft, []byte(strings.Join(parts[i+1:], "\n\n")), 0600); err != nil {
+				warnf("failed to update draft: %v", err)
+			}
+		}
+	}
+	if drafted {
+		os.Remove(draft)
+	}
+	infof("comment posted to issue %s", id)
+}
+
+// draftsFlags is flags of drafts.
+type draftsFlags struct {
+	discard string
+}
+
+// register add flags of drafts to the flag set.
+func (o *draftsFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.discard, "discard", "", "discard the draft of the issue")
+}
+
+// showDrafts list comment drafts, and discard one with -discard.
+func showDrafts(ctx context.Context, config *Config, c *Client, args []string) {
+	var o draftsFlags
+	fs := newFlagSet("drafts")
+	o.register(fs)
+	fs.Parse(args)
+
+	if o.discard != "" {
+		if err := os.Remove(draftFile(config, c.Project, o.discard)); err != nil {
+			fatal("failed to discard draft:", err)
+		}
+		return
+	}
+	fis, _ := ioutil.ReadDir(draftDir(config))
+	for _, fi := range fis {
+		name := strings.TrimSuffix(fi.Name(), ".txt")
+		if !strings.HasPrefix(name, c.Project+"-") {
+			continue
+		}
+		b, _ := ioutil.ReadFile(filepath.Join(draftDir(config), fi.Name()))
+		line := strings.SplitN(strings.TrimSpace(string(b)), "\n", 2)[0]
+		fmt.Printf("%s: %s %s\n", name[len(c.Project)+1:], fi.ModTime().Format("2006-01-02 15:04"), line)
+	}
+	// issue drafts kept by failed create.
+	issues, _ := filepath.Glob(filepath.Join(tempDir(config), "goissue-*.txt"))
+	for _, file := range issues {
+		fmt.Println("issue draft: " + file)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// userFlags is flags taking a user name, which are completed with members.
+var userFlags = []string{"-owner", "-comment-by", "-user", "-author"}
+
+const bashCompletion = `# bash completion of goissue: eval "$(goissue completion bash)"
+_goissue() {
+	local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+	case $prev in
+	%s)
+		COMPREPLY=($(goissue members -prefix "$cur" 2>/dev/null))
+		return;;
+	esac
+	if [ "$COMP_CWORD" -eq 1 ]; then
+		COMPREPLY=($(compgen -W "%s" -- "$cur"))
+	fi
+}
+complete -F _goissue goissue
+`
+
+const zshCompletion = `#compdef goissue
+# zsh completion of goissue: eval "$(goissue completion zsh)"
+_goissue() {
+	local -a names
+	case $words[CURRENT-1] in
+	%s)
+		names=(${(f)"$(goissue members -prefix "$PREFIX" 2>/dev/null)"})
+		compadd -a names
+		return;;
+	esac
+	if (( CURRENT == 2 )); then
+		compadd -- %s
+	fi
+}
+compdef _goissue goissue
+`
+
+// writeCompletion write completion script of the shell, which complete
+// commands, and user names by goissue members.
+func writeCompletion(w io.Writer, shell string) error {
+	var script string
+	switch shell {
+	case "bash":
+		script = bashCompletion
+	case "zsh":
+		script = zshCompletion
+	default:
+		return fmt.Errorf("unknown shell: %s (valid: bash, zsh)", shell)
+	}
+	var names []string
+	for _, d := range commandTable {
+		names = append(names, d.name)
+	}
+	_, err := fmt.Fprintf(w, script, strings.Join(userFlags, "|"), strings.Join(names, " "))
+	return err
+}
+
+// showCompletion print completion script: goissue completion bash|zsh.
+func showCompletion(args []string) {
+	fs := newFlagSet("completion")
+	args = parseFlags(fs, args)
+	if len(args) != 1 {
+		fs.Usage()
+		exit(exitUsage)
+	}
+	if err := writeCompletion(os.Stdout, args[0]); err != nil {
+		fmt.Fprint(os.Stderr, err.Error()+"\n")
+		fs.Usage()
+		exit(exitUsage)
+	}
+}
@@ -0,0 +1,557 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is settings of goissue, read from settings.json and the other
+// settings files. Fields not given in the files have the defaults of
+// settingDefs. JSON values like "routes" are kept as JSON text and decoded
+// by the commands using them.
+type Config struct {
+	Email           string
+	Password        string
+	PasswordCommand string
+	TokenCommand    string
+	SaveToken       bool
+	Project         string
+	Projects        []string
+	DefaultCommand  string
+	BaseURL         string
+	WebURL          string
+	LoginURL        string
+	UpdateURL       string
+	CacheTTL        time.Duration
+	CacheMaxSize    int64
+	MaxResponseSize int64
+	LogLevel        string
+	LogFile         string
+	Lang            string
+	Plain           bool
+	TmpDir          string
+	Templates       string
+	NewIssue        json.RawMessage
+	Statuses        []string
+	Labels          []string
+	Members         []string
+	SecretPatterns  json.RawMessage
+	Lint            json.RawMessage
+	SpellCommand    string
+	StateFile       string
+	MaxCommentSize  int
+	WatchCommand    string
+	BlockerLabel    string
+	Priorities      []string
+	AreaOwners      json.RawMessage
+	EscalateComment string
+	Routes          json.RawMessage
+	Dashboard       json.RawMessage
+	SLA             json.RawMessage
+}
+
+// settings is values of settings files keyed as in settings.json, which
+// are merged and checked before made into Config. Values are kept as
+// written; JSON values other than strings are kept as JSON text.
+type settings map[string]string
+
+// setting is definition of a key of settings.json.
+type setting struct {
+	key   string
+	kind  string // string, bool, int, duration, size, list (comma separated) or json
+	def   string // default value; empty means unset
+	usage string
+}
+
+// settingDefs is all keys of settings.json.
+var settingDefs = []setting{
+	{"email", "string", "", "account to log in with; without it, goissue access anonymously"},
+	{"password", "string", "", "password of the account"},
+	{"password_command", "string", "", "command that print the password, like \"pass show google\""},
+	{"token_command", "string", "", "command that print the auth token, used instead of logging in"},
+	{"save_token", "bool", "true", "save the auth token in token.json (not encrypted) for 12 hours"},
+	{"project", "string", "go", "project on the tracker"},
+	{"projects", "list", "", "projects used by -project all and sync -all-projects"},
+	{"default_command", "string", "", "command run by bare goissue, like \"inbox\""},
+	{"base_url", "string", "", "URL of the tracker API"},
+	{"web_url", "string", "", "URL of the web pages of the tracker"},
+	{"login_url", "string", "", "URL to log in"},
+	{"update_url", "string", "", "URL of releases for selfupdate"},
+	{"cache_ttl", "duration", "60s", "duration that responses are cached for"},
+	{"cache_max_size", "size", "", "maximum size of the cache, like 100M"},
+	{"max_response_size", "size", "32M", "maximum size of responses from the tracker"},
+	{"log_level", "string", "", "error, warn, info or debug"},
+	{"log_file", "string", "", "file that log messages are appended to"},
+	{"lang", "string", "", "language of messages and templates, like ja"},
+	{"plain", "bool", "false", "print linear text for screen readers"},
+	{"tmpdir", "string", "", "directory that issues and comments are edited in"},
+	{"templates", "string", "", "directory of templates of new issues; templates in the settings directory by default"},
+	{"new_issue", "json", "", "defaults of new issues"},
+	{"statuses", "list", "", "statuses accepted in new issues"},
+	{"labels", "list", "", "labels accepted in new issues"},
+	{"members", "list", "", "members of the project"},
+	{"secret_patterns", "json", "", "regular expressions of secrets checked before posting"},
+	{"lint", "json", "", "rules checked on the body of new issues, like [{\"min_length\": 80, \"level\": \"error\"}]"},
+	{"spell_command", "string", "", "command that print misspelled words, like \"aspell list\""},
+	{"state_file", "string", "", "file shared between machines that state merges read marks and lists of issues with"},
+	{"max_comment_size", "int", "50000", "bytes over which comments are split"},
+	{"watch_command", "string", "", "command run by watch for each event, given the event in JSON on stdin"},
+	{"blocker_label", "string", "", "label of issues blocking a milestone"},
+	{"priorities", "list", "Priority-Low,Priority-Medium,Priority-High,Priority-Critical", "priority labels from low to high, raised by escalate"},
+	{"area_owners", "json", "", "owners cc'd by escalate by label, like {\"OS-Windows\": [\"alex\"]}"},
+	{"escalate_comment", "string", "", "comment posted by escalate; $from, $to, $reason and $cc are replaced"},
+	{"routes", "json", "", "rules giving labels, owner and cc to issues mentioning keywords, used by triage and create"},
+	{"dashboard", "json", "", "named filters shown by dashboard, like [{\"name\": \"Mine\", \"filters\": \"-owner me\", \"top\": 5}]"},
+	{"sla", "json", "", "how long open issues with the label may go without update, like {\"Priority-Critical\": \"3d\"}"},
+}
+
+// lookupSetting return definition of the key, or nil.
+func lookupSetting(key string) *setting {
+	for i := range settingDefs {
+		if settingDefs[i].key == key {
+			return &settingDefs[i]
+		}
+	}
+	return nil
+}
+
+// check return error naming the key if value is not of the kind.
+func (s *setting) check(value string) error {
+	var err error
+	switch s.kind {
+	case "bool":
+		_, err = strconv.ParseBool(value)
+	case "int":
+		var n int
+		n, err = strconv.Atoi(value)
+		if err == nil && n <= 0 {
+			err = errors.New("must be positive")
+		}
+	case "duration":
+		_, err = time.ParseDuration(value)
+	case "size":
+		_, err = parseSize(value)
+	case "json":
+		if !json.Valid([]byte(value)) {
+			err = errors.New("must be a JSON object or array")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s in your settings.json: %q is not a %s: %v", s.key, value, s.kind, err)
+	}
+	return nil
+}
+
+// config return the settings as Config. Values must be checked by check,
+// which also set the defaults.
+func (s settings) config() *Config {
+	flag := func(key string) bool {
+		b, _ := strconv.ParseBool(s[key])
+		return b
+	}
+	size := func(key string) int64 {
+		n, _ := parseSize(s[key])
+		return n
+	}
+	raw := func(key string) json.RawMessage {
+		if v, ok := s[key]; ok {
+			return json.RawMessage(v)
+		}
+		return nil
+	}
+	ttl, _ := time.ParseDuration(s["cache_ttl"])
+	max, _ := strconv.Atoi(s["max_comment_size"])
+	return &Config{
+		Email:           s["email"],
+		Password:        s["password"],
+		PasswordCommand: s["password_command"],
+		TokenCommand:    s["token_command"],
+		SaveToken:       flag("save_token"),
+		Project:         s["project"],
+		Projects:        splitList(s["projects"]),
+		DefaultCommand:  s["default_command"],
+		BaseURL:         s["base_url"],
+		WebURL:          s["web_url"],
+		LoginURL:        s["login_url"],
+		UpdateURL:       s["update_url"],
+		CacheTTL:        ttl,
+		CacheMaxSize:    size("cache_max_size"),
+		MaxResponseSize: size("max_response_size"),
+		LogLevel:        s["log_level"],
+		LogFile:         s["log_file"],
+		Lang:            s["lang"],
+		Plain:           flag("plain"),
+		TmpDir:          s["tmpdir"],
+		Templates:       s["templates"],
+		NewIssue:        raw("new_issue"),
+		Statuses:        splitList(s["statuses"]),
+		Labels:          splitList(s["labels"]),
+		Members:         splitList(s["members"]),
+		SecretPatterns:  raw("secret_patterns"),
+		Lint:            raw("lint"),
+		SpellCommand:    s["spell_command"],
+		StateFile:       s["state_file"],
+		MaxCommentSize:  max,
+		WatchCommand:    s["watch_command"],
+		BlockerLabel:    s["blocker_label"],
+		Priorities:      splitList(s["priorities"]),
+		AreaOwners:      raw("area_owners"),
+		EscalateComment: s["escalate_comment"],
+		Routes:          raw("routes"),
+		Dashboard:       raw("dashboard"),
+		SLA:             raw("sla"),
+	}
+}
+
+// configNames is names of the settings file, in the order looked up.
+var configNames = []string{"settings.json", "settings.toml", "settings.yaml", "settings.yml"}
+
+// configFile return path of the settings file; settings.json, or
+// settings.toml or settings.yaml if it exists instead.
+func configFile() string {
+	for _, name := range configNames {
+		file := filepath.Join(configDir(), name)
+		if _, err := os.Stat(file); err == nil {
+			return file
+		}
+	}
+	return filepath.Join(configDir(), configNames[0])
+}
+
+// localConfigName is the name of settings file in a repository, which is
+// shared by the team.
+const localConfigName = ".goissue"
+
+// personalKeys is settings not taken from the repository.
+var personalKeys = []string{"email", "password", "password_command", "token_command"}
+
+// getConfig return settings for the project, or exit with the error.
+// project may be empty.
+func getConfig(project string) *Config {
+	config, err := readConfig(project)
+	if err != nil {
+		fatal(err)
+	}
+	return config
+}
+
+// readConfig read settings merged from the settings file, the settings of
+// the project in projects/<project>.json (or .toml, .yaml) and .goissue
+// in the current directory or its parents, in this order; later ones
+// override keys of earlier ones. project overrides "project" of the files
+// if not empty. Lines of settings.json may have comments starting with
+// "//". Unknown keys are warned, and defaults are set for missing keys.
+// Without the settings file, goissue access anonymously with defaults.
+func readConfig(project string) (*Config, error) {
+	values, err := readConfigFile(configFile())
+	if os.IsNotExist(err) {
+		values, err = settings{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var local settings
+	if file := findLocalConfig(); file != "" {
+		if local, err = readConfigFile(file); err != nil {
+			return nil, err
+		}
+		for _, k := range personalKeys {
+			if _, ok := local[k]; ok {
+				warnf("%s in %s is ignored; keep it in %s", k, file, configFile())
+				delete(local, k)
+			}
+		}
+		resolvePaths(local, filepath.Dir(file))
+	}
+
+	if project == "" {
+		project = local["project"]
+	}
+	if project == "" {
+		project = values["project"]
+	}
+	if file := projectConfigFile(project); file != "" {
+		pv, err := readConfigFile(file)
+		if err != nil {
+			return nil, err
+		}
+		values.merge(pv)
+	}
+	values.merge(local)
+	if project != "" {
+		values["project"] = project
+	}
+	if err := values.check(); err != nil {
+		return nil, err
+	}
+	config := values.config()
+
+	// the password may be encrypted in credentials.enc instead.
+	sealed, err := readSealed(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.Email == "" && sealed != nil {
+		config.Email = sealed.Email
+	}
+	return config, nil
+}
+
+// configFiles return files the settings of the project are read from, in
+// the order merged.
+func configFiles(project string) []string {
+	var files []string
+	if _, err := os.Stat(configFile()); err == nil {
+		files = append(files, configFile())
+	}
+	if file := projectConfigFile(project); file != "" {
+		files = append(files, file)
+	}
+	if file := findLocalConfig(); file != "" {
+		files = append(files, file)
+	}
+	return files
+}
+
+// readConfigFile read a settings file. The error of a missing file is
+// returned as is, so that os.IsNotExist tell it.
+func readConfigFile(file string) (settings, error) {
+	b, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.New("failed to read file " + file + ": " + err.Error())
+	}
+	ext := filepath.Ext(file)
+	if filepath.Base(file) == localConfigName {
+		ext = guessFormat(b)
+	}
+	values, err := decodeConfig(ext, b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return values, nil
+}
+
+// guessFormat return extension of the format that b is written in; JSON
+// if it start with "{", TOML if it has "key = value", or YAML.
+func guessFormat(b []byte) string {
+	for _, line := range strings.Split(toLF(string(b)), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//"):
+			continue
+		case strings.HasPrefix(line, "{"):
+			return ".json"
+		case strings.HasPrefix(line, "["):
+			return ".toml"
+		}
+		if eq := strings.Index(line, "="); eq > 0 && yamlColon(line[:eq]) < 0 {
+			return ".toml"
+		}
+		return ".yaml"
+	}
+	return ".yaml"
+}
+
+// findLocalConfig return path of .goissue in the current directory or the
+// nearest parent, or empty.
+func findLocalConfig() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		file := filepath.Join(dir, localConfigName)
+		if st, err := os.Stat(file); err == nil && !st.IsDir() {
+			return file
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// projectConfigFile return path of settings of the project, or empty.
+func projectConfigFile(project string) string {
+	if project == "" {
+		return ""
+	}
+	for _, name := range configNames {
+		file := filepath.Join(configDir(), "projects", project+filepath.Ext(name))
+		if _, err := os.Stat(file); err == nil {
+			return file
+		}
+	}
+	return ""
+}
+
+// pathKeys is settings of paths, which are relative to the file in .goissue.
+var pathKeys = []string{"templates", "tmpdir"}
+
+// resolvePaths make relative paths in the settings relative to dir.
+func resolvePaths(values settings, dir string) {
+	for _, k := range pathKeys {
+		if v, ok := values[k]; ok && v != "" && !filepath.IsAbs(v) {
+			values[k] = filepath.Join(dir, v)
+		}
+	}
+}
+
+// merge set keys of o into s.
+func (s settings) merge(o settings) {
+	for k, v := range o {
+		s[k] = v
+	}
+}
+
+// decodeConfig decode settings written in the format of the extension.
+func decodeConfig(ext string, b []byte) (settings, error) {
+	config := settings{}
+	if ext == ".json" {
+		var values map[string]json.RawMessage
+		err := json.Unmarshal(stripComments(b), &values)
+		if err != nil {
+			return nil, errors.New("failed to unmarhal settings.json: " + err.Error())
+		}
+		for k, v := range values {
+			var s string
+			if json.Unmarshal(v, &s) == nil {
+				config[k] = s
+			} else {
+				config[k] = string(v)
+			}
+		}
+		joinLists(config)
+		return config, nil
+	}
+
+	var values map[string]interface{}
+	var err error
+	if ext == ".toml" {
+		values, err = parseTOML(b)
+	} else {
+		values, err = parseYAML(b)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range values {
+		switch v := v.(type) {
+		case string:
+			config[k] = v
+			continue
+		case numberText:
+			config[k] = string(v)
+			continue
+		}
+		// keep other values as JSON text like in settings.json.
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", k, err)
+		}
+		config[k] = string(b)
+	}
+	joinLists(config)
+	return config, nil
+}
+
+// joinLists make lists written as arrays, like ["go", "gofrontend"], comma
+// separated.
+func joinLists(values settings) {
+	for k, v := range values {
+		var list []string
+		if s := lookupSetting(k); s != nil && s.kind == "list" && json.Unmarshal([]byte(v), &list) == nil {
+			values[k] = strings.Join(list, ",")
+		}
+	}
+}
+
+// check validate keys and values of the settings, and set defaults.
+func (s settings) check() error {
+	for k, v := range s {
+		d := lookupSetting(k)
+		if d == nil {
+			warnf("unknown key %q in your settings.json", k)
+			continue
+		}
+		if err := d.check(v); err != nil {
+			return err
+		}
+	}
+	s.setDefaults()
+
+	// without email and password, goissue access the tracker anonymously.
+	_, hasEmail := s["email"]
+	_, hasPassword := s["password"]
+	_, hasCommand := s["password_command"]
+	if hasEmail && !hasPassword && !hasCommand {
+		if _, err := os.Stat(credentialsFile()); err != nil {
+			return errors.New("failed to get password from your settings.json")
+		}
+	}
+	if hasPassword && !hasEmail {
+		return errors.New("failed to get email from your settings.json")
+	}
+	return nil
+}
+
+// setDefaults set the defaults of keys not in the settings.
+func (s settings) setDefaults() {
+	for _, d := range settingDefs {
+		if _, ok := s[d.key]; !ok && d.def != "" {
+			s[d.key] = d.def
+		}
+	}
+}
+
+// defaultConfig return Config of the defaults, as if settings.json is {}.
+func defaultConfig() *Config {
+	s := settings{}
+	s.setDefaults()
+	return s.config()
+}
+
+// stripComments remove comments from "//" to the end of line outside of
+// strings in JSON text.
+func stripComments(b []byte) []byte {
+	var out bytes.Buffer
+	inString, escaped := false, false
+	for i := 0; i < len(b); i++ {
+		ch := b[i]
+		switch {
+		case inString:
+			if escaped {
+				escaped = false
+			} else if ch == '\\' {
+				escaped = true
+			} else if ch == '"' {
+				inString = false
+			}
+		case ch == '"':
+			inString = true
+		case ch == '/' && i+1 < len(b) && b[i+1] == '/':
+			for i < len(b) && b[i] != '\n' {
+				i++
+			}
+			if i < len(b) {
+				out.WriteByte('\n')
+			}
+			continue
+		}
+		out.WriteByte(ch)
+	}
+	return out.Bytes()
+}
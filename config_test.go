@@ -0,0 +1,222 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStripComments(t *testing.T) {
+	in := "{\n// account\n\"email\": \"a@example.com\", // mine\n\"web_url\": \"http://example.com//x\"\n}"
+	want := "{\n\n\"email\": \"a@example.com\", \n\"web_url\": \"http://example.com//x\"\n}"
+	if got := string(stripComments([]byte(in))); got != want {
+		t.Errorf("stripComments = %q, want %q", got, want)
+	}
+}
+
+func TestConfigCheck(t *testing.T) {
+	s := settings{}
+	if err := s.check(); err != nil {
+		t.Fatal(err)
+	}
+	if c := s.config(); c.Project != "go" || c.MaxCommentSize != 50000 {
+		t.Errorf("defaults are not set: %+v", c)
+	}
+
+	s = settings{"cache_ttl": "soon"}
+	err := s.check()
+	if err == nil || !strings.Contains(err.Error(), "cache_ttl") {
+		t.Errorf("error of invalid cache_ttl = %v", err)
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+	c := defaultConfig()
+	if c.CacheTTL.Seconds() != 60 || c.Plain || !c.SaveToken || len(c.Priorities) != 4 {
+		t.Errorf("defaults are not set: %+v", c)
+	}
+}
+
+func TestConfigFields(t *testing.T) {
+	// every setting must be made into a field of Config.
+	s := settings{}
+	for _, d := range settingDefs {
+		switch d.kind {
+		case "bool":
+			s[d.key] = "true"
+		case "int":
+			s[d.key] = "1"
+		case "duration":
+			s[d.key] = "1s"
+		case "size":
+			s[d.key] = "1K"
+		case "json":
+			s[d.key] = "{}"
+		default:
+			s[d.key] = "x"
+		}
+	}
+	v := reflect.ValueOf(*s.config())
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).IsZero() {
+			t.Errorf("%s is not set from settings", v.Type().Field(i).Name)
+		}
+	}
+	if n := reflect.TypeOf(Config{}).NumField(); n != len(settingDefs) {
+		t.Errorf("Config has %d fields for %d settings", n, len(settingDefs))
+	}
+}
+
+const testTOML = `# settings of goissue
+email = "a@example.com"
+password = 'p#ss'
+base_url = "http://localhost:8080/#x" # test server
+login_url = http://localhost:8080/#login
+project = 0123
+cache_ttl = "5m"
+max_comment_size = 30_000
+projects = ["go", "gofrontend"]
+
+[new_issue]
+status = "New"
+labels = [
+  "Type-Defect",
+  "Priority-Medium",
+]
+`
+
+const testYAML = `# settings of goissue
+email: a@example.com
+password: p#ss # unquoted
+base_url: http://localhost:8080/#x
+login_url: http://localhost:8080/#login
+project: 0123
+cache_ttl: 5m
+max_comment_size: 30000
+projects:
+  - go
+  - gofrontend
+new_issue:
+  status: New
+  labels: [Type-Defect, Priority-Medium]
+`
+
+func TestDecodeConfig(t *testing.T) {
+	for ext, text := range map[string]string{".toml": testTOML, ".yaml": testYAML} {
+		c, err := decodeConfig(ext, []byte(text))
+		if err == nil {
+			err = c.check()
+		}
+		if err != nil {
+			t.Errorf("%s: %v", ext, err)
+			continue
+		}
+		want := settings{
+			"email":            "a@example.com",
+			"password":         "p#ss",
+			"base_url":         "http://localhost:8080/#x",
+			"login_url":        "http://localhost:8080/#login",
+			"project":          "0123",
+			"cache_ttl":        "5m",
+			"max_comment_size": "30000",
+			"projects":         "go,gofrontend",
+			"new_issue":        `{"labels":["Type-Defect","Priority-Medium"],"status":"New"}`,
+		}
+		for k, v := range want {
+			if c[k] != v {
+				t.Errorf("%s: %s = %q, want %q", ext, k, c[k], v)
+			}
+		}
+	}
+}
+
+func TestDecodeConfigError(t *testing.T) {
+	_, err := decodeConfig(".toml", []byte("email = \"a@example.com\"\npassword\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("error = %v, want one of line 2", err)
+	}
+}
+
+func TestReadConfigLayers(t *testing.T) {
+	home, err := ioutil.TempDir("", "goissue-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	repo := filepath.Join(home, "repo")
+	sub := filepath.Join(repo, "src")
+	dir := filepath.Join(home, ".config", "goissue")
+	for _, d := range []string{sub, filepath.Join(dir, "projects")} {
+		if err := os.MkdirAll(d, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(dir, "settings.json"):               `{"email": "a@example.com", "password": "p", "labels": "A", "statuses": "New"}`,
+		filepath.Join(dir, "projects", "gofrontend.toml"): `statuses = "Started"`,
+		filepath.Join(repo, ".goissue"):                   "project: gofrontend\nlabels: B\npassword: shared\ntemplates: tmpl\n",
+	}
+	for file, text := range files {
+		if err := ioutil.WriteFile(file, []byte(text), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(sub); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := readConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Project != "gofrontend" || c.Password != "p" || c.Templates != filepath.Join(repo, "tmpl") {
+		t.Errorf("project = %q, password = %q, templates = %q", c.Project, c.Password, c.Templates)
+	}
+	if !reflect.DeepEqual(c.Labels, []string{"B"}) || !reflect.DeepEqual(c.Statuses, []string{"Started"}) {
+		t.Errorf("labels = %q, statuses = %q; want B and Started", c.Labels, c.Statuses)
+	}
+	if files := configFiles(c.Project); len(files) != 3 {
+		t.Errorf("configFiles = %v", files)
+	}
+
+	c, err = readConfig("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Project != "go" || !reflect.DeepEqual(c.Statuses, []string{"New"}) {
+		t.Errorf("project = %q, statuses = %q; want go and New", c.Project, c.Statuses)
+	}
+}
+
+func TestReadConfigMissing(t *testing.T) {
+	home, err := ioutil.TempDir("", "goissue-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(home); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := readConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Project != "go" || c.Email != "" || loginFunc(c) != nil {
+		t.Errorf("config without settings.json = %+v", c)
+	}
+	if files := configFiles(c.Project); len(files) != 0 {
+		t.Errorf("configFiles = %v, want none", files)
+	}
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parsers of settings.toml and settings.yaml. They cover what settings
+// need, not the whole languages: tables, strings, numbers, booleans,
+// arrays and nested maps. Results are like those of json.Unmarshal into
+// interface{}, except that numbers are numberText.
+
+// syntaxError is error of the line in a settings file.
+func syntaxError(file string, line int, msg string) error {
+	return fmt.Errorf("failed to parse %s: line %d: %s", file, line, msg)
+}
+
+// cutComment remove a comment starting with '#' outside of quotes. '#'
+// in a word, like p#ss or http://h/#x, doesn't start a comment; only at the
+// start of the line or after a space.
+func cutComment(s string) string {
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// subMap return the map at path in m, creating it if needed.
+func subMap(m map[string]interface{}, path []string) (map[string]interface{}, error) {
+	for _, k := range path {
+		v, ok := m[k]
+		if !ok {
+			v = map[string]interface{}{}
+			m[k] = v
+		}
+		sub, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errors.New(k + " is not a table")
+		}
+		m = sub
+	}
+	return m, nil
+}
+
+// splitKey split a dotted key like a."b.c" into its parts.
+func splitKey(s string) ([]string, error) {
+	var keys []string
+	for s = strings.TrimSpace(s); s != ""; {
+		var k string
+		if s[0] == '"' || s[0] == '\'' {
+			end := strings.IndexByte(s[1:], s[0])
+			if end < 0 {
+				return nil, errors.New("unterminated key")
+			}
+			k, s = s[1:end+1], s[end+2:]
+		} else {
+			end := strings.IndexByte(s, '.')
+			if end < 0 {
+				end = len(s)
+			}
+			k, s = strings.TrimSpace(s[:end]), s[end:]
+			if k == "" {
+				return nil, errors.New("empty key")
+			}
+		}
+		keys = append(keys, k)
+		s = strings.TrimSpace(s)
+		if s != "" {
+			if s[0] != '.' {
+				return nil, errors.New("invalid key")
+			}
+			s = strings.TrimSpace(s[1:])
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("empty key")
+	}
+	return keys, nil
+}
+
+// balanced return true if brackets and braces outside of quotes in s are
+// closed, for values written over lines.
+func balanced(s string) bool {
+	depth := 0
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '[' || ch == '{':
+			depth++
+		case ch == ']' || ch == '}':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// parseTOML parse settings written in TOML.
+func parseTOML(b []byte) (map[string]interface{}, error) {
+	root := map[string]interface{}{}
+	table := root
+	lines := strings.Split(toLF(string(b)), "\n")
+	for n := 0; n < len(lines); n++ {
+		lineNo := n + 1
+		line := strings.TrimSpace(cutComment(lines[n]))
+		if line == "" {
+			continue
+		}
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, syntaxError("settings.toml", lineNo, "invalid table header")
+			}
+			path, err := splitKey(line[1 : len(line)-1])
+			if err == nil {
+				table, err = subMap(root, path)
+			}
+			if err != nil {
+				return nil, syntaxError("settings.toml", lineNo, err.Error())
+			}
+			continue
+		}
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			return nil, syntaxError("settings.toml", lineNo, "expected key = value")
+		}
+		value := strings.TrimSpace(line[eq+1:])
+		for !balanced(value) && n+1 < len(lines) {
+			n++
+			value += " " + strings.TrimSpace(cutComment(lines[n]))
+		}
+		path, err := splitKey(line[:eq])
+		if err != nil {
+			return nil, syntaxError("settings.toml", lineNo, err.Error())
+		}
+		m, err := subMap(table, path[:len(path)-1])
+		if err != nil {
+			return nil, syntaxError("settings.toml", lineNo, err.Error())
+		}
+		v, rest, err := parseValue(value, '=')
+		if err == nil && strings.TrimSpace(rest) != "" {
+			err = errors.New("unexpected " + strings.TrimSpace(rest))
+		}
+		if err != nil {
+			return nil, syntaxError("settings.toml", lineNo, err.Error())
+		}
+		m[path[len(path)-1]] = v
+	}
+	return root, nil
+}
+
+// parseValue parse a value at the start of s, and return the rest. sep is
+// the separator of keys and values in inline tables; '=' for TOML and ':'
+// for YAML.
+func parseValue(s string, sep byte) (interface{}, string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, "", errors.New("missing value")
+	}
+	switch s[0] {
+	case '"':
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\\' {
+				i++
+			} else if s[i] == '"' {
+				v, err := strconv.Unquote(s[:i+1])
+				return v, s[i+1:], err
+			}
+		}
+		return nil, "", errors.New("unterminated string")
+	case '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return nil, "", errors.New("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+	case '[':
+		list := []interface{}{}
+		s = strings.TrimSpace(s[1:])
+		for {
+			if strings.HasPrefix(s, "]") {
+				return list, s[1:], nil
+			}
+			v, rest, err := parseValue(s, sep)
+			if err != nil {
+				return nil, "", err
+			}
+			list = append(list, v)
+			s = strings.TrimSpace(rest)
+			if strings.HasPrefix(s, ",") {
+				s = strings.TrimSpace(s[1:])
+			} else if !strings.HasPrefix(s, "]") {
+				return nil, "", errors.New("expected , or ] in array")
+			}
+		}
+	case '{':
+		m := map[string]interface{}{}
+		s = strings.TrimSpace(s[1:])
+		for {
+			if strings.HasPrefix(s, "}") {
+				return m, s[1:], nil
+			}
+			i := strings.IndexByte(s, sep)
+			if i < 0 {
+				return nil, "", errors.New("expected key in inline table")
+			}
+			k := strings.Trim(strings.TrimSpace(s[:i]), `"'`)
+			v, rest, err := parseValue(s[i+1:], sep)
+			if err != nil {
+				return nil, "", err
+			}
+			m[k] = v
+			s = strings.TrimSpace(rest)
+			if strings.HasPrefix(s, ",") {
+				s = strings.TrimSpace(s[1:])
+			} else if !strings.HasPrefix(s, "}") {
+				return nil, "", errors.New("expected , or } in inline table")
+			}
+		}
+	}
+	end := strings.IndexAny(s, ",]}")
+	if end < 0 {
+		end = len(s)
+	}
+	word := strings.TrimSpace(s[:end])
+	return scalar(word, sep == '='), s[end:], nil
+}
+
+// numberText is a number written in a settings file. It is kept as written,
+// since values of settings are strings in settings.json and "0123" must not
+// become "123"; in JSON values like new_issue, it is a number.
+type numberText string
+
+func (n numberText) MarshalJSON() ([]byte, error) {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return json.Marshal(i)
+	}
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(f)
+}
+
+// scalar return bool or number of the word, or the word itself. Unquoted
+// words are strings in YAML but not in TOML, where they are taken as is
+// anyway to be forgiving. Underscores separating digits of numbers are
+// removed.
+func scalar(word string, toml bool) interface{} {
+	switch word {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null", "~":
+		if !toml {
+			return nil
+		}
+	}
+	digits := strings.Replace(word, "_", "", -1)
+	if _, err := strconv.ParseInt(digits, 10, 64); err == nil {
+		return numberText(digits)
+	}
+	if _, err := strconv.ParseFloat(word, 64); err == nil {
+		return numberText(word)
+	}
+	return word
+}
+
+// yamlLine is a line of YAML without comment.
+type yamlLine struct {
+	no     int
+	indent int
+	text   string
+}
+
+// parseYAML parse settings written in YAML: block mappings and sequences
+// nested by indentation, scalars and flow collections like [a, b].
+func parseYAML(b []byte) (map[string]interface{}, error) {
+	var lines []yamlLine
+	for i, l := range strings.Split(toLF(string(b)), "\n") {
+		if strings.Contains(l[:len(l)-len(strings.TrimLeft(l, " \t"))], "\t") {
+			return nil, syntaxError("settings.yaml", i+1, "tabs can't be used for indentation")
+		}
+		text := strings.TrimRight(cutComment(l), " ")
+		if strings.TrimSpace(text) == "" || strings.TrimSpace(text) == "---" {
+			continue
+		}
+		trimmed := strings.TrimLeft(text, " ")
+		lines = append(lines, yamlLine{i + 1, len(text) - len(trimmed), trimmed})
+	}
+	if len(lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	v, rest, err := yamlBlock(lines, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, syntaxError("settings.yaml", rest[0].no, "unexpected indentation")
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, syntaxError("settings.yaml", lines[0].no, "settings must be a mapping")
+	}
+	return m, nil
+}
+
+// yamlBlock parse lines at the indent as a mapping or a sequence, and
+// return lines after it.
+func yamlBlock(lines []yamlLine, indent int) (interface{}, []yamlLine, error) {
+	if strings.HasPrefix(lines[0].text, "- ") || lines[0].text == "-" {
+		var list []interface{}
+		for len(lines) > 0 && lines[0].indent == indent && strings.HasPrefix(lines[0].text+" ", "- ") {
+			l := lines[0]
+			item := strings.TrimSpace(strings.TrimPrefix(l.text, "-"))
+			lines = lines[1:]
+			if item == "" {
+				if len(lines) == 0 || lines[0].indent <= indent {
+					list = append(list, nil)
+					continue
+				}
+				v, rest, err := yamlBlock(lines, lines[0].indent)
+				if err != nil {
+					return nil, nil, err
+				}
+				list, lines = append(list, v), rest
+				continue
+			}
+			v, err := yamlScalar(item, l.no)
+			if err != nil {
+				return nil, nil, err
+			}
+			list = append(list, v)
+		}
+		return list, lines, nil
+	}
+
+	m := map[string]interface{}{}
+	for len(lines) > 0 && lines[0].indent == indent {
+		l := lines[0]
+		i := yamlColon(l.text)
+		if i < 0 {
+			return nil, nil, syntaxError("settings.yaml", l.no, "expected key: value")
+		}
+		key := strings.Trim(strings.TrimSpace(l.text[:i]), `"'`)
+		value := strings.TrimSpace(l.text[i+1:])
+		lines = lines[1:]
+		if value != "" {
+			v, err := yamlScalar(value, l.no)
+			if err != nil {
+				return nil, nil, err
+			}
+			m[key] = v
+			continue
+		}
+		if len(lines) == 0 || lines[0].indent < indent ||
+			lines[0].indent == indent && !strings.HasPrefix(lines[0].text, "- ") {
+			m[key] = nil
+			continue
+		}
+		v, rest, err := yamlBlock(lines, lines[0].indent)
+		if err != nil {
+			return nil, nil, err
+		}
+		m[key], lines = v, rest
+	}
+	return m, lines, nil
+}
+
+// yamlColon return index of ':' separating key and value in s, or -1.
+func yamlColon(s string) int {
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == ':' && (i+1 == len(s) || s[i+1] == ' '):
+			return i
+		}
+	}
+	return -1
+}
+
+// yamlScalar parse the value written after "key:" or "- ".
+func yamlScalar(s string, no int) (interface{}, error) {
+	if s[0] == '[' || s[0] == '{' || s[0] == '"' || s[0] == '\'' {
+		v, rest, err := parseValue(s, ':')
+		if err == nil && strings.TrimSpace(rest) != "" {
+			err = errors.New("unexpected " + strings.TrimSpace(rest))
+		}
+		if err != nil {
+			return nil, syntaxError("settings.yaml", no, err.Error())
+		}
+		return v, nil
+	}
+	return scalar(s, false), nil
+}
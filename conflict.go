@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// updateIssue post comment with updates to the issue of entry. If the
+// issue was changed upstream since entry was fetched, the difference is
+// shown and confirmation is asked, to avoid reverting someone's triage.
+func updateIssue(ctx context.Context, c *Client, from string, entry Entry, body string, u *Updates) error {
+	id := issueId(entry)
+	c.Cache.forget(c.IssueURL(id))
+	current, err := c.Entry(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current.Updated != entry.Updated {
+		warnf("issue %s was changed upstream at %s:", id, current.Updated)
+		printDelta(os.Stderr, entry, current)
+		if !confirm("update anyway?") {
+			return errors.New("canceled")
+		}
+	}
+	// If-Match with the etag make the server refuse the post if the issue
+	// is changed again meanwhile.
+	return c.PostComment(ctx, id, from, current.Etag, body, u)
+}
+
+// printDelta print difference between old and new copy of the issue.
+func printDelta(w io.Writer, old, new Entry) {
+	diff := func(name, a, b string) {
+		if a != b {
+			fmt.Fprintf(w, "  %s: %q -> %q\n", name, a, b)
+		}
+	}
+	diff("title", old.Title, new.Title)
+	diff("state", entryState(old), entryState(new))
+	diff("status", strings.Join(old.IssuesStatus, ","), strings.Join(new.IssuesStatus, ","))
+	diff("owner", ownerName(old), ownerName(new))
+	for _, label := range new.IssuesLabel {
+		if !hasLabel(old, label) {
+			fmt.Fprintf(w, "  label: +%s\n", label)
+		}
+	}
+	for _, label := range old.IssuesLabel {
+		if !hasLabel(new, label) {
+			fmt.Fprintf(w, "  label: -%s\n", label)
+		}
+	}
+	if old.Content != new.Content {
+		fmt.Fprintln(w, "  description changed")
+	}
+}
+
+// ownerName return user name of the owner of the issue.
+func ownerName(entry Entry) string {
+	if len(entry.IssuesOwner) == 0 {
+		return ""
+	}
+	return entry.IssuesOwner[0].IssuesUsername
+}
+
+// answers is the reader of lines answered on stdin. Every question read
+// it, since a reader of its own may buffer lines typed ahead for the next
+// question and lose them.
+var answers = bufio.NewReader(os.Stdin)
+
+// confirm ask question on the terminal and return true if answered yes.
+func confirm(question string) bool {
+	fmt.Fprint(os.Stderr, tr(question)+" [y/N] ")
+	line, _ := answers.ReadString('\n')
+	line = strings.ToLower(strings.TrimSpace(line))
+	return line == "y" || line == "yes"
+}
@@ -0,0 +1,450 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// issueTemplate is the text opened in the editor to create an issue. Lines
+// before the separator are headers; "status" and "labels" may be left
+// empty to use the defaults of the project.
+const issueTemplate = `from: 
+title: 
+status: 
+labels: 
+--------------
+Before filing a bug, please check whether it has been fixed since
+the latest release: run "hg pull -u" and retry what you did to
+reproduce the problem.  Thanks.
+
+What steps will reproduce the problem?
+1.
+2.
+3.
+
+What is the expected output?
+
+
+What do you see instead?
+
+
+Which compiler are you using (5g, 6g, 8g, gccgo)?
+
+
+Which operating system are you using?
+
+
+Which revision are you using?  (hg identify)
+
+
+Please provide any additional information below.
+`
+
+func run(argv []string) error {
+	cmd, err := exec.LookPath(argv[0])
+	if err != nil {
+		return err
+	}
+	var stdin *os.File
+	if runtime.GOOS == "windows" {
+		stdin, _ = os.Open("CONIN$")
+	} else {
+		stdin = os.Stdin
+	}
+	p, err := os.StartProcess(cmd, argv, &os.ProcAttr{Files: []*os.File{stdin, os.Stdout, os.Stderr}})
+	if err != nil {
+		return err
+	}
+	defer p.Release()
+	w, err := p.Wait()
+	if err != nil {
+		return err
+	}
+	if !w.Exited() || !w.Success() {
+		return errors.New("failed to execute text editor")
+	}
+	return nil
+}
+
+// editorCommand return the text editor given by EDITOR, or the default.
+func editorCommand() string {
+	editor := os.Getenv("EDITOR")
+	if len(editor) == 0 {
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		} else {
+			editor = "vim"
+		}
+	}
+	return editor
+}
+
+// tempDir return directory to put files edited in the text editor. It is
+// "tmpdir" in config, or the config directory which only the user can read.
+func tempDir(config *Config) string {
+	if dir := config.TmpDir; dir != "" {
+		return dir
+	}
+	return configDir()
+}
+
+// editText open text in the text editor and return the edited text and the
+// file of it. The file is created with 0600 permission and is not removed,
+// so that the text is not lost if later steps fail; remove it on success.
+func editText(config *Config, text string) (string, string, error) {
+	if err := os.MkdirAll(tempDir(config), 0700); err != nil {
+		return "", "", err
+	}
+	f, err := ioutil.TempFile(tempDir(config), "goissue-*.txt")
+	if err != nil {
+		return "", "", err
+	}
+	file := f.Name()
+	_, err = f.WriteString(toNative(text))
+	f.Close()
+	if err != nil {
+		return "", file, err
+	}
+	if err := run([]string{editorCommand(), file}); err != nil {
+		return "", file, err
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", file, err
+	}
+	return toLF(string(b)), file, nil
+}
+
+// parseIssue parse text edited in the editor. Header lines like
+// "title: foo" continue until the separator line starting with "---".
+func parseIssue(text string) (*NewIssue, error) {
+	lines := strings.Split(text, "\n")
+	issue := &NewIssue{}
+	for i, line := range lines {
+		if strings.HasPrefix(line, "---") {
+			issue.Body = strings.Join(lines[i+1:], "\n")
+			break
+		}
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			return nil, errors.New("invalid header: " + line)
+		}
+		value := strings.TrimSpace(kv[1])
+		switch strings.TrimSpace(kv[0]) {
+		case "from":
+			issue.From = value
+		case "title":
+			issue.Title = value
+		case "status":
+			issue.Status = value
+		case "owner":
+			issue.Owner = value
+		case "labels":
+			for _, label := range strings.Split(value, ",") {
+				if label = strings.TrimSpace(label); label != "" {
+					issue.Labels = append(issue.Labels, label)
+				}
+			}
+		default:
+			return nil, errors.New("unknown header: " + line)
+		}
+	}
+	if issue.From == "" {
+		return nil, errors.New("from is empty")
+	}
+	if issue.Title == "" {
+		return nil, errors.New("title is empty")
+	}
+	return issue, nil
+}
+
+// issueDefaults is "new_issue" in config, which is fields of new issues
+// used when no flags are given. Types override them for the type given
+// with -type, like {"types": {"enhancement": {"status": "Accepted"}}}.
+type issueDefaults struct {
+	Status string                   `json:"status"`
+	Labels []string                 `json:"labels"`
+	Owner  string                   `json:"owner"`
+	Types  map[string]issueDefaults `json:"types"`
+}
+
+// forType return defaults for issues of the type. Labels of other types
+// are replaced with the label of the type.
+func (d issueDefaults) forType(typ string) issueDefaults {
+	typ = strings.ToLower(typ)
+	t := d.Types[typ]
+	if t.Status == "" {
+		t.Status = d.Status
+	}
+	if t.Owner == "" {
+		t.Owner = d.Owner
+	}
+	if t.Labels == nil {
+		label := "Type-" + strings.Title(typ)
+		if it, ok := issueTypes[typ]; ok {
+			label = it.label
+		}
+		t.Labels = []string{label}
+		for _, l := range d.Labels {
+			if !strings.HasPrefix(l, "Type-") {
+				t.Labels = append(t.Labels, l)
+			}
+		}
+	}
+	return t
+}
+
+// getIssueDefaults return "new_issue" in config.
+func getIssueDefaults(config *Config) (d issueDefaults) {
+	if config.NewIssue != nil {
+		if err := json.Unmarshal(config.NewIssue, &d); err != nil {
+			fatal("invalid new_issue in your settings.json:", err)
+		}
+	}
+	return d
+}
+
+// headerValue return value of the header line in the text, or "".
+func headerValue(text, key string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "---") {
+			break
+		}
+		if strings.HasPrefix(line, key+":") {
+			return strings.TrimSpace(line[len(key)+1:])
+		}
+	}
+	return ""
+}
+
+// fillHeader set value of the header line in the template.
+func fillHeader(template, key, value string) string {
+	return strings.Replace(template, "\n"+key+": \n", "\n"+key+": "+value+"\n", 1)
+}
+
+// createFlags is flags of create.
+type createFlags struct {
+	status  string
+	labels  string
+	owner   string
+	title   string
+	typ     string
+	fromCmd string
+	preview bool
+	offline bool
+	prompt  bool
+	values  placeholderValues
+}
+
+// register add flags of create to the flag set.
+func (o *createFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.status, "status", "", "status of the issue, instead of the default in \"new_issue\" of settings")
+	fs.StringVar(&o.labels, "labels", "", "comma separated labels, instead of the defaults")
+	fs.StringVar(&o.owner, "owner", "", "owner of the issue, instead of the default")
+	fs.StringVar(&o.title, "title", "", "title of the issue")
+	fs.StringVar(&o.typ, "type", "", "type of the issue: defect, enhancement or doc")
+	fs.StringVar(&o.fromCmd, "from-cmd", "", "run the command and report its output")
+	fs.BoolVar(&o.preview, "preview", false, "show the issue as it will look and confirm before posting")
+	fs.BoolVar(&o.offline, "offline", false, "save the issue in the outbox to post later, with a provisional ID")
+	fs.BoolVar(&o.prompt, "prompt", false, "ask {{placeholders}} of the template and post without the editor")
+	o.values = placeholderValues{}
+	fs.Var(o.values, "set", "fill {{NAME}} of the template with the value, like -set version=go1.0.1; repeatable")
+}
+
+// fileIssue create issue with flags: goissue create [-status S] [-labels L]
+func fileIssue(ctx context.Context, config *Config, c *Client, args []string) {
+	var o createFlags
+	fs := newFlagSet("create")
+	o.register(fs)
+	fs.Parse(args)
+
+	// defaults, of the type if given, are used for flags not given.
+	d := getIssueDefaults(config)
+	if o.typ != "" {
+		d = d.forType(o.typ)
+		if _, ok := issueTypes[strings.ToLower(o.typ)]; !ok && loadTemplate(config, c.Project, o.typ) == issueTemplate {
+			fatal("unknown issue type:", o.typ)
+		}
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["status"] {
+		o.status = d.Status
+	}
+	if !set["labels"] {
+		o.labels = strings.Join(d.Labels, ",")
+	}
+	if !set["owner"] {
+		o.owner = d.Owner
+	}
+
+	template := "\n" + loadTemplate(config, c.Project, o.typ)
+	template = fillHeader(template, "from", config.Email)
+	template = fillHeader(template, "title", o.title)
+	if o.fromCmd != "" {
+		title, report := commandReport(o.fromCmd)
+		template = fillHeader(template, "title", title)
+		template += "\n" + report
+	}
+	template = fillHeader(template, "status", o.status)
+	template = fillHeader(template, "labels", o.labels)
+	if o.owner != "" {
+		template = strings.Replace(template, "\nstatus: ", "\nowner: "+o.owner+"\nstatus: ", 1)
+	}
+	if o.prompt {
+		if headerValue(template, "title") == "" {
+			// the title is asked first, like placeholders.
+			template = fillHeader(template, "title", "{{title: Title of the issue?}}")
+		}
+		if err := askPlaceholders(template, o.values); err != nil {
+			fatal("failed to create issue:", err)
+		}
+	}
+	filled, missing := fillPlaceholders(template, o.values)
+	if o.prompt || (len(o.values) > 0 && len(missing) == 0 && headerValue(filled, "title") != "") {
+		// the template is complete; post it without the editor.
+		submitIssue(ctx, config, c, template[1:], filled[1:], "", o.preview, o.offline)
+		return
+	}
+	editIssue(ctx, config, c, filled[1:], o.preview, o.offline)
+}
+
+// shellCommand return command running the command line with the shell.
+func shellCommand(cmdline string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/c", cmdline)
+	}
+	return exec.Command("sh", "-c", cmdline)
+}
+
+// commandReport run the command line with the shell and return title and
+// body text that report its output, exit status and the environment.
+func commandReport(cmdline string) (title, report string) {
+	b, err := shellCommand(cmdline).CombinedOutput()
+	out := toLF(string(b))
+	status := "exit status 0"
+	if err != nil {
+		status = err.Error()
+	}
+	title = cmdline + " fails: " + status
+
+	report = "Output of: " + cmdline + " (" + status + ")\n\n" +
+		"<pre>\n" + strings.TrimRight(out, "\n") + "\n</pre>\n\n" +
+		"Environment:\n" +
+		"GOOS=" + runtime.GOOS + " GOARCH=" + runtime.GOARCH + "\n"
+	if v, err := exec.Command("go", "version").Output(); err == nil {
+		report += toLF(string(v))
+	}
+	if wd, err := os.Getwd(); err == nil {
+		report += "directory: " + wd + "\n"
+	}
+	return title, report
+}
+
+// createIssue create issue written in the text editor.
+func createIssue(ctx context.Context, config *Config, c *Client) {
+	fileIssue(ctx, config, c, nil)
+}
+
+// previewIssue print the issue as it will be shown on the tracker.
+func previewIssue(c *Client, issue *NewIssue) {
+	text, err := render(issue.Body, c.Options)
+	if err != nil {
+		fatal("failed to render issue:", err)
+	}
+	fmt.Println("title:  " + issue.Title)
+	fmt.Println("from:   " + issue.From)
+	if issue.Owner != "" {
+		fmt.Println("owner:  " + issue.Owner)
+	}
+	fmt.Println("status: " + issue.Status)
+	fmt.Println("labels: " + strings.Join(issue.Labels, ", "))
+	fmt.Println("--------------")
+	fmt.Println(text)
+}
+
+// editIssue open template in the text editor and create the issue written.
+// If preview is true, the issue is shown and confirmed before posting. If
+// offline is true, the issue is put into the outbox with a provisional ID
+// instead of posting. Return the issue created.
+func editIssue(ctx context.Context, config *Config, c *Client, template string, preview, offline bool) Entry {
+	text, file, err := editText(config, template)
+	if err != nil {
+		if file != "" {
+			fatal("failed to create issue:", err, " (draft is kept in "+file+")")
+		}
+		fatal("failed to create issue:", err)
+	}
+	return submitIssue(ctx, config, c, template, text, file, preview, offline)
+}
+
+// submitIssue check the text of the issue written from the template and
+// create it. file is the draft of the text, removed when the issue is
+// posted, or empty if the text is not written in the editor.
+func submitIssue(ctx context.Context, config *Config, c *Client, template, text, file string, preview, offline bool) Entry {
+	// the draft is kept on failure to be able to write it again.
+	fail := func(v ...interface{}) {
+		if file != "" {
+			v = append(v, " (draft is kept in "+file+")")
+		}
+		fatal(v...)
+	}
+	issue, err := parseIssue(text)
+	if err != nil {
+		fail("failed to create issue:", err)
+	}
+	issue.Body = formatTraces(issue.Body)
+	suggestRoutes(config, c, issue)
+	if err := validateIssue(config, c.Project, issue); err != nil {
+		fail("failed to create issue:", err)
+	}
+	lints, failures := lintIssue(loadLintRules(config), issue.Body)
+	if len(failures) > 0 {
+		fail("failed to create issue: " + strings.Join(failures, "; "))
+	}
+	if !checkIssue(config, template, issue, lints) {
+		fail("canceled")
+	}
+	if !checkSecrets(config, issue.Title+"\n"+issue.Body) {
+		fail("canceled")
+	}
+	if preview {
+		previewIssue(c, issue)
+		if !confirm("post this issue?") {
+			fail("canceled")
+		}
+	}
+	if offline {
+		provisional, err := nextProvisional()
+		if err != nil {
+			fail("failed to save issue:", err)
+		}
+		item := &outboxItem{Kind: "issue", Project: c.Project, Issue: issue, Provisional: provisional}
+		if _, err := item.queue(); err != nil {
+			fail("failed to save issue:", err)
+		}
+		os.Remove(file)
+		infof("issue saved as %s; it is posted by sync or outbox push", item.Provisional)
+		return Entry{}
+	}
+	entry, err := c.CreateIssue(ctx, issue)
+	if err != nil {
+		if msg := queueOnFailure(&outboxItem{Kind: "issue", Project: c.Project, Issue: issue}, err); msg != "" {
+			os.Remove(file)
+			fatal("failed to post issue:", err, msg)
+		}
+		fail("failed to post issue:", err)
+	}
+	os.Remove(file)
+	infof("issue %s created", issueId(entry))
+	return entry
+}
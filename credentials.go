@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// sealedIter is iterations of PBKDF2 for new credentials files.
+const sealedIter = 200000
+
+// sealedCredentials is the password encrypted with a passphrase. The email
+// is kept in plain text, so that goissue know the account without asking
+// the passphrase until it log in.
+type sealedCredentials struct {
+	Email string `json:"email"`
+	KDF   string `json:"kdf"` // pbkdf2-sha256
+	Iter  int    `json:"iter"`
+	Salt  []byte `json:"salt"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"` // password sealed with AES-256-GCM
+}
+
+// credentialsFile return path of the encrypted credentials.
+func credentialsFile() string {
+	return filepath.Join(configDir(), "credentials.enc")
+}
+
+// pbkdf2 derive key from the passphrase with PBKDF2-HMAC-SHA256 (RFC 2898).
+func pbkdf2(pass, salt []byte, iter, keyLen int) []byte {
+	prf := hmac.New(sha256.New, pass)
+	var key []byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.Write(prf, binary.BigEndian, block)
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iter; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
+
+// gcm return AES-256-GCM with the key derived from the passphrase.
+func (s *sealedCredentials) gcm(passphrase string) (cipher.AEAD, error) {
+	if s.KDF != "pbkdf2-sha256" {
+		return nil, errors.New("unknown kdf: " + s.KDF)
+	}
+	block, err := aes.NewCipher(pbkdf2([]byte(passphrase), s.Salt, s.Iter, 32))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealCredentials encrypt the password with the passphrase.
+func sealCredentials(email, password, passphrase string) (*sealedCredentials, error) {
+	s := &sealedCredentials{Email: email, KDF: "pbkdf2-sha256", Iter: sealedIter, Salt: make([]byte, 16)}
+	if _, err := rand.Read(s.Salt); err != nil {
+		return nil, err
+	}
+	aead, err := s.gcm(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	s.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(s.Nonce); err != nil {
+		return nil, err
+	}
+	s.Data = aead.Seal(nil, s.Nonce, []byte(password), []byte(email))
+	return s, nil
+}
+
+// open decrypt the password with the passphrase.
+func (s *sealedCredentials) open(passphrase string) (string, error) {
+	aead, err := s.gcm(passphrase)
+	if err != nil {
+		return "", err
+	}
+	b, err := aead.Open(nil, s.Nonce, s.Data, []byte(s.Email))
+	if err != nil {
+		return "", errors.New("wrong passphrase, or " + credentialsFile() + " is broken")
+	}
+	return string(b), nil
+}
+
+// readSealed read the encrypted credentials, which are used when config
+// has neither password nor password_command. nil is returned if they are
+// not used, or there is no file.
+func readSealed(config *Config) (*sealedCredentials, error) {
+	if config.Password != "" || config.PasswordCommand != "" {
+		return nil, nil
+	}
+	b, err := ioutil.ReadFile(credentialsFile())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s sealedCredentials
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, errors.New("failed to read " + credentialsFile() + ": " + err.Error())
+	}
+	return &s, nil
+}
+
+// readPassphrase return the passphrase from GOISSUE_PASSPHRASE, which an
+// agent or a script can set, or ask it on the terminal without echo.
+func readPassphrase(prompt string) (string, error) {
+	if p := os.Getenv("GOISSUE_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	if !isTerminal(os.Stdin) {
+		return "", errors.New("passphrase is required; set GOISSUE_PASSPHRASE or run on a terminal")
+	}
+	fmt.Fprint(os.Stderr, tr(prompt)+": ")
+	if runtime.GOOS != "windows" {
+		stty := func(arg string) {
+			cmd := exec.Command("stty", arg)
+			cmd.Stdin = os.Stdin
+			cmd.Run()
+		}
+		stty("-echo")
+		defer stty("echo")
+	}
+	line, err := answers.ReadString('\n')
+	fmt.Fprintln(os.Stderr)
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// secretCommand run the command line of the setting key and return the
+// first line of its output, like "pass show" print. The command can ask on
+// the terminal since stdin and stderr are passed through.
+func secretCommand(key, line string) (string, error) {
+	cmd := shellCommand(line)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	b, err := cmd.Output()
+	if err != nil {
+		return "", &authError{fmt.Errorf("%s failed: %v", key, err)}
+	}
+	secret := strings.TrimRight(strings.SplitN(toLF(string(b)), "\n", 2)[0], " ")
+	if secret == "" {
+		return "", &authError{errors.New(key + " printed nothing")}
+	}
+	return secret, nil
+}
+
+// password return the password in config, the output of password_command,
+// or the one decrypted from the credentials file, asking the passphrase.
+// The password is kept in config for the rest of the command.
+func password(config *Config) (string, error) {
+	if p := config.Password; p != "" {
+		return p, nil
+	}
+	if config.PasswordCommand != "" {
+		p, err := secretCommand("password_command", config.PasswordCommand)
+		if err == nil {
+			config.Password = p
+		}
+		return p, err
+	}
+	sealed, err := readSealed(config)
+	if err != nil || sealed == nil {
+		return "", err
+	}
+	passphrase, err := readPassphrase("passphrase of " + credentialsFile())
+	if err != nil {
+		return "", &authError{err}
+	}
+	p, err := sealed.open(passphrase)
+	if err != nil {
+		return "", &authError{err}
+	}
+	config.Password = p
+	return p, nil
+}
+
+// loginFunc return function to get the auth token for config; from
+// token_command if set, or by logging in with the email, reusing the token
+// saved by an earlier run. nil is returned if there are no credentials.
+func loginFunc(config *Config) func(ctx context.Context) (string, error) {
+	if config.TokenCommand != "" {
+		return func(ctx context.Context) (string, error) {
+			return secretCommand("token_command", config.TokenCommand)
+		}
+	}
+	if config.Email != "" {
+		return func(ctx context.Context) (string, error) {
+			return cachedLogin(ctx, config)
+		}
+	}
+	return nil
+}
+
+// manageCredentials encrypt the password in settings into the credentials
+// file, or print the decrypted credentials.
+func manageCredentials(ctx context.Context, config *Config, c *Client, args []string) {
+	fs := newFlagSet("credentials")
+	rest := parseFlags(fs, args)
+	if len(rest) != 1 || (rest[0] != "encrypt" && rest[0] != "decrypt") {
+		fmt.Fprint(os.Stderr, "Usage: goissue credentials encrypt|decrypt\n")
+		exit(exitUsage)
+	}
+	switch rest[0] {
+	case "encrypt":
+		email := config.Email
+		if email == "" {
+			fatal("failed to encrypt credentials: no email in your settings.json")
+		}
+		pass, err := password(config)
+		if err != nil {
+			fatal("failed to encrypt credentials:", err)
+		}
+		passphrase, err := readPassphrase("new passphrase")
+		if err == nil && os.Getenv("GOISSUE_PASSPHRASE") == "" {
+			var again string
+			if again, err = readPassphrase("new passphrase again"); err == nil && again != passphrase {
+				err = errors.New("passphrases don't match")
+			}
+		}
+		if err != nil {
+			fatal("failed to encrypt credentials:", err)
+		}
+		s, err := sealCredentials(email, pass, passphrase)
+		if err != nil {
+			fatal("failed to encrypt credentials:", err)
+		}
+		b, _ := json.MarshalIndent(s, "", "  ")
+		if err := replaceFile(credentialsFile(), append(b, '\n'), 0600); err != nil {
+			fatal("failed to encrypt credentials:", err)
+		}
+		infof("credentials saved in %s; remove \"password\" from %s", credentialsFile(), configFile())
+	case "decrypt":
+		sealed, err := readSealed(config)
+		if err != nil {
+			fatal("failed to decrypt credentials:", err)
+		}
+		if sealed == nil {
+			fatal("failed to decrypt credentials: no " + credentialsFile() + ", or settings have password")
+		}
+		pass, err := password(config)
+		if err != nil {
+			fatal("failed to decrypt credentials:", err)
+		}
+		b, _ := json.Marshal(map[string]string{"email": sealed.Email, "password": pass})
+		fmt.Println(string(b))
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"runtime"
+	"testing"
+)
+
+func TestPBKDF2(t *testing.T) {
+	// RFC 7914, section 11.
+	want := "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc" +
+		"49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"
+	if got := hex.EncodeToString(pbkdf2([]byte("passwd"), []byte("salt"), 1, 64)); got != want {
+		t.Errorf("pbkdf2 = %s, want %s", got, want)
+	}
+}
+
+func TestSealCredentials(t *testing.T) {
+	s, err := sealCredentials("a@example.com", "secret", "open sesame")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := s.open("open sesame"); err != nil || p != "secret" {
+		t.Errorf("open = %q, %v; want secret", p, err)
+	}
+	if _, err := s.open("wrong"); err == nil {
+		t.Error("open with wrong passphrase succeeded")
+	}
+	s.Email = "b@example.com"
+	if _, err := s.open("open sesame"); err == nil {
+		t.Error("open with changed email succeeded")
+	}
+}
+
+func TestPasswordCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	config := &Config{Email: "a@example.com", PasswordCommand: "echo secret; echo comment"}
+	if p, err := password(config); err != nil || p != "secret" {
+		t.Errorf("password = %q, %v; want secret", p, err)
+	}
+	config = &Config{TokenCommand: "true"}
+	if _, err := loginFunc(config)(context.Background()); exitCode(err) != exitAuth {
+		t.Errorf("error of empty token = %v, want auth error", err)
+	}
+}
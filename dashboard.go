@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"sync"
+)
+
+// dashboardSection is a named query in "dashboard" of config. Filters are
+// flags of filters like "-owner me -is open", and Top is the number of
+// issues shown.
+type dashboardSection struct {
+	Name    string `json:"name"`
+	Filters string `json:"filters"`
+	Top     int    `json:"top"`
+
+	filter filter
+}
+
+// loadDashboard return sections of "dashboard" in config, with filters
+// parsed.
+func loadDashboard(config *Config) []dashboardSection {
+	if config.Dashboard == nil {
+		fatal(`no "dashboard" in your settings.json, like [{"name": "Mine", "filters": "-owner me"}]`)
+	}
+	var sections []dashboardSection
+	if err := json.Unmarshal(config.Dashboard, &sections); err != nil {
+		fatal("invalid dashboard in your settings.json:", err)
+	}
+	for i := range sections {
+		d := &sections[i]
+		args, err := splitArgs(d.Filters)
+		if err == nil {
+			fs := flag.NewFlagSet(d.Name, flag.ContinueOnError)
+			fs.SetOutput(ioutil.Discard)
+			d.filter.register(fs)
+			if err = fs.Parse(args); err == nil && fs.NArg() > 0 {
+				err = fmt.Errorf("unexpected %q", fs.Arg(0))
+			}
+		}
+		if err != nil {
+			fatal("invalid filters of "+d.Name+" in dashboard of your settings.json:", err)
+		}
+		// -filter is compiled here not to fail in parallel.
+		d.filter.condition()
+	}
+	return sections
+}
+
+// dashboardFlags is flags of dashboard.
+type dashboardFlags struct {
+	top int
+}
+
+// register add flags of dashboard to the flag set.
+func (o *dashboardFlags) register(fs *flag.FlagSet) {
+	fs.IntVar(&o.top, "top", 5, "number of issues shown in sections without \"top\"")
+}
+
+// showDashboard print sections of "dashboard" in config in one screen: the
+// number of issues matched and the top of them. Sections are fetched in
+// parallel.
+func showDashboard(ctx context.Context, config *Config, c *Client, args []string) {
+	var o dashboardFlags
+	fs := newFlagSet("dashboard")
+	o.register(fs)
+	parseFlags(fs, args)
+
+	sections := loadDashboard(config)
+	results := make([][]Entry, len(sections))
+	errs := make([]error, len(sections))
+	var wg sync.WaitGroup
+	for i := range sections {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f := &sections[i].filter
+			entries, err := c.Entries(ctx, f.values("open"))
+			results[i], errs[i] = f.narrow(entries), err
+		}(i)
+	}
+	wg.Wait()
+
+	headers := !c.Options.Porcelain && !c.Options.IdsOnly
+	for i, d := range sections {
+		if errs[i] != nil {
+			warnf("failed to get issues of %s: %v", d.Name, errs[i])
+			continue
+		}
+		entries := dropSpam(c, results[i])
+		if headers {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s (%d)\n", d.Name, len(entries))
+		}
+		n := d.Top
+		if n <= 0 {
+			n = o.top
+		}
+		for j, entry := range entries {
+			if j == n {
+				if headers {
+					fmt.Printf("%s(%d more)\n", indent(c), len(entries)-n)
+				}
+				break
+			}
+			line := issueId(entry) + ": " + entry.Title
+			if headers {
+				line = indent(c) + line
+			}
+			printIssue(c, entry, line)
+		}
+	}
+}
+
+// indent return the indentation of issues under headers, which is none in
+// plain mode of c.
+func indent(c *Client) string {
+	if c.Options.Plain {
+		return ""
+	}
+	return "  "
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLoadDashboard(t *testing.T) {
+	config := &Config{Dashboard: json.RawMessage(`[{"name": "Mine", "filters": "-owner me -is open"}, {"name": "Fresh", "filters": "-filter \"opened < 7d\"", "top": 3}]`)}
+	sections := loadDashboard(config)
+	if len(sections) != 2 {
+		t.Fatalf("loadDashboard returned %d sections, want 2", len(sections))
+	}
+	if f := sections[0].filter; f.owner != "me" || f.is != "open" {
+		t.Errorf("filters of Mine = %+v", f)
+	}
+	if d := sections[1]; d.filter.cond == nil || d.Top != 3 {
+		t.Errorf("Fresh = %+v, want -filter compiled and top 3", d)
+	}
+}
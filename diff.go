@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// issueLines return the issue as lines to compare: title, state, status,
+// owner and labels, then the text of the description.
+func issueLines(c *Client, entry Entry) ([]string, error) {
+	text, err := renderEntry(c, entry)
+	if err != nil {
+		return nil, err
+	}
+	lines := []string{
+		"Title: " + entry.Title,
+		"State: " + entryState(entry),
+		"Status: " + strings.Join(entry.IssuesStatus, ","),
+		"Owner: " + ownerName(entry),
+	}
+	labels := append([]string(nil), entry.IssuesLabel...)
+	sort.Strings(labels)
+	for _, label := range labels {
+		lines = append(lines, "Label: "+label)
+	}
+	lines = append(lines, "")
+	return append(lines, strings.Split(strings.TrimRight(text, "\n"), "\n")...), nil
+}
+
+// diffOp is a line of difference; kind is ' ' for the line in both, '-'
+// for the line only in old and '+' for the line only in new.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines return lines of a and b as edits from a to b, by the longest
+// common subsequence. Descriptions are short enough for the O(n*m) table.
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+// changed return true if ops have any line added or removed.
+func changed(ops []diffOp) bool {
+	for _, op := range ops {
+		if op.kind != ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+// writeUnified write ops as unified diff with n lines of context around
+// changes.
+func writeUnified(w io.Writer, from, to string, ops []diffOp, n int) {
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", from, to)
+	// line numbers in old and new before each op.
+	oldLine := make([]int, len(ops)+1)
+	newLine := make([]int, len(ops)+1)
+	for i, op := range ops {
+		oldLine[i+1], newLine[i+1] = oldLine[i], newLine[i]
+		if op.kind != '+' {
+			oldLine[i+1]++
+		}
+		if op.kind != '-' {
+			newLine[i+1]++
+		}
+	}
+	span := func(line []int, lo, hi int) string {
+		count := line[hi] - line[lo]
+		if count == 0 {
+			return fmt.Sprintf("%d,0", line[lo])
+		}
+		return fmt.Sprintf("%d,%d", line[lo]+1, count)
+	}
+	for start := 0; start < len(ops); {
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		// changes closer than 2n lines are shown in one hunk.
+		hi := first
+		for {
+			for hi < len(ops) && ops[hi].kind != ' ' {
+				hi++
+			}
+			next := hi
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-hi > 2*n {
+				break
+			}
+			hi = next
+		}
+		lo := first - n
+		if lo < start {
+			lo = start
+		}
+		end := hi + n
+		if end > len(ops) {
+			end = len(ops)
+		}
+		fmt.Fprintf(w, "@@ -%s +%s @@\n", span(oldLine, lo, end), span(newLine, lo, end))
+		for _, op := range ops[lo:end] {
+			fmt.Fprintf(w, "%c%s\n", op.kind, op.line)
+		}
+		start = end
+	}
+}
+
+// writeSideBySide write ops in two columns of the width, like diff -y.
+// Lines removed and added in a row are paired as changed with '|'.
+func writeSideBySide(w io.Writer, from, to string, ops []diffOp, width int) {
+	col := (width - 3) / 2
+	if col < 10 {
+		col = 10
+	}
+	cell := func(s string) string {
+		r := []rune(strings.Replace(s, "\t", "    ", -1))
+		if len(r) > col {
+			r = r[:col]
+		}
+		return string(r) + strings.Repeat(" ", col-len(r))
+	}
+	row := func(left string, mark byte, right string) {
+		fmt.Fprintln(w, strings.TrimRight(cell(left)+" "+string(mark)+" "+right, " "))
+	}
+	row(from, ' ', to)
+	row(strings.Repeat("-", col), ' ', strings.Repeat("-", col))
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			row(ops[i].line, ' ', ops[i].line)
+			i++
+			continue
+		}
+		var removed, added []string
+		for ; i < len(ops) && ops[i].kind == '-'; i++ {
+			removed = append(removed, ops[i].line)
+		}
+		for ; i < len(ops) && ops[i].kind == '+'; i++ {
+			added = append(added, ops[i].line)
+		}
+		for j := 0; j < len(removed) || j < len(added); j++ {
+			switch {
+			case j < len(removed) && j < len(added):
+				row(removed[j], '|', added[j])
+			case j < len(removed):
+				row(removed[j], '<', "")
+			default:
+				row("", '>', added[j])
+			}
+		}
+	}
+}
+
+// diffFlags is flags of diff.
+type diffFlags struct {
+	lines int
+	keep  bool
+	side  bool
+	width int
+}
+
+// register add flags of diff to the flag set.
+func (o *diffFlags) register(fs *flag.FlagSet) {
+	fs.IntVar(&o.lines, "U", 3, "lines of context")
+	fs.BoolVar(&o.keep, "keep", false, "don't save the current issue into the local store")
+	fs.BoolVar(&o.side, "side-by-side", false, "show the issues in two columns")
+	fs.IntVar(&o.width, "width", 130, "width of -side-by-side output")
+}
+
+// diffIssue print what changed in the issue since the copy in the local
+// store, and save the current issue as the copy unless -keep is given.
+// With two IDs, the issues are compared with each other, to tell whether
+// they are duplicates.
+func diffIssue(ctx context.Context, config *Config, c *Client, args []string) {
+	var o diffFlags
+	fs := newFlagSet("diff")
+	o.register(fs)
+	rest := parseFlags(fs, args)
+	if len(rest) != 1 && len(rest) != 2 {
+		fmt.Fprint(os.Stderr, "Usage: goissue diff [-U N] [-keep] [-side-by-side] ID\n")
+		fmt.Fprint(os.Stderr, "       goissue diff [-U N] [-side-by-side] ID1 ID2\n")
+		fs.PrintDefaults()
+		exit(exitUsage)
+	}
+	write := func(from, to string, a, b []string) {
+		ops := diffLines(a, b)
+		switch {
+		case o.side:
+			writeSideBySide(os.Stdout, from, to, ops, o.width)
+		case changed(ops):
+			writeUnified(os.Stdout, from, to, ops, o.lines)
+		}
+	}
+
+	if len(rest) == 2 {
+		var text [2][]string
+		for i, id := range rest {
+			entry, err := c.Entry(ctx, id)
+			if err != nil {
+				fatal("failed to get issue:", err)
+			}
+			if text[i], err = issueLines(c, entry); err != nil {
+				fatal("failed to parse xml:", err)
+			}
+		}
+		write(rest[0], rest[1], text[0], text[1])
+		return
+	}
+
+	id := rest[0]
+	old, ok := loadStore(c.Project).Entries[id]
+	if !ok {
+		fatal("issue " + id + " is not in the local store; run: goissue sync")
+	}
+	c.Cache.forget(c.IssueURL(id))
+	current, err := c.Entry(ctx, id)
+	if err != nil {
+		fatal("failed to get issue:", err)
+	}
+	a, err := issueLines(c, old)
+	if err != nil {
+		fatal("failed to parse xml:", err)
+	}
+	b, err := issueLines(c, current)
+	if err != nil {
+		fatal("failed to parse xml:", err)
+	}
+	write(id+"@"+old.Updated, id+"@"+current.Updated, a, b)
+	if o.keep || current.Updated == old.Updated {
+		return
+	}
+	unlock, err := lockFile(storeFile(c.Project))
+	if err != nil {
+		fatal("failed to write store:", err)
+	}
+	s := loadStore(c.Project)
+	s.Entries[id] = current
+	s.save()
+	unlock()
+}
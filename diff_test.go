@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteUnified(t *testing.T) {
+	a := strings.Split("a b c d e f g h i j", " ")
+	b := strings.Split("a B c d e f g h i j k", " ")
+	var buf bytes.Buffer
+	writeUnified(&buf, "old", "new", diffLines(a, b), 1)
+	want := `--- old
++++ new
+@@ -1,3 +1,3 @@
+ a
+-b
++B
+ c
+@@ -10,1 +10,2 @@
+ j
++k
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	if changed(diffLines(a, a)) {
+		t.Error("same lines are changed")
+	}
+}
+
+func TestIssueLines(t *testing.T) {
+	entry := Entry{
+		Title:        "crash",
+		IssuesState:  []string{"open"},
+		IssuesStatus: []string{"New"},
+		IssuesLabel:  []string{"Type-Defect", "OS-Linux"},
+		Content:      "it crashes",
+	}
+	lines, err := issueLines(&Client{}, entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Title: crash", "State: open", "Status: New", "Owner: ", "Label: OS-Linux", "Label: Type-Defect", "", "it crashes"}
+	// the text is indented by render.
+	lines[len(lines)-1] = strings.TrimSpace(lines[len(lines)-1])
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", lines, want)
+	}
+}
+
+func TestWriteSideBySide(t *testing.T) {
+	a := []string{"same", "old", "gone"}
+	b := []string{"same", "new"}
+	var buf bytes.Buffer
+	writeSideBySide(&buf, "1", "2", diffLines(a, b), 23)
+	want := `1            2
+----------   ----------
+same         same
+old        | new
+gone       <
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// doctorCheck is a check of doctor. It return what was found, and the fix
+// if something is wrong.
+type doctorCheck struct {
+	name  string
+	check func(ctx context.Context) (found, fix string, ok bool)
+}
+
+// checkPerm return fix if the file can be read by others.
+func checkPerm(file string, perm os.FileMode) (string, bool) {
+	if runtime.GOOS == "windows" {
+		return "", true
+	}
+	fi, err := os.Stat(file)
+	if err != nil {
+		return "", true
+	}
+	if fi.Mode().Perm()&0077 != 0 {
+		return fmt.Sprintf("run: chmod %o %s", perm, file), false
+	}
+	return "", true
+}
+
+// runDoctor check settings and the environment, and print what to fix.
+// Return exit code.
+func runDoctor(ctx context.Context) int {
+	var config *Config
+	var c *Client
+	checks := []doctorCheck{
+		{"settings", func(ctx context.Context) (string, string, bool) {
+			var err error
+			config, err = readConfig("")
+			if err != nil {
+				return err.Error(), "create " + configFile() + ` like {"email": "...", "password": "..."}, or {} to access anonymously`, false
+			}
+			files := configFiles(config.Project)
+			if len(files) == 0 {
+				return "no settings files; reading public issues anonymously", "", true
+			}
+			return strings.Join(files, ", "), "", true
+		}},
+		{"permissions", func(ctx context.Context) (string, string, bool) {
+			if fix, ok := checkPerm(configDir(), 0700); !ok {
+				return configDir() + " can be read by others", fix, false
+			}
+			if fix, ok := checkPerm(configFile(), 0600); !ok {
+				return configFile() + " can be read by others", fix, false
+			}
+			return "only you can read settings", "", true
+		}},
+		{"login", func(ctx context.Context) (string, string, bool) {
+			if config == nil {
+				return "skipped", "", true
+			}
+			c = newClient(config, "")
+			f := loginFunc(config)
+			if f == nil {
+				return "anonymous", "", true
+			}
+			auth, err := f(ctx)
+			if err != nil {
+				return err.Error(), `check "email" and "password" (or "password_command", "token_command") in settings.json`, false
+			}
+			c.Auth = auth
+			if config.TokenCommand != "" {
+				return "token from token_command", "", true
+			}
+			return "logged in as " + config.Email, "", true
+		}},
+		{"project", func(ctx context.Context) (string, string, bool) {
+			if c == nil {
+				return "skipped", "", true
+			}
+			feed, err := c.Feed(ctx, c.IssuesURL()+"?max-results=1")
+			if err != nil {
+				return err.Error(), `check "project" in settings.json and the network`, false
+			}
+			return fmt.Sprintf("%s has %d issues", c.Project, feed.TotalResults), "", true
+		}},
+		{"editor", func(ctx context.Context) (string, string, bool) {
+			editor := editorCommand()
+			path, err := exec.LookPath(editor)
+			if err != nil {
+				return err.Error(), "install " + editor + ", or set EDITOR", false
+			}
+			return path, "", true
+		}},
+		{"cache", func(ctx context.Context) (string, string, bool) {
+			dir := filepath.Join(configDir(), "cache")
+			err := os.MkdirAll(dir, 0700)
+			if err == nil {
+				var f *os.File
+				f, err = ioutil.TempFile(dir, "doctor")
+				if err == nil {
+					f.Close()
+					err = os.Remove(f.Name())
+				}
+			}
+			if err != nil {
+				return err.Error(), "make " + dir + " writable, or run: goissue cache clear", false
+			}
+			return dir + " is writable", "", true
+		}},
+	}
+
+	code := 0
+	for _, ch := range checks {
+		found, fix, ok := ch.check(ctx)
+		if ok {
+			fmt.Printf("ok   %-12s %s\n", ch.name, found)
+			continue
+		}
+		code = 1
+		fmt.Printf("FAIL %-12s %s\n", ch.name, found)
+		fmt.Printf("     %-12s fix: %s\n", "", fix)
+	}
+	return code
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// cp1252 is characters of windows-1252 at 0x80-0x9f which differ from
+// Latin-1. HTML parsers give them for &#128; to &#159; too.
+var cp1252 = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+	0x88: 'ˆ', 0x89: '‰', 0x8a: 'Š', 0x8b: '‹', 0x8c: 'Œ', 0x8e: 'Ž',
+	0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+	0x98: '˜', 0x99: '™', 0x9a: 'š', 0x9b: '›', 0x9c: 'œ', 0x9e: 'ž', 0x9f: 'Ÿ',
+}
+
+// cp1252Byte return the byte of the rune in windows-1252.
+func cp1252Byte(r rune) (byte, bool) {
+	if r < 0x100 {
+		return byte(r), true
+	}
+	for b, c := range cp1252 {
+		if c == r {
+			return b, true
+		}
+	}
+	return 0, false
+}
+
+// charsetRE match charsets declared in contents, like <meta
+// charset="shift_jis"> or encoding="euc-jp".
+var charsetRE = regexp.MustCompile(`(?i)\b(?:charset|encoding)\s*=\s*["']?([a-z][a-z0-9_\-]*)`)
+
+// declaredCharset return the charset declared in the content in lower
+// case, or "" if none or UTF-8.
+func declaredCharset(content string) string {
+	m := charsetRE.FindStringSubmatch(content)
+	if m == nil {
+		return ""
+	}
+	cs := strings.ToLower(m[1])
+	if cs == "utf-8" || cs == "utf8" {
+		return ""
+	}
+	return cs
+}
+
+// isLatin1 return true if the charset is Latin-1 or its superset
+// windows-1252, which goissue convert by itself. Latin-1 is taken as
+// windows-1252 as browsers do.
+func isLatin1(charset string) bool {
+	switch charset {
+	case "latin1", "latin-1", "iso-8859-1", "iso8859-1", "windows-1252", "cp1252", "us-ascii":
+		return true
+	}
+	return false
+}
+
+// shiftJISPairs return the number of double byte characters in b if every
+// byte over 0x7f is a part of Shift_JIS character, or -1. Half-width
+// katakana are counted as well.
+func shiftJISPairs(b []byte) int {
+	n := 0
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c < 0x80:
+		case c >= 0xa1 && c <= 0xdf:
+			n++
+		case (c >= 0x81 && c <= 0x9f) || (c >= 0xe0 && c <= 0xfc):
+			if i+1 == len(b) {
+				return -1
+			}
+			t := b[i+1]
+			if t < 0x40 || t == 0x7f || t > 0xfc {
+				return -1
+			}
+			n++
+			i++
+		default:
+			return -1
+		}
+	}
+	return n
+}
+
+// guessCharset return the charset b is likely written in: "utf-8",
+// "shift_jis" or "windows-1252".
+func guessCharset(b []byte) string {
+	switch {
+	case utf8.Valid(b):
+		return "utf-8"
+	case shiftJISPairs(b) >= 2:
+		return "shift_jis"
+	}
+	return "windows-1252"
+}
+
+// decodeBytes convert b in the charset to UTF-8. Latin-1 and windows-1252
+// are converted by goissue, and others by iconv.
+func decodeBytes(b []byte, charset string) (string, error) {
+	if isLatin1(charset) {
+		r := make([]rune, len(b))
+		for i, c := range b {
+			r[i] = rune(c)
+			if x, ok := cp1252[c]; ok {
+				r[i] = x
+			}
+		}
+		return string(r), nil
+	}
+	cmd := exec.Command("iconv", "-f", charset, "-t", "UTF-8")
+	cmd.Stdin = bytes.NewReader(b)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// fixEncoding return the content converted to UTF-8. Content which isn't
+// valid UTF-8 is converted from the charset declared in it, or guessed.
+// In valid UTF-8, fragments that were decoded in wrong encoding (Latin-1
+// characters that were UTF-8 or Shift_JIS bytes) are converted back.
+// Fragments which can't be converted are left as is.
+func fixEncoding(content string) string {
+	declared := declaredCharset(content)
+	if !utf8.ValidString(content) {
+		cs := declared
+		if cs == "" {
+			cs = guessCharset([]byte(content))
+		}
+		s, err := decodeBytes([]byte(content), cs)
+		if err != nil {
+			debugf("failed to convert content from %s: %v", cs, err)
+			return strings.ToValidUTF8(content, "\ufffd")
+		}
+		return s
+	}
+
+	var out strings.Builder
+	start := -1
+	for i, r := range content {
+		_, ok := cp1252Byte(r)
+		if ok && !unicode.IsSpace(r) && r != '<' && r != '>' {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			out.WriteString(fixFragment(content[start:i], declared))
+			start = -1
+		}
+		out.WriteRune(r)
+	}
+	if start >= 0 {
+		out.WriteString(fixFragment(content[start:], declared))
+	}
+	return out.String()
+}
+
+// fixFragment return the fragment, a word of characters in windows-1252,
+// converted back to the characters its bytes were if it was decoded in
+// wrong encoding.
+func fixFragment(original, declared string) string {
+	var run []byte
+	high := false
+	for _, r := range original {
+		b, _ := cp1252Byte(r)
+		run = append(run, b)
+		high = high || b >= 0x80
+	}
+	if !high {
+		return original
+	}
+	if utf8.Valid(run) {
+		return string(run)
+	}
+	cs := declared
+	if cs == "" && shiftJISPairs(run) >= 2 {
+		cs = "shift_jis"
+	}
+	if cs == "" || isLatin1(cs) {
+		return original
+	}
+	s, err := decodeBytes(run, cs)
+	if err != nil {
+		debugf("failed to convert %q from %s: %v", original, cs, err)
+		return original
+	}
+	return s
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFixEncoding(t *testing.T) {
+	for _, tt := range []struct {
+		in, want string
+	}{
+		{"plain text", "plain text"},
+		{"café naïve", "café naïve"},
+		{"日本語 text", "日本語 text"},
+		// UTF-8 read as windows-1252.
+		{"it says æ—¥æœ¬ here", "it says 日本 here"},
+		{"<b>cafÃ©</b>", "<b>café</b>"},
+		// invalid UTF-8 in Latin-1.
+		{"caf\xe9 \x93quoted\x94", "café “quoted”"},
+	} {
+		if got := fixEncoding(tt.in); got != tt.want {
+			t.Errorf("fixEncoding(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	if got, err := render("cafÃ©", Options{RawBytes: true}); err != nil || strings.TrimSpace(got) != "cafÃ©" {
+		t.Errorf("render with -raw-bytes = %q, %v; want as is", got, err)
+	}
+}
+
+func TestGuessCharset(t *testing.T) {
+	for _, tt := range []struct {
+		in, want string
+	}{
+		{"ascii", "utf-8"},
+		{"日本", "utf-8"},
+		{"\x93\xfa\x96\x7b\x8c\xea", "shift_jis"},
+		{"na\xefve", "windows-1252"},
+	} {
+		if got := guessCharset([]byte(tt.in)); got != tt.want {
+			t.Errorf("guessCharset(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := declaredCharset(`<meta charset="Shift_JIS">`); got != "shift_jis" {
+		t.Errorf("declaredCharset = %q, want shift_jis", got)
+	}
+}
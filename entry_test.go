@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func readFixture(t testing.TB, name string, v interface{}) {
+	b, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := xml.Unmarshal(b, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEntryUnmarshal(t *testing.T) {
+	var entry Entry
+	readFixture(t, "entry.xml", &entry)
+
+	tests := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"Etag", entry.Etag, `W/"DUUFQH47eCl7ImA9WxBbFEg."`},
+		{"Id", entry.Id, "http://code.google.com/feeds/issues/p/go/issues/full/1234"},
+		{"Published", entry.Published, "2012-01-02T03:04:05.000Z"},
+		{"Updated", entry.Updated, "2012-02-03T04:05:06.000Z"},
+		{"Title", entry.Title, "runtime: crash on arm"},
+		{"Content", entry.Content, "What steps will reproduce the problem?\n1. run the program"},
+		{"Link", len(entry.Link), 2},
+		{"Link.Href", entry.Link[1].Href, "http://code.google.com/p/go/issues/detail?id=1234"},
+		{"Link.Rel", entry.Link[1].Rel, "alternate"},
+		{"Link.Type", entry.Link[1].Type, "text/html"},
+		{"Author", entry.Author, []Author{{Name: "gopher", Uri: "/u/gopher/"}}},
+		{"IssuesCc", entry.IssuesCc, []IssuesCc{{IssuesUri: "/u/rsc/", IssuesUsername: "rsc"}}},
+		{"IssuesLabel", entry.IssuesLabel, []string{"Type-Defect", "Priority-Critical", "Go1.1"}},
+		{"IssuesOwner", entry.IssuesOwner, []IssuesOwner{{IssuesUri: "/u/adg/", IssuesUsername: "adg"}}},
+		{"IssuesStars", entry.IssuesStars, []int{12}},
+		{"IssuesState", entry.IssuesState, []string{"closed"}},
+		{"IssuesStatus", entry.IssuesStatus, []string{"Fixed"}},
+		{"IssuesBlockedOn", entry.IssuesBlockedOn, []IssuesRef{{IssuesId: "1000", IssuesProject: "go"}}},
+		{"IssuesBlocking", entry.IssuesBlocking, []IssuesRef{{IssuesId: "2000"}}},
+		{"IssuesMergedInto", entry.IssuesMergedInto, []IssuesRef{{IssuesId: "999", IssuesProject: "go"}}},
+		{"IssuesClosedDate", entry.IssuesClosedDate, "2012-02-03T04:05:06.000Z"},
+		{"issueId", issueId(entry), "1234"},
+	}
+	for _, test := range tests {
+		if !reflect.DeepEqual(test.got, test.want) {
+			t.Errorf("%s: want %#v, got %#v", test.name, test.want, test.got)
+		}
+	}
+}
+
+func TestCommentUpdatesUnmarshal(t *testing.T) {
+	var feed Feed
+	readFixture(t, "comments.xml", &feed)
+
+	if len(feed.Entry) != 1 {
+		t.Fatalf("want 1 entry, got %d", len(feed.Entry))
+	}
+	if feed.TotalResults != 1 || feed.StartIndex != 1 || feed.ItemsPerPage != 25 {
+		t.Errorf("want paging 1/1/25, got %d/%d/%d", feed.TotalResults, feed.StartIndex, feed.ItemsPerPage)
+	}
+	u := feed.Entry[0].IssuesUpdates
+	if u == nil {
+		t.Fatal("updates is not unmarshaled")
+	}
+	want := IssuesUpdates{
+		IssuesSummary:          "runtime: crash on arm",
+		IssuesStatus:           "Accepted",
+		IssuesOwnerUpdate:      "adg",
+		IssuesLabel:            []string{"Go1.1", "-Priority-Medium"},
+		IssuesCcUpdate:         []string{"rsc"},
+		IssuesMergedIntoUpdate: "999",
+		IssuesBlockedOnUpdate:  []string{"1000"},
+	}
+	if !reflect.DeepEqual(*u, want) {
+		t.Errorf("want %#v, got %#v", want, *u)
+	}
+}
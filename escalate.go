@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// escalateComment is the comment posted by escalate unless
+// "escalate_comment" is in config. $from, $to, $reason and $cc are
+// replaced.
+const escalateComment = `Escalating from $from to $to.
+
+$reason`
+
+// areaOwners return "area_owners" in config, owners to cc by label.
+func areaOwners(config *Config) map[string][]string {
+	owners := map[string][]string{}
+	if config.AreaOwners != nil {
+		if err := json.Unmarshal(config.AreaOwners, &owners); err != nil {
+			fatal("invalid area_owners in your settings.json:", err)
+		}
+	}
+	return owners
+}
+
+// escalateFlags is flags of escalate.
+type escalateFlags struct {
+	reason string
+	to     string
+	dryRun bool
+}
+
+// register add flags of escalate to the flag set.
+func (o *escalateFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.reason, "m", "", "why the issue is escalated")
+	fs.StringVar(&o.to, "to", "", "priority label to raise to, instead of the next one")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "print the update without posting it")
+}
+
+// escalateIssue raise the priority label of the issue one step, cc the
+// owners of its areas, and post a comment telling why, in one update.
+func escalateIssue(ctx context.Context, config *Config, c *Client, args []string) {
+	var o escalateFlags
+	fs := newFlagSet("escalate")
+	o.register(fs)
+	rest := parseFlags(fs, args)
+	if len(rest) != 1 || o.reason == "" {
+		fmt.Fprint(os.Stderr, "Usage: goissue escalate -m REASON [-to LABEL] [-dry-run] ID\n")
+		fs.PrintDefaults()
+		exit(exitUsage)
+	}
+
+	id := rest[0]
+	entry, err := c.Entry(ctx, id)
+	if err != nil {
+		fatal("failed to get issue:", err)
+	}
+	priorities := config.Priorities
+	if len(priorities) == 0 {
+		fatal("no \"priorities\" in your settings.json")
+	}
+	current := -1
+	for i, p := range priorities {
+		if hasLabel(entry, p) {
+			current = i
+		}
+	}
+	next := current + 1
+	if o.to != "" {
+		next = -1
+		for i, p := range priorities {
+			if strings.EqualFold(p, o.to) {
+				next = i
+			}
+		}
+		if next < 0 {
+			fatal(o.to + " is not in \"priorities\" of your settings.json")
+		}
+	}
+	if next >= len(priorities) {
+		fatal("issue " + id + " already has the highest priority " + priorities[current])
+	}
+	if next <= current {
+		fatal("issue " + id + " already has " + priorities[current])
+	}
+
+	u := &Updates{Labels: []string{priorities[next]}}
+	from := "no priority"
+	if current >= 0 {
+		from = priorities[current]
+		u.Labels = append([]string{"-" + from}, u.Labels...)
+	}
+	// owners of areas of the issue who are not on it yet.
+	seen := map[string]bool{ownerName(entry): true}
+	for _, cc := range entry.IssuesCc {
+		seen[cc.IssuesUsername] = true
+	}
+	owners := areaOwners(config)
+	for _, label := range append(entry.IssuesLabel, priorities[next]) {
+		for _, owner := range owners[label] {
+			if !seen[owner] {
+				seen[owner] = true
+				u.Cc = append(u.Cc, owner)
+			}
+		}
+	}
+	sort.Strings(u.Cc)
+
+	template := escalateComment
+	if t := config.EscalateComment; t != "" {
+		template = toLF(t)
+	}
+	body := os.Expand(template, func(key string) string {
+		switch key {
+		case "from":
+			return from
+		case "to":
+			return priorities[next]
+		case "reason":
+			return o.reason
+		case "cc":
+			return strings.Join(u.Cc, ", ")
+		}
+		return "$" + key
+	})
+	if o.dryRun {
+		fmt.Printf("labels: %s\n", strings.Join(u.Labels, " "))
+		fmt.Printf("cc: %s\n", strings.Join(u.Cc, ", "))
+		fmt.Println(body)
+		return
+	}
+	if !checkSecrets(config, body) {
+		fatal("canceled")
+	}
+	if err := updateIssue(ctx, c, config.Email, entry, body, u); err != nil {
+		fatal("failed to escalate issue:", err)
+	}
+	infof("issue %s escalated to %s", id, priorities[next])
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync"
+)
+
+// Exit codes of goissue, for scripts to branch on outcomes.
+const (
+	exitOK       = 0
+	exitError    = 1 // generic error
+	exitUsage    = 2 // invalid command line
+	exitAuth     = 3 // authentication failed or required
+	exitNotFound = 4 // no such issue, or no issues matched
+	exitNetwork  = 5 // the tracker can't be reached
+)
+
+// authError is an error of logging in.
+type authError struct {
+	err error
+}
+
+func (e *authError) Error() string { return e.err.Error() }
+
+// exitCode return exit code for the error.
+func exitCode(err error) int {
+	var apiErr *APIError
+	var netErr net.Error
+	var authErr *authError
+	switch {
+	case err == nil:
+		return exitOK
+	case err == errAuthRequired, errors.As(err, &authErr):
+		return exitAuth
+	case errors.As(err, &apiErr):
+		if apiErr.Permission() {
+			return exitAuth
+		}
+		if apiErr.StatusCode == 404 {
+			return exitNotFound
+		}
+	case errors.As(err, &netErr):
+		return exitNetwork
+	}
+	return exitError
+}
+
+// cleanups is functions run by exit, like releasing locks and writing
+// profiles, since os.Exit skip deferred calls.
+var (
+	cleanupMu sync.Mutex
+	cleanups  []*func()
+)
+
+// atExit register f to be run by exit or runCleanups, and return function
+// to unregister it.
+func atExit(f func()) (cancel func()) {
+	p := &f
+	cleanupMu.Lock()
+	cleanups = append(cleanups, p)
+	cleanupMu.Unlock()
+	return func() {
+		cleanupMu.Lock()
+		defer cleanupMu.Unlock()
+		for i, c := range cleanups {
+			if c == p {
+				cleanups = append(cleanups[:i], cleanups[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// runCleanups run registered cleanups in the reverse order, once each.
+func runCleanups() {
+	for {
+		cleanupMu.Lock()
+		if len(cleanups) == 0 {
+			cleanupMu.Unlock()
+			return
+		}
+		f := cleanups[len(cleanups)-1]
+		cleanups = cleanups[:len(cleanups)-1]
+		cleanupMu.Unlock()
+		(*f)()
+	}
+}
+
+// exit run cleanups and exit with the code. Use it instead of os.Exit.
+func exit(code int) {
+	runCleanups()
+	os.Exit(code)
+}
+
+// fatal is like log.Fatal, but exit with the code for the first error in v.
+// Cleanups are run before exiting, so that locks aren't left.
+func fatal(v ...interface{}) {
+	log.Print(trArgs(v)...)
+	exit(codeOf(v))
+}
+
+// fatalf is like log.Fatalf, but exit with the code for the first error in
+// v.
+func fatalf(format string, v ...interface{}) {
+	log.Print(fmt.Sprintf(tr(format), v...))
+	exit(codeOf(v))
+}
+
+// trArgs return v with messages translated. Errors are translated only if
+// the whole text is in the catalog.
+func trArgs(v []interface{}) []interface{} {
+	t := make([]interface{}, len(v))
+	for i, a := range v {
+		switch a := a.(type) {
+		case string:
+			t[i] = tr(a)
+		case error:
+			if s := tr(a.Error()); s != a.Error() {
+				t[i] = s
+			} else {
+				t[i] = a
+			}
+		default:
+			t[i] = a
+		}
+	}
+	return t
+}
+
+func codeOf(v []interface{}) int {
+	for _, a := range v {
+		if err, ok := a.(error); ok {
+			return exitCode(err)
+		}
+	}
+	return exitError
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"exp/html"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// thread is an issue with its comments.
+type thread struct {
+	Issue    Entry   `json:"issue"`
+	Comments []Entry `json:"comments"`
+	URL      string  `json:"url"`
+}
+
+// fetchThread return the issue and all comments of it.
+func fetchThread(ctx context.Context, c *Client, id string) (thread, error) {
+	entry, err := c.Entry(ctx, id)
+	if err != nil {
+		return thread{}, err
+	}
+	comments, err := c.Comments(ctx, id)
+	if err != nil {
+		return thread{}, err
+	}
+	link := webLink(entry)
+	if link == "" {
+		link = c.IssueWebURL(id)
+	}
+	return thread{entry, comments, link}, nil
+}
+
+// contentLinks return URLs linked from html content, like attachments.
+// javascript: links are not URLs of anything and dropped.
+func contentLinks(content string) []string {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return nil
+	}
+	var links []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			for _, a := range n.Attr {
+				if a.Key == "href" && !strings.HasPrefix(strings.ToLower(strings.TrimSpace(a.Val)), "javascript:") {
+					links = append(links, a.Val)
+				}
+			}
+		}
+		for _, c := range n.Child {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return links
+}
+
+// webLink return URL of the entry on the web.
+func webLink(entry Entry) string {
+	for _, l := range entry.Link {
+		if l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// authorName return name of the first author of the entry.
+func authorName(entry Entry) string {
+	if len(entry.Author) == 0 {
+		return ""
+	}
+	return entry.Author[0].Name
+}
+
+// formatTime return time in the feed as readable text.
+func formatTime(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return s
+	}
+	return t.UTC().Format("2006-01-02 15:04 MST")
+}
+
+// writeText write the thread as markdown text.
+func (t thread) writeText(w io.Writer, c *Client) error {
+	e := t.Issue
+	fmt.Fprintf(w, "# Issue %s: %s\n\n", issueId(e), e.Title)
+	fmt.Fprintf(w, "- Reported by %s on %s\n", authorName(e), formatTime(e.Published))
+	fmt.Fprintf(w, "- Status: %s\n", strings.Join(e.IssuesStatus, ", "))
+	if owner := ownerName(e); owner != "" {
+		fmt.Fprintf(w, "- Owner: %s\n", owner)
+	}
+	if len(e.IssuesLabel) > 0 {
+		fmt.Fprintf(w, "- Labels: %s\n", strings.Join(e.IssuesLabel, ", "))
+	}
+	if t.URL != "" {
+		fmt.Fprintf(w, "- URL: %s\n", t.URL)
+	}
+	text, err := renderEntry(c, e)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\n%s\n", strings.TrimSpace(text))
+	links := contentLinks(e.Content)
+	for i, comment := range t.Comments {
+		fmt.Fprintf(w, "\n## Comment %d by %s on %s\n\n", i+1, authorName(comment), formatTime(comment.Published))
+		if u := comment.IssuesUpdates; u != nil {
+			if u.IssuesStatus != "" {
+				fmt.Fprintf(w, "- Status: %s\n", u.IssuesStatus)
+			}
+			if len(u.IssuesLabel) > 0 {
+				fmt.Fprintf(w, "- Labels: %s\n", strings.Join(u.IssuesLabel, ", "))
+			}
+			if u.IssuesOwnerUpdate != "" {
+				fmt.Fprintf(w, "- Owner: %s\n", u.IssuesOwnerUpdate)
+			}
+			fmt.Fprintln(w)
+		}
+		text, err := renderEntry(c, comment)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", strings.TrimSpace(text))
+		links = append(links, contentLinks(comment.Content)...)
+	}
+	if len(links) > 0 {
+		fmt.Fprint(w, "\n## Attachments and links\n\n")
+		for _, link := range links {
+			fmt.Fprintf(w, "- %s\n", link)
+		}
+	}
+	return nil
+}
+
+// messageId return Message-ID of the issue, or the nth comment of it, for
+// email. n is 0 for the issue itself.
+func messageId(project, id string, n int) string {
+	return fmt.Sprintf("<%s.issue%s.%d@goissue>", project, id, n)
+}
+
+// writeMessage write an email message of the entry. eol is "\r\n" for
+// eml files, or "\n" for mbox. inReplyTo is Message-ID of the parent, or
+// empty.
+func writeMessage(w io.Writer, eol string, entry Entry, subject, id, inReplyTo, body string) error {
+	date, _ := time.Parse(time.RFC3339, entry.Published)
+	h := "From: " + authorName(entry) + eol +
+		"Date: " + date.Format(time.RFC1123Z) + eol +
+		"Subject: " + subject + eol +
+		"Message-ID: " + id + eol
+	if inReplyTo != "" {
+		h += "In-Reply-To: " + inReplyTo + eol + "References: " + inReplyTo + eol
+	}
+	h += "MIME-Version: 1.0" + eol +
+		"Content-Type: text/plain; charset=UTF-8" + eol +
+		"Content-Transfer-Encoding: 8bit" + eol + eol
+	_, err := io.WriteString(w, h+strings.Replace(body, "\n", eol, -1))
+	return err
+}
+
+// writeMail write the thread as an email message.
+func (t thread) writeMail(w io.Writer, c *Client) error {
+	var b bytes.Buffer
+	if err := t.writeText(&b, c); err != nil {
+		return err
+	}
+	e := t.Issue
+	subject := fmt.Sprintf("[%s] Issue %s: %s", c.Project, issueId(e), e.Title)
+	return writeMessage(w, "\r\n", e, subject, messageId(c.Project, issueId(e), 0), "", b.String())
+}
+
+// mboxQuote escape lines of body that look like the separator of mbox.
+func mboxQuote(body string) string {
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+			lines[i] = ">" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeMbox write the issue and each comment as messages in mbox format.
+// Comments are replies to the issue, so that mail readers show them as a
+// thread.
+func (t thread) writeMbox(w io.Writer, c *Client) error {
+	e := t.Issue
+	id := issueId(e)
+	subject := fmt.Sprintf("[%s] Issue %s: %s", c.Project, id, e.Title)
+	parent := messageId(c.Project, id, 0)
+	write := func(entry Entry, subject, msgid, inReplyTo, body string) error {
+		date, _ := time.Parse(time.RFC3339, entry.Published)
+		fmt.Fprintf(w, "From goissue %s\n", date.UTC().Format(time.ANSIC))
+		if err := writeMessage(w, "\n", entry, subject, msgid, inReplyTo, mboxQuote(body)); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	}
+
+	var b bytes.Buffer
+	if err := (thread{Issue: e, URL: t.URL}).writeText(&b, c); err != nil {
+		return err
+	}
+	if err := write(e, subject, parent, "", b.String()); err != nil {
+		return err
+	}
+	for i, comment := range t.Comments {
+		text, err := renderEntry(c, comment)
+		if err != nil {
+			return err
+		}
+		body := ""
+		if u := comment.IssuesUpdates; u != nil && u.IssuesStatus != "" {
+			body += "Status: " + u.IssuesStatus + "\n\n"
+		}
+		body += strings.TrimSpace(text) + "\n"
+		if err := write(comment, "Re: "+subject, messageId(c.Project, id, i+1), parent, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportMbox write issues matched to the filter with comments into the
+// mbox file.
+func exportMbox(ctx context.Context, c *Client, f *filter, file string) {
+	entries, err := c.Entries(ctx, f.values("all"))
+	if err != nil {
+		fatal("failed to get issues:", err)
+	}
+	entries = f.narrow(entries)
+	w, err := os.Create(file)
+	if err != nil {
+		fatal("failed to export issues:", err)
+	}
+	defer w.Close()
+	for i, entry := range entries {
+		t, err := fetchThread(ctx, c, issueId(entry))
+		if err != nil {
+			fatal("failed to get issue:", err)
+		}
+		if err := t.writeMbox(w, c); err != nil {
+			fatal("failed to export issues:", err)
+		}
+		progressf(c, "[%d/%d] exported %s\n", i+1, len(entries), issueId(entry))
+	}
+}
+
+// exportFlags is flags of export.
+type exportFlags struct {
+	format string
+	output string
+	mbox   string
+	filter filter
+}
+
+// register add flags of export to the flag set.
+func (o *exportFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.format, "format", "md", "output format: md, json or eml")
+	fs.StringVar(&o.output, "o", "", "write to the file instead of stdout")
+	fs.StringVar(&o.mbox, "mbox", "", "write issues matched to filters into the mbox file")
+	o.filter.register(fs)
+}
+
+// exportIssue write the issue with comments into a file. With -mbox, issues
+// matched to filters are written into the mbox file.
+func exportIssue(ctx context.Context, config *Config, c *Client, args []string) {
+	var o exportFlags
+	fs := newFlagSet("export")
+	o.register(fs)
+	rest := parseFlags(fs, args)
+	if o.mbox != "" && len(rest) == 0 {
+		exportMbox(ctx, c, &o.filter, o.mbox)
+		return
+	}
+	if len(rest) != 1 {
+		fmt.Fprint(os.Stderr, "Usage: goissue export [-format md|json|eml] [-o FILE] ID\n")
+		fmt.Fprint(os.Stderr, "       goissue export -mbox FILE [filters]\n")
+		fs.PrintDefaults()
+		exit(exitUsage)
+	}
+
+	t, err := fetchThread(ctx, c, rest[0])
+	if err != nil {
+		fatal("failed to get issue:", err)
+	}
+	var b bytes.Buffer
+	switch o.format {
+	case "md":
+		err = t.writeText(&b, c)
+	case "json":
+		var j []byte
+		j, err = json.MarshalIndent(t, "", "  ")
+		b.Write(j)
+		b.WriteString("\n")
+	case "eml":
+		err = t.writeMail(&b, c)
+	default:
+		fatal("unknown format:", o.format)
+	}
+	if err != nil {
+		fatal("failed to export issue:", err)
+	}
+	w := os.Stdout
+	if o.output != "" {
+		w, err = os.Create(o.output)
+		if err != nil {
+			fatal("failed to export issue:", err)
+		}
+		defer w.Close()
+	}
+	if _, err := w.Write(b.Bytes()); err != nil {
+		fatal("failed to export issue:", err)
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// condition is a compiled -filter expression; it report whether the entry
+// satisfy it at the time.
+type condition func(entry Entry, now time.Time) bool
+
+// exprToken is a token of -filter expressions. kind is "word" for field
+// names and bare values, "string" for quoted values, "op" for operators
+// and parentheses, and "" for the end.
+type exprToken struct {
+	kind string
+	text string
+	pos  int
+}
+
+// exprOps is operators of -filter expressions, longer ones first.
+var exprOps = []string{"&&", "||", "==", "!=", "=~", "!~", "<=", ">=", "<", ">", "!", "(", ")"}
+
+// tokenize split the expression into tokens.
+func tokenize(s string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '"':
+			j := i + 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string at %d", i+1)
+			}
+			text, err := strconv.Unquote(s[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at %d: %v", i+1, err)
+			}
+			tokens = append(tokens, exprToken{"string", text, i})
+			i = j + 1
+		default:
+			op := ""
+			for _, o := range exprOps {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op != "" {
+				tokens = append(tokens, exprToken{"op", op, i})
+				i += len(op)
+				continue
+			}
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t\n\"&|=!~<>()", rune(s[j])) {
+				j++
+			}
+			if j == i {
+				return nil, fmt.Errorf("unexpected %q at %d", c, i+1)
+			}
+			tokens = append(tokens, exprToken{"word", s[i:j], i})
+			i = j
+		}
+	}
+	return append(tokens, exprToken{"", "", len(s)}), nil
+}
+
+// exprParser parse tokens of an expression by recursive descent:
+//
+//	expr       = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | "(" expr ")" | comparison
+//	comparison = field op value
+type exprParser struct {
+	tokens []exprToken
+	i      int
+}
+
+func (p *exprParser) peek() exprToken { return p.tokens[p.i] }
+
+func (p *exprParser) next() exprToken {
+	t := p.tokens[p.i]
+	if t.kind != "" {
+		p.i++
+	}
+	return t
+}
+
+func (p *exprParser) errorf(t exprToken, format string, v ...interface{}) error {
+	at := "at the end"
+	if t.kind != "" {
+		at = fmt.Sprintf("at %d", t.pos+1)
+	}
+	return fmt.Errorf(format+" "+at, v...)
+}
+
+func (p *exprParser) expr() (condition, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().text == "||" && p.peek().kind == "op" {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(e Entry, now time.Time) bool { return l(e, now) || right(e, now) }
+	}
+	return left, nil
+}
+
+func (p *exprParser) and() (condition, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().text == "&&" && p.peek().kind == "op" {
+		p.next()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(e Entry, now time.Time) bool { return l(e, now) && right(e, now) }
+	}
+	return left, nil
+}
+
+func (p *exprParser) unary() (condition, error) {
+	t := p.peek()
+	if t.kind == "op" && t.text == "!" {
+		p.next()
+		c, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(e Entry, now time.Time) bool { return !c(e, now) }, nil
+	}
+	if t.kind == "op" && t.text == "(" {
+		p.next()
+		c, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != "op" || t.text != ")" {
+			return nil, p.errorf(t, "want )")
+		}
+		return c, nil
+	}
+	return p.comparison()
+}
+
+// exprFields is fields of issues in -filter expressions by their kinds.
+var exprFields = map[string]string{
+	"id":      "number",
+	"stars":   "number",
+	"title":   "text",
+	"body":    "text",
+	"status":  "text",
+	"state":   "text",
+	"owner":   "text",
+	"author":  "text",
+	"label":   "labels",
+	"opened":  "time",
+	"updated": "time",
+}
+
+// fieldValue return the text or number of the field of the entry.
+func fieldValue(entry Entry, field string) string {
+	switch field {
+	case "id":
+		return issueId(entry)
+	case "stars":
+		if len(entry.IssuesStars) == 0 {
+			return "0"
+		}
+		return strconv.Itoa(entry.IssuesStars[0])
+	case "title":
+		return entry.Title
+	case "body":
+		return entry.Content
+	case "status":
+		return strings.Join(entry.IssuesStatus, ",")
+	case "state":
+		return entryState(entry)
+	case "owner":
+		return ownerName(entry)
+	case "author":
+		return authorName(entry)
+	case "opened":
+		return entry.Published
+	case "updated":
+		return entry.Updated
+	}
+	return ""
+}
+
+func (p *exprParser) comparison() (condition, error) {
+	ft := p.next()
+	kind, ok := exprFields[ft.text]
+	if ft.kind != "word" || !ok {
+		return nil, p.errorf(ft, "want a field like stars, label or updated")
+	}
+	field := ft.text
+	ot := p.next()
+	op := ot.text
+	if ot.kind != "op" || !strings.Contains(" == != =~ !~ < <= > >= ", " "+op+" ") {
+		return nil, p.errorf(ot, "want an operator like ==, =~ or <")
+	}
+	vt := p.next()
+	if vt.kind != "word" && vt.kind != "string" {
+		return nil, p.errorf(vt, "want a value")
+	}
+	value := vt.text
+
+	if op == "=~" || op == "!~" {
+		if kind == "number" || kind == "time" {
+			return nil, p.errorf(ot, "%s can't be used for %s", op, field)
+		}
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, p.errorf(vt, "invalid regular expression (%v)", err)
+		}
+		return textCondition(field, op == "!~", re.MatchString), nil
+	}
+	switch kind {
+	case "number":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, p.errorf(vt, "want a number")
+		}
+		return func(e Entry, now time.Time) bool {
+			v, _ := strconv.Atoi(fieldValue(e, field))
+			return compare(op, int64(v-n))
+		}, nil
+	case "time":
+		if op == "==" || op == "!=" {
+			return nil, p.errorf(ot, "%s can't be used for %s", op, field)
+		}
+		c, ok := timeCondition(field, op, value)
+		if !ok {
+			return nil, p.errorf(vt, "want a duration like 30d or a date like 2012-05-01")
+		}
+		return c, nil
+	}
+	if op != "==" && op != "!=" {
+		return nil, p.errorf(ot, "%s can't be used for %s", op, field)
+	}
+	return textCondition(field, op == "!=", func(s string) bool { return strings.EqualFold(s, value) }), nil
+}
+
+// textCondition return the condition that the text field, or any label,
+// match; negated, none match.
+func textCondition(field string, negate bool, match func(string) bool) condition {
+	return func(e Entry, now time.Time) bool {
+		found := false
+		if field == "label" {
+			for _, l := range e.IssuesLabel {
+				found = found || match(l)
+			}
+		} else {
+			found = match(fieldValue(e, field))
+		}
+		return found != negate
+	}
+}
+
+// timeCondition return the condition comparing the time field. With a
+// duration, the age is compared: "updated < 30d" is updated in 30 days.
+// With a date, the time is compared: "opened > 2012-05-01" is opened after
+// the day.
+func timeCondition(field, op, value string) (condition, bool) {
+	if d, err := parseAge(value); err == nil {
+		return func(e Entry, now time.Time) bool {
+			t, err := time.Parse(time.RFC3339, fieldValue(e, field))
+			return err == nil && compare(op, int64(now.Sub(t)-d))
+		}, true
+	}
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, false
+	}
+	return func(e Entry, now time.Time) bool {
+		t, err := time.Parse(time.RFC3339, fieldValue(e, field))
+		return err == nil && compare(op, int64(t.Sub(day)))
+	}, true
+}
+
+// compare return the result of op for the sign of the difference.
+func compare(op string, diff int64) bool {
+	switch op {
+	case "<":
+		return diff < 0
+	case "<=":
+		return diff <= 0
+	case ">":
+		return diff > 0
+	case ">=":
+		return diff >= 0
+	case "==":
+		return diff == 0
+	case "!=":
+		return diff != 0
+	}
+	return false
+}
+
+// parseCondition compile the -filter expression, like
+// `stars > 5 && label =~ "Priority-(High|Critical)" && updated < 30d`.
+func parseCondition(s string) (condition, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	c, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != "" {
+		return nil, p.errorf(t, "unexpected %q", t.text)
+	}
+	return c, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCondition(t *testing.T) {
+	now, _ := time.Parse(time.RFC3339, "2012-05-10T00:00:00Z")
+	entry := Entry{
+		Id:           "http://code.google.com/feeds/issues/p/go/issues/full/123",
+		Title:        "net/http: crash on redirect",
+		IssuesStars:  []int{7},
+		IssuesState:  []string{"open"},
+		IssuesStatus: []string{"Accepted"},
+		IssuesLabel:  []string{"Type-Defect", "Priority-High"},
+		Published:    "2012-03-01T00:00:00Z",
+		Updated:      "2012-05-01T00:00:00Z",
+	}
+	for _, tt := range []struct {
+		expr string
+		want bool
+	}{
+		{`stars > 5 && label =~ "Priority-(High|Critical)" && updated < 30d`, true},
+		{`stars > 7`, false},
+		{`stars >= 7 && id == 123`, true},
+		{`label == priority-high`, true},
+		{`label != Type-Defect`, false},
+		{`label !~ "^OS-"`, true},
+		{`status == Started || state == open`, true},
+		{`!(state == open)`, false},
+		{`updated < 5d`, false},
+		{`opened > 2012-02-01 && opened < 2012-04-01`, true},
+		{`title =~ "^net/http:" && owner == ""`, true},
+	} {
+		c, err := parseCondition(tt.expr)
+		if err != nil {
+			t.Errorf("parseCondition(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := c(entry, now); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+	for _, expr := range []string{
+		``,
+		`stars >`,
+		`stars > many`,
+		`title < x`,
+		`updated == 30d`,
+		`color == red`,
+		`(stars > 1`,
+		`stars > 1 stars`,
+		`title == "x`,
+		`title = x`,
+	} {
+		if _, err := parseCondition(expr); err == nil {
+			t.Errorf("parseCondition(%q) must fail", expr)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// filter hold conditions to narrow down issues given from command line.
+// They are translated into the search syntax of Google Code, so users
+// don't need to remember it.
+type filter struct {
+	query       string
+	label       string
+	owner       string
+	status      string
+	is          string
+	hasLabel    string
+	openedAfter string
+	starsMin    int
+	summary     string
+	description string
+	commentBy   string
+	where       string
+
+	cond condition
+}
+
+// register add flags of the filter to the flag set.
+func (f *filter) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.query, "q", "", "search word")
+	fs.StringVar(&f.label, "label", "", "filter by label")
+	fs.StringVar(&f.owner, "owner", "", "filter by owner")
+	fs.StringVar(&f.status, "status", "", "filter by status")
+	fs.StringVar(&f.is, "is", "", "filter by state like open, or starred")
+	fs.StringVar(&f.hasLabel, "has-label", "", "filter issues having a label with the prefix, or owner")
+	fs.StringVar(&f.openedAfter, "opened-after", "", "filter issues opened after the date (YYYY-MM-DD)")
+	fs.IntVar(&f.starsMin, "stars-min", 0, "filter issues starred at least N times")
+	fs.StringVar(&f.summary, "summary", "", "search word in summary")
+	fs.StringVar(&f.description, "description", "", "search word in description")
+	fs.StringVar(&f.commentBy, "comment-by", "", "filter issues commented by the user")
+	fs.StringVar(&f.where, "filter", "", "filter by the expression like 'stars > 5 && updated < 30d'")
+}
+
+// condition return -filter compiled, or nil if not given.
+func (f *filter) condition() condition {
+	if f.where != "" && f.cond == nil {
+		c, err := parseCondition(f.where)
+		if err != nil {
+			fatal("invalid -filter:", err)
+		}
+		f.cond = c
+	}
+	return f.cond
+}
+
+// narrow return entries satisfying -filter, which can't be searched on the
+// server.
+func (f *filter) narrow(entries []Entry) []Entry {
+	cond := f.condition()
+	if cond == nil {
+		return entries
+	}
+	now := time.Now()
+	var kept []Entry
+	for _, entry := range entries {
+		if cond(entry, now) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// quote return the word quoted if it contain spaces.
+func quote(s string) string {
+	if strings.ContainsAny(s, " \t") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+// values return query parameters of issues feed. can is canned query like
+// "open" or "all".
+func (f *filter) values(can string) url.Values {
+	var terms []string
+	if f.query != "" {
+		terms = append(terms, f.query)
+	}
+	if f.is != "" {
+		terms = append(terms, "is:"+f.is)
+		can = "all"
+	}
+	if f.hasLabel != "" {
+		terms = append(terms, "has:"+f.hasLabel)
+	}
+	if f.openedAfter != "" {
+		terms = append(terms, "opened-after:"+parseDate(f.openedAfter).Format("2006/01/02"))
+	}
+	if f.starsMin > 0 {
+		terms = append(terms, "stars:"+strconv.Itoa(f.starsMin))
+	}
+	if f.summary != "" {
+		terms = append(terms, "summary:"+quote(f.summary))
+	}
+	if f.description != "" {
+		terms = append(terms, "description:"+quote(f.description))
+	}
+	if f.commentBy != "" {
+		terms = append(terms, "commentby:"+f.commentBy)
+	}
+
+	v := url.Values{}
+	v.Set("can", can)
+	if len(terms) > 0 {
+		v.Set("q", strings.Join(terms, " "))
+	}
+	if f.label != "" {
+		v.Set("label", f.label)
+	}
+	if f.owner != "" {
+		v.Set("owner", f.owner)
+	}
+	if f.status != "" {
+		v.Set("status", f.status)
+	}
+	return v
+}
+
+// containsFold return true if s contain substr ignoring case.
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// match return true if the entry satisfy the filter. This is used to
+// narrow down issues in the local store. -comment-by can't be checked
+// since the store doesn't have comments.
+func (f *filter) match(entry Entry) bool {
+	if f.query != "" && !containsFold(entry.Title, f.query) && !containsFold(entry.Content, f.query) {
+		return false
+	}
+	if f.label != "" && !hasLabel(entry, f.label) {
+		return false
+	}
+	if f.owner != "" && !strings.EqualFold(ownerName(entry), f.owner) {
+		return false
+	}
+	if f.status != "" && !contains(entry.IssuesStatus, f.status) {
+		return false
+	}
+	if f.is == "open" || f.is == "closed" {
+		if entryState(entry) != f.is {
+			return false
+		}
+	}
+	if f.hasLabel != "" {
+		if strings.EqualFold(f.hasLabel, "owner") {
+			if ownerName(entry) == "" {
+				return false
+			}
+		} else if !hasLabelPrefix(entry, f.hasLabel+"-") {
+			return false
+		}
+	}
+	if f.openedAfter != "" {
+		t, err := time.Parse(time.RFC3339, entry.Published)
+		if err != nil || t.Before(parseDate(f.openedAfter)) {
+			return false
+		}
+	}
+	if f.starsMin > 0 && (len(entry.IssuesStars) == 0 || entry.IssuesStars[0] < f.starsMin) {
+		return false
+	}
+	if f.summary != "" && !containsFold(entry.Title, f.summary) {
+		return false
+	}
+	if f.description != "" && !containsFold(entry.Content, f.description) {
+		return false
+	}
+	if cond := f.condition(); cond != nil && !cond(entry, time.Now()) {
+		return false
+	}
+	return true
+}
+
+// hasLabel return true if the entry has the label.
+func hasLabel(entry Entry, label string) bool {
+	return contains(entry.IssuesLabel, label)
+}
+
+// hasLabelPrefix return true if the entry has a label starting with the
+// prefix.
+func hasLabelPrefix(entry Entry, prefix string) bool {
+	for _, l := range entry.IssuesLabel {
+		if len(l) >= len(prefix) && strings.EqualFold(l[:len(prefix)], prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// listFlags is flags of list.
+type listFlags struct {
+	sla     bool
+	groupBy string
+	filter  filter
+}
+
+// register add flags of list to the flag set.
+func (o *listFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.sla, "sla", false, "mark issues whose status is not changed for longer than \"sla\" in settings")
+	fs.StringVar(&o.groupBy, "group-by", "", "print issues in groups of label, status, owner, or label:PREFIX like label:Priority")
+	o.filter.register(fs)
+}
+
+// listIssues print issues matched to the filter. With -project all, issues
+// of all projects are listed with the project name. With -group-by, they
+// are printed in groups. Exit with exitNotFound if nothing matched.
+func listIssues(ctx context.Context, config *Config, c *Client, args []string) {
+	var o listFlags
+	fs := newFlagSet("list")
+	o.register(fs)
+	fs.Parse(args)
+	if o.groupBy != "" && !validGroupBy(o.groupBy) {
+		fatal("invalid -group-by: " + o.groupBy + " (valid: label, status, owner, label:PREFIX)")
+	}
+
+	var rules map[string]time.Duration
+	if o.sla {
+		rules = slaRules(config)
+	}
+	clients := projectClients(config, c, false)
+	found, breached := 0, 0
+	now := time.Now()
+	var grouped []listedIssue
+	for _, pc := range clients {
+		entries, err := pc.Entries(ctx, o.filter.values("open"))
+		if err != nil {
+			fatal("failed to get issues:", err)
+		}
+		entries = dropSpam(pc, o.filter.narrow(entries))
+		for _, entry := range entries {
+			line := projectPrefix(pc, len(clients)) + issueId(entry) + ": " + entry.Title
+			if _, _, ok := slaRule(rules, entry); ok {
+				// status changes are only in comments.
+				comments, err := pc.Comments(ctx, issueId(entry))
+				if err != nil {
+					fatal("failed to get comments:", err)
+				}
+				if why, ok := overSLA(rules, entry, comments, now); ok {
+					line += "  ! " + why
+					breached++
+				}
+			}
+			if o.groupBy != "" {
+				grouped = append(grouped, listedIssue{pc, entry, line})
+				continue
+			}
+			printIssue(pc, entry, line)
+		}
+		found += len(entries)
+	}
+	if o.groupBy != "" {
+		printGroups(c, grouped, o.groupBy)
+	}
+	if o.sla {
+		notef("%d of %d issues over SLA\n", breached, found)
+	}
+	if found == 0 {
+		exit(exitNotFound)
+	}
+}
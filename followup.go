@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// linkWords is wording of issues made by split and followup. The first is
+// written in the new issue, and the second is commented on the original.
+var linkWords = map[string][2]string{
+	"split":    {"Split from issue %s.", "Split into issue %s."},
+	"followup": {"Follow-up to issue %s.", "Follow-up in issue %s."},
+}
+
+// copyLabels return labels of the entry which start with one of prefixes.
+// "all" copy all labels.
+func copyLabels(entry Entry, prefixes string) []string {
+	var labels []string
+	for _, label := range entry.IssuesLabel {
+		if prefixes == "all" {
+			labels = append(labels, label)
+			continue
+		}
+		for _, p := range splitList(prefixes) {
+			if strings.HasPrefix(label, p) {
+				labels = append(labels, label)
+				break
+			}
+		}
+	}
+	return labels
+}
+
+// linkFlags is flags of the linking commands.
+type linkFlags struct {
+	prefixes string
+	status   string
+	preview  bool
+}
+
+// register add flags of the linking commands to the flag set.
+func (o *linkFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.prefixes, "copy", "all", "comma separated prefixes of labels to copy, or all")
+	fs.StringVar(&o.status, "status", "", "status of the issue, instead of the default in \"new_issue\" of settings")
+	fs.BoolVar(&o.preview, "preview", false, "show the issue as it will look and confirm before posting")
+}
+
+// linkIssue create issue linked to the original one, and comment on both
+// issues to link each other.
+func linkIssue(kind string) func(ctx context.Context, config *Config, c *Client, args []string) {
+	return func(ctx context.Context, config *Config, c *Client, args []string) {
+		var o linkFlags
+		fs := newFlagSet(kind)
+		o.register(fs)
+		rest := parseFlags(fs, args)
+		if len(rest) != 1 {
+			fmt.Fprint(os.Stderr, "Usage: goissue "+kind+" [-copy PREFIXES] [-status STATUS] [-preview] ID\n")
+			fs.PrintDefaults()
+			exit(exitUsage)
+		}
+		if o.status == "" {
+			o.status = getIssueDefaults(config).Status
+		}
+		id := rest[0]
+		orig, err := c.Entry(ctx, id)
+		if err != nil {
+			fatal("failed to get issue:", err)
+		}
+
+		words := linkWords[kind]
+		template := "\n" + loadTemplate(config, c.Project, "")
+		template = fillHeader(template, "title", orig.Title)
+		template = fillHeader(template, "status", o.status)
+		template = fillHeader(template, "labels", strings.Join(copyLabels(orig, o.prefixes), ","))
+		if i := strings.Index(template, "\n---"); i >= 0 {
+			i += strings.Index(template[i+1:], "\n") + 2
+			template = template[:i] + fmt.Sprintf(words[0], id) + "\n\n" + template[i:]
+		}
+		entry := editIssue(ctx, config, c, template[1:], o.preview, false)
+
+		newId := issueId(entry)
+		if err := c.PostComment(ctx, id, config.Email, "", fmt.Sprintf(words[1], newId), nil); err != nil {
+			fatal("failed to post comment:", err)
+		}
+		if err := c.PostComment(ctx, newId, config.Email, "", fmt.Sprintf(words[0], id), nil); err != nil {
+			fatal("failed to post comment:", err)
+		}
+		infof("issue %s and %s are linked", id, newId)
+	}
+}
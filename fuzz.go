@@ -0,0 +1,45 @@
+//go:build gofuzz
+// +build gofuzz
+
+package main
+
+import (
+	"encoding/xml"
+)
+
+// Fuzzing entry points for go-fuzz. Content of issues and comments is
+// written by anyone, so parsing it must return errors, not crash.
+//
+//	go-fuzz-build -func FuzzFeed && go-fuzz -bin goissue-fuzz.zip -workdir fuzz/feed
+//	go-fuzz-build -func FuzzRender && go-fuzz -bin goissue-fuzz.zip -workdir fuzz/render
+//
+// testdata/*.xml are good seeds for the corpus.
+
+// FuzzFeed unmarshal data as a feed and an entry, and render them.
+func FuzzFeed(data []byte) int {
+	var feed Feed
+	if err := xml.Unmarshal(data, &feed); err == nil {
+		for _, entry := range feed.Entry {
+			issueId(entry)
+			render(entry.Content, Options{})
+		}
+		return 1
+	}
+	var entry Entry
+	if err := xml.Unmarshal(data, &entry); err != nil {
+		return 0
+	}
+	issueId(entry)
+	if _, err := render(entry.Content, Options{}); err != nil {
+		return 0
+	}
+	return 1
+}
+
+// FuzzRender render data as html content of an issue.
+func FuzzRender(data []byte) int {
+	if _, err := render(string(data), Options{}); err != nil {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// subsequence return true if all characters of s appear in t in order.
+func subsequence(s, t string) bool {
+	i := 0
+	for _, r := range t {
+		if i < len(s) && rune(s[i]) == r {
+			i++
+		}
+	}
+	return i == len(s)
+}
+
+// fuzzyScore return how well the title match the words. Every word must
+// match some word of the title as prefix, substring or subsequence; 0 is
+// returned if any word doesn't match.
+func fuzzyScore(words []string, title string) int {
+	fields := strings.Fields(strings.ToLower(title))
+	score := 0
+	for _, w := range words {
+		best := 0
+		for _, f := range fields {
+			switch {
+			case f == w:
+				best = 4
+			case strings.HasPrefix(f, w) && best < 3:
+				best = 3
+			case strings.Contains(f, w) && best < 2:
+				best = 2
+			case subsequence(w, f) && best < 1:
+				best = 1
+			}
+		}
+		if best == 0 {
+			return 0
+		}
+		score += best
+	}
+	return score
+}
+
+// scored is an entry with the score of fuzzy matching.
+type scored struct {
+	entry Entry
+	score int
+}
+
+// byScore sort entries by score, and recently updated first in same score.
+type byScore []scored
+
+func (s byScore) Len() int      { return len(s) }
+func (s byScore) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byScore) Less(i, j int) bool {
+	if s[i].score != s[j].score {
+		return s[i].score > s[j].score
+	}
+	return s[i].entry.Updated > s[j].entry.Updated
+}
+
+// fuzzyMatch return entries in the store whose title match the words, best
+// first.
+func fuzzyMatch(s *store, words string) []scored {
+	w := strings.Fields(strings.ToLower(words))
+	if len(w) == 0 {
+		return nil
+	}
+	var found []scored
+	for _, entry := range s.Entries {
+		if score := fuzzyScore(w, entry.Title); score > 0 {
+			found = append(found, scored{entry, score})
+		}
+	}
+	sort.Sort(byScore(found))
+	return found
+}
+
+// showFuzzy show an issue looked up by title. Titles are taken from the
+// store, so sync should be done before. If some issues are equally
+// matched, the user pick one of them on terminal, or they are listed.
+func showFuzzy(ctx context.Context, c *Client, words string, view commentView) {
+	found := fuzzyMatch(loadStore(c.Project), words)
+	if len(found) == 0 {
+		fatal("no issue matched:", words)
+	}
+	entry := found[0].entry
+	if len(found) > 1 && found[1].score == found[0].score {
+		var candidates []Entry
+		for _, s := range found {
+			if len(candidates) == 10 {
+				break
+			}
+			candidates = append(candidates, s.entry)
+		}
+		if !interactive(c) {
+			for _, e := range candidates {
+				printIssue(c, e, issueId(e)+": "+e.Title)
+			}
+			if len(found) > len(candidates) {
+				notef("... and %d more\n", len(found)-len(candidates))
+			}
+			return
+		}
+		var ok bool
+		if entry, ok = pick(c, candidates); !ok {
+			return
+		}
+	}
+	id := issueId(entry)
+	showIssue(ctx, c, id)
+	showComments(ctx, c, id, view)
+}
+
+// showFlags is flags of show.
+type showFlags struct {
+	fuzzy string
+	view  commentView
+}
+
+// register add flags of show to the flag set.
+func (o *showFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.fuzzy, "fuzzy", "", "look up the issue by words in title")
+	o.view.register(fs)
+}
+
+// showIssuesCommand show issues given by number, or looked up by title
+// with -fuzzy.
+func showIssuesCommand(ctx context.Context, config *Config, c *Client, args []string) {
+	var o showFlags
+	fs := newFlagSet("show")
+	o.register(fs)
+	rest := parseFlags(fs, args)
+
+	if o.fuzzy != "" {
+		showFuzzy(ctx, c, o.fuzzy, o.view)
+		return
+	}
+	if len(rest) == 0 {
+		fmt.Fprint(os.Stderr, "Usage: goissue show [-c] [-threaded] [-comments N] [-no-comments] [-fuzzy WORDS] [ID...]\n")
+		fs.PrintDefaults()
+		exit(exitUsage)
+	}
+	for _, id := range rest {
+		showIssue(ctx, c, id)
+		showComments(ctx, c, id, o.view)
+	}
+}
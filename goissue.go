@@ -2,29 +2,26 @@ package main
 
 import (
 	"bytes"
-	"encoding/json"
-	"encoding/xml"
+	"context"
 	"errors"
+	"exp/html"
 	"flag"
 	"fmt"
-	"exp/html"
 	"io"
 	"io/ioutil"
-	"log"
-	"math/rand"
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
-	"strings"
 	"runtime"
+	"runtime/pprof"
+	"strings"
+	"syscall"
 )
 
 const version = "0.01"
 
-var project = "go"
-
 var xmlSpecial = map[byte]string{
 	'<':  "&lt;",
 	'>':  "&gt;",
@@ -45,91 +42,127 @@ type Author struct {
 	Email string `xml:"email"`
 }
 type IssuesCc struct {
-	IssuesUri      string `xml:"issues:uri"`
-	IssuesUsername string `xml:"issues:username"`
+	IssuesUri      string `xml:"http://schemas.google.com/projecthosting/issues/2009 uri"`
+	IssuesUsername string `xml:"http://schemas.google.com/projecthosting/issues/2009 username"`
 }
 type IssuesOwner struct {
-	IssuesUri      string `xml:"issues:uri"`
-	IssuesUsername string `xml:"issues:username"`
+	IssuesUri      string `xml:"http://schemas.google.com/projecthosting/issues/2009 uri"`
+	IssuesUsername string `xml:"http://schemas.google.com/projecthosting/issues/2009 username"`
+}
+type IssuesRef struct {
+	IssuesId      string `xml:"http://schemas.google.com/projecthosting/issues/2009 id"`
+	IssuesProject string `xml:"http://schemas.google.com/projecthosting/issues/2009 project"`
+}
+type IssuesUpdates struct {
+	IssuesSummary          string   `xml:"http://schemas.google.com/projecthosting/issues/2009 summary"`
+	IssuesStatus           string   `xml:"http://schemas.google.com/projecthosting/issues/2009 status"`
+	IssuesOwnerUpdate      string   `xml:"http://schemas.google.com/projecthosting/issues/2009 ownerUpdate"`
+	IssuesLabel            []string `xml:"http://schemas.google.com/projecthosting/issues/2009 label"`
+	IssuesCcUpdate         []string `xml:"http://schemas.google.com/projecthosting/issues/2009 ccUpdate"`
+	IssuesMergedIntoUpdate string   `xml:"http://schemas.google.com/projecthosting/issues/2009 mergedIntoUpdate"`
+	IssuesBlockedOnUpdate  []string `xml:"http://schemas.google.com/projecthosting/issues/2009 blockedOnUpdate"`
 }
 type Entry struct {
-	XMLNs         string        `xml:"attr"`
-	Id            string        `xml:"id"`
-	Published     string        `xml:"published"`
-	Updated       string        `xml:"updated"`
-	Title         string        `xml:"title"`
-	Content       string        `xml:"content"`
-	Link          []Link        `xml:"link"`
-	Author        []Author      `xml:"author"`
-	IssuesCc      []IssuesCc    `xml:"issues:cc"`
-	IssuesLabel   []string      `xml:"issues:label"`
-	IssuesOwner   []IssuesOwner `xml:"issues:owner"`
-	IssuesStars   []int         `xml:"issues:stars"`
-	IssuesState   []string      `xml:"issues:state"`
-	IssuesStatus  []string      `xml:"issues:status"`
-	IssuesSummary string        `xml:"issues:summary"`
+	XMLNs            string         `xml:"xmlns,attr"`
+	Etag             string         `xml:"http://schemas.google.com/g/2005 etag,attr"`
+	Id               string         `xml:"http://www.w3.org/2005/Atom id"`
+	Published        string         `xml:"http://www.w3.org/2005/Atom published"`
+	Updated          string         `xml:"http://www.w3.org/2005/Atom updated"`
+	Title            string         `xml:"http://www.w3.org/2005/Atom title"`
+	Content          string         `xml:"http://www.w3.org/2005/Atom content"`
+	Link             []Link         `xml:"http://www.w3.org/2005/Atom link"`
+	Author           []Author       `xml:"http://www.w3.org/2005/Atom author"`
+	IssuesCc         []IssuesCc     `xml:"http://schemas.google.com/projecthosting/issues/2009 cc"`
+	IssuesLabel      []string       `xml:"http://schemas.google.com/projecthosting/issues/2009 label"`
+	IssuesOwner      []IssuesOwner  `xml:"http://schemas.google.com/projecthosting/issues/2009 owner"`
+	IssuesStars      []int          `xml:"http://schemas.google.com/projecthosting/issues/2009 stars"`
+	IssuesState      []string       `xml:"http://schemas.google.com/projecthosting/issues/2009 state"`
+	IssuesStatus     []string       `xml:"http://schemas.google.com/projecthosting/issues/2009 status"`
+	IssuesSummary    string         `xml:"http://schemas.google.com/projecthosting/issues/2009 summary"`
+	IssuesBlockedOn  []IssuesRef    `xml:"http://schemas.google.com/projecthosting/issues/2009 blockedOn"`
+	IssuesMergedInto []IssuesRef    `xml:"http://schemas.google.com/projecthosting/issues/2009 mergedInto"`
+	IssuesBlocking   []IssuesRef    `xml:"http://schemas.google.com/projecthosting/issues/2009 blocking"`
+	IssuesClosedDate string         `xml:"http://schemas.google.com/projecthosting/issues/2009 closedDate"`
+	IssuesUpdates    *IssuesUpdates `xml:"http://schemas.google.com/projecthosting/issues/2009 updates"`
 }
 
 type Feed struct {
-	Entry []Entry `xml:"entry"`
+	TotalResults int     `xml:"http://a9.com/-/spec/opensearch/1.1/ totalResults"`
+	StartIndex   int     `xml:"http://a9.com/-/spec/opensearch/1.1/ startIndex"`
+	ItemsPerPage int     `xml:"http://a9.com/-/spec/opensearch/1.1/ itemsPerPage"`
+	Entry        []Entry `xml:"entry"`
 }
 
-// authLogin return auth code from AuthSub server.
+// defaultLoginURL is the URL to log in with email and password. It can be
+// changed with "login_url" in config.
+const defaultLoginURL = "https://www.google.com/accounts/ClientLogin"
+
+// login authenticate with email and password in config, and return auth
+// code from AuthSub server.
 // see: http://code.google.com/apis/accounts/docs/AuthForWebApps.html
-func authLogin(config map[string]string) (auth string) {
-	res, err := http.PostForm(
-		"https://www.google.com/accounts/ClientLogin",
-		url.Values(map[string][]string{
-			"accountType": []string{"GOOGLE"},
-			"Email":       []string{config["email"]},
-			"Passwd":      []string{config["password"]},
-			"service":     []string{"code"},
-			"source":      []string{"golang-goissue-" + version},
-		}))
+func login(ctx context.Context, config *Config) (string, error) {
+	passwd, err := password(config)
 	if err != nil {
-		log.Fatal("failed to authenticate:", err)
+		return "", err
+	}
+	form := url.Values(map[string][]string{
+		"accountType": []string{"GOOGLE"},
+		"Email":       []string{config.Email},
+		"Passwd":      []string{passwd},
+		"service":     []string{"code"},
+		"source":      []string{"golang-goissue-" + version},
+	})
+	loginURL := defaultLoginURL
+	if u := config.LoginURL; u != "" {
+		loginURL = u
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", loginURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
 	}
 	defer res.Body.Close()
 	b, _ := ioutil.ReadAll(res.Body)
 	if res.StatusCode != 200 {
-		log.Fatal("failed to authenticate:", res.Status)
+		return "", errors.New(res.Status)
 	}
 	lines := strings.Split(string(b), "\n")
-	return lines[2]
+	if len(lines) < 3 {
+		return "", errors.New("unexpected response")
+	}
+	return lines[2], nil
 }
 
-// getConfig return string map of configuration that store email and password.
-func getConfig() (config map[string]string) {
-	file := ""
+// configDir return directory path that store settings.json and other files.
+func configDir() string {
 	if runtime.GOOS == "windows" {
-		file = filepath.Join(os.Getenv("USERPROFILE"), "Application Data", "goissue", "settings.json")
-	} else {
-		file = filepath.Join(os.Getenv("HOME"), ".config", "goissue", "settings.json")
-	}
-
-	b, err := ioutil.ReadFile(file)
-	if err != nil {
-		log.Fatal("failed to read file "+file+":", err)
-	}
-	err = json.Unmarshal(b, &config)
-	if err != nil {
-		log.Fatal("failed to unmarhal settings.json:", err)
+		return filepath.Join(os.Getenv("USERPROFILE"), "Application Data", "goissue")
 	}
+	return filepath.Join(os.Getenv("HOME"), ".config", "goissue")
+}
 
-	if _, ok := config["email"]; !ok {
-		log.Fatal("failed to get email from your settings.json:", err)
-	}
-	if _, ok := config["password"]; !ok {
-		log.Fatal("failed to get email from your settings.json:", err)
+// newClient return client for the project in config. "base_url" and
+// "web_url" in config change where the tracker is; web_url is base_url if
+// not given. "max_response_size" limit the size of responses.
+func newClient(config *Config, auth string) *Client {
+	c := NewClient(config.Project, auth)
+	if u := config.BaseURL; u != "" {
+		c.BaseURL = strings.TrimRight(u, "/")
+		c.WebURL = c.BaseURL
 	}
-	if _, ok := config["project"]; ok {
-		project = config["project"]
+	if u := config.WebURL; u != "" {
+		c.WebURL = strings.TrimRight(u, "/")
 	}
-	return config
+	c.MaxBody = config.MaxResponseSize
+	return c
 }
 
-func dumpLevel(w io.Writer, n *html.Node, level int) error {
-	for i := 0; i < level; i++ {
+func dumpLevel(w io.Writer, n *html.Node, level int, plain bool) error {
+	for i := 0; i < level && !plain; i++ {
 		io.WriteString(w, "  ")
 	}
 	switch n.Type {
@@ -138,170 +171,269 @@ func dumpLevel(w io.Writer, n *html.Node, level int) error {
 	case html.DocumentNode:
 		return errors.New("unexpected DocumentNode")
 	case html.ElementNode:
+		if hiddenElement(n) {
+			return nil
+		}
+		if n.Data == "pre" {
+			// preformatted text like stack traces are printed as is,
+			// without indentation.
+			io.WriteString(w, "\n"+preText(n)+"\n")
+			return nil
+		}
 	case html.TextNode:
-		fmt.Fprintf(w, n.Data)
-	case html.CommentNode:
-		return errors.New("COMMENT")
+		io.WriteString(w, stripControls(n.Data))
+	case html.CommentNode, html.DoctypeNode:
+		// user-written html may contain them; they are not shown.
+		return nil
 	default:
 		return errors.New("unknown node type")
 	}
 	for _, c := range n.Child {
-		if err := dumpLevel(w, c, level+1); err != nil {
+		if err := dumpLevel(w, c, level+1, plain); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func dump(n *html.Node) (string, error) {
+// hiddenElement return true if the element is not shown: scripts and
+// styles in user-written html. Attributes like event handlers are never
+// shown either.
+func hiddenElement(n *html.Node) bool {
+	return n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style")
+}
+
+// preText return text in the node as is, except control characters.
+func preText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return stripControls(n.Data)
+	}
+	if hiddenElement(n) {
+		return ""
+	}
+	s := ""
+	for _, c := range n.Child {
+		s += preText(c)
+	}
+	return s
+}
+
+func dump(n *html.Node, plain bool) (string, error) {
 	if n == nil || len(n.Child) == 0 {
 		return "", nil
 	}
 	b := bytes.NewBuffer(nil)
 	for _, child := range n.Child {
-		if err := dumpLevel(b, child, 0); err != nil {
+		if err := dumpLevel(b, child, 0, plain); err != nil {
 			return "", err
 		}
 	}
 	return b.String(), nil
 }
 
-// showIssue print issue detail.
-func showIssue(auth string, id string) {
-	req, err := http.NewRequest("GET", "https://code.google.com/feeds/issues/p/"+project+"/issues/full/"+id, nil)
-	if err != nil {
-		log.Fatal("failed to get issue:", err)
+// render return text of html content of issue or comment, as -plain and
+// -raw-bytes in o. Bump rendererVersion when changing the output.
+func render(content string, o Options) (string, error) {
+	defer prof.start("render")()
+	if !o.RawBytes {
+		content = fixEncoding(content)
 	}
-	req.Header.Set("Authorization", "GoogleLogin "+auth)
-	res, err := http.DefaultClient.Do(req)
+	doc, err := html.Parse(strings.NewReader(toLF(content)))
 	if err != nil {
-		log.Fatal("failed to get issue:", err)
+		return "", err
 	}
-	defer res.Body.Close()
-	if res.StatusCode != 200 {
-		log.Fatal("failed to authenticate:", res.Status)
-	}
-	var entry Entry
-	err = xml.NewDecoder(res.Body).Decode(&entry)
-	if err != nil {
-		log.Fatal("failed to get issue:", err)
+	return dump(doc, o.Plain)
+}
+
+// renderEntry return text of html content of the issue or comment, cached
+// in the cache of c until the entry is updated. Nothing is cached when
+// responses are not, as with -no-cache.
+func renderEntry(c *Client, entry Entry) (string, error) {
+	cache := c.Cache
+	if cache != nil && cache.TTL <= 0 {
+		cache = nil
 	}
-	doc, err := html.Parse(strings.NewReader(entry.Content))
-	if err != nil {
-		log.Fatal("failed to parse xml:", err)
+	if text, ok := cache.rendered(entry, c.Options); ok {
+		return text, nil
 	}
-	text, err := dump(doc)
+	text, err := render(entry.Content, c.Options)
 	if err != nil {
-		log.Fatal("failed to parse xml:", err)
+		return "", err
 	}
-	fmt.Println(entry.Title, "\n", text)
+	cache.putRendered(entry, c.Options, text)
+	return text, nil
 }
 
-// searchIssues search word in issue list.
-func searchIssues(auth, word string) {
-	req, err := http.NewRequest("GET", "https://code.google.com/feeds/issues/p/"+project+"/issues/full?q="+url.QueryEscape(word), nil)
-	if err != nil {
-		log.Fatal("failed to get issues:", err)
-	}
-	req.Header.Set("Authorization", "GoogleLogin "+auth)
-	res, err := http.DefaultClient.Do(req)
+// issueId return issue number from id of the entry.
+func issueId(entry Entry) string {
+	return entry.Id[strings.LastIndex(entry.Id, "/")+1:]
+}
+
+// showIssue print issue detail, and mark it read for inbox.
+func showIssue(ctx context.Context, c *Client, id string) {
+	entry, err := c.Entry(ctx, id)
 	if err != nil {
-		log.Fatal("failed to get issues:", err)
+		fatal("failed to get issue:", err)
 	}
-	defer res.Body.Close()
-	if res.StatusCode != 200 {
-		log.Fatal("failed to get issues:", res.Status)
+	markSeen(c.Project, entry)
+	if err := writeIssue(os.Stdout, c, entry); err != nil {
+		fatal("failed to parse xml:", err)
 	}
-	var feed Feed
-	err = xml.NewDecoder(res.Body).Decode(&feed)
+}
+
+// writeIssue write the title and the text of the issue.
+func writeIssue(w io.Writer, c *Client, entry Entry) error {
+	text, err := renderEntry(c, entry)
 	if err != nil {
-		log.Fatal("failed to parse xml:", err)
-	}
-	for _, entry := range feed.Entry {
-		fmt.Println(entry.Id + ": " + entry.Title)
+		return err
 	}
+	_, err = fmt.Fprintln(w, entry.Title, "\n", text)
+	return err
 }
 
-// showIssues print issue list.
-func showIssues(auth string) {
-	req, err := http.NewRequest("GET", "https://code.google.com/feeds/issues/p/"+project+"/issues/full", nil)
+// searchIssues search issues matched to the expression in the scope. On
+// terminal, the user pick one of them to show. Exit with exitNotFound if
+// nothing matched.
+func searchIssues(ctx context.Context, c *Client, expr, scope string, page url.Values) {
+	q, err := buildQuery(expr, scope)
 	if err != nil {
-		log.Fatal("failed to get issues:", err)
+		fatal("failed to parse search words:", err)
 	}
-	req.Header.Set("Authorization", "GoogleLogin "+auth)
-	res, err := http.DefaultClient.Do(req)
+	page.Set("q", q)
+	if !interactive(c) {
+		if showIssues(ctx, c, page) == 0 {
+			exit(exitNotFound)
+		}
+		return
+	}
+	feed, err := c.Feed(ctx, c.IssuesURL()+"?"+page.Encode())
 	if err != nil {
-		log.Fatal("failed to get issues:", err)
+		fatal("failed to get issues:", err)
+	}
+	feed.Entry = dropSpam(c, feed.Entry)
+	if len(feed.Entry) == 0 {
+		exit(exitNotFound)
+	}
+	entry := feed.Entry[0]
+	if len(feed.Entry) > 1 {
+		var ok bool
+		printPaging(c, feed)
+		if entry, ok = pick(c, feed.Entry); !ok {
+			return
+		}
 	}
-	defer res.Body.Close()
-	if res.StatusCode != 200 {
-		log.Fatal("failed to get issues:", res.Status)
+	showIssue(ctx, c, issueId(entry))
+}
+
+// showIssues print issue list, and return the number of issues printed.
+// query may have start-index and max-results to show another page.
+func showIssues(ctx context.Context, c *Client, query url.Values) int {
+	uri := c.IssuesURL()
+	if len(query) > 0 {
+		uri += "?" + query.Encode()
 	}
-	var feed Feed
-	err = xml.NewDecoder(res.Body).Decode(&feed)
+	feed, err := c.Feed(ctx, uri)
 	if err != nil {
-		log.Fatal("failed to parse xml:", err)
+		fatal("failed to get issues:", err)
 	}
+	feed.Entry = dropSpam(c, feed.Entry)
 	for _, entry := range feed.Entry {
-		fmt.Println(entry.Id + ": " + entry.Title)
+		printIssue(c, entry, entry.Id+": "+entry.Title)
 	}
+	printPaging(c, feed)
+	return len(feed.Entry)
 }
 
-// showComments print comment list.
-func showComments(auth string, id string) {
-	req, err := http.NewRequest("GET", "https://code.google.com/feeds/issues/p/"+project+"/issues/"+id+"/comments/full", nil)
-	if err != nil {
-		log.Fatal("failed to get comments:", err)
+// printPaging print which part of the results is shown, to stderr, unless
+// in porcelain mode of c.
+func printPaging(c *Client, feed Feed) {
+	if feed.TotalResults == 0 {
+		return
 	}
-	req.Header.Set("Authorization", "GoogleLogin "+auth)
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Fatal("failed to get comments:", err)
+	start := feed.StartIndex
+	if start == 0 {
+		start = 1
 	}
-	defer res.Body.Close()
-	if res.StatusCode != 200 {
-		log.Fatal("failed to authenticate:", res.Status)
+	end := start + len(feed.Entry) - 1
+	if c.Options.Porcelain {
+		return
 	}
-	var feed Feed
-	err = xml.NewDecoder(res.Body).Decode(&feed)
-	if err != nil {
-		log.Fatal("failed to get comments:", err)
+	fmt.Fprintf(os.Stderr, "showing %d-%d of %d", start, end, feed.TotalResults)
+	if end < feed.TotalResults {
+		fmt.Fprintf(os.Stderr, " (next: -start %d)", end+1)
 	}
-	for _, entry := range feed.Entry {
-		doc, err := html.Parse(strings.NewReader(entry.Content))
-		if err != nil {
-			log.Fatal("failed to parse xml:", err)
-		}
-		text, err := dump(doc)
-		if err != nil {
-			log.Fatal("failed to parse xml:", err)
-		}
-		fmt.Println(entry.Title, "\n", text)
+	fmt.Fprintln(os.Stderr)
+}
+
+// commentView is how comments are shown with issues, given by -c,
+// -threaded, -comments N and -no-comments.
+type commentView struct {
+	all      bool
+	threaded bool
+	last     int
+	none     bool
+}
+
+// register add flags of the view to the flag set.
+func (v *commentView) register(fs *flag.FlagSet) {
+	fs.BoolVar(&v.all, "c", false, "show comments")
+	fs.BoolVar(&v.threaded, "threaded", false, "show comments as trees of replies")
+	fs.IntVar(&v.last, "comments", 0, "show only the latest N comments")
+	fs.BoolVar(&v.none, "no-comments", false, "don't show comments even with -c, -threaded or -comments")
+}
+
+// shown return true if comments are shown.
+func (v commentView) shown() bool {
+	return !v.none && (v.all || v.threaded || v.last > 0)
+}
+
+// commentsFeed return comments of the issue. With last > 0, only the latest
+// last comments are fetched, from the index computed with the total told
+// by a page of one comment; otherwise all pages are fetched.
+func commentsFeed(ctx context.Context, c *Client, id string, last int) (Feed, error) {
+	if last <= 0 {
+		entries, err := c.Comments(ctx, id)
+		return Feed{TotalResults: len(entries), Entry: entries}, err
+	}
+	feed, err := c.Feed(ctx, c.CommentsURL(id)+"?max-results=1")
+	if err != nil || feed.TotalResults <= 1 {
+		return feed, err
+	}
+	start := feed.TotalResults - last + 1
+	if start < 1 {
+		start = 1
 	}
+	return c.Feed(ctx, fmt.Sprintf("%s?start-index=%d&max-results=%d", c.CommentsURL(id), start, last))
 }
 
-func run(argv []string) error {
-	cmd, err := exec.LookPath(argv[0])
+// showComments print comment list. With -threaded, replies are shown under
+// the comments they reply to.
+func showComments(ctx context.Context, c *Client, id string, v commentView) {
+	if !v.shown() {
+		return
+	}
+	feed, err := commentsFeed(ctx, c, id, v.last)
 	if err != nil {
-		return err
+		fatal("failed to get comments:", err)
 	}
-	var stdin *os.File
-	if runtime.GOOS == "windows" {
-		stdin, _ = os.Open("CONIN$")
-	} else {
-		stdin = os.Stdin
+	if v.last > 0 && feed.TotalResults > len(feed.Entry) {
+		notef("showing the latest %d of %d comments\n", len(feed.Entry), feed.TotalResults)
 	}
-	p, err := os.StartProcess(cmd, argv, &os.ProcAttr{Files: []*os.File{stdin, os.Stdout, os.Stderr}})
-	if err != nil {
-		return err
+	write := writeComments
+	if v.threaded {
+		write = writeThreaded
 	}
-	defer p.Release()
-	w, err := p.Wait()
-	if err != nil {
-		return err
+	if err := write(os.Stdout, c, feed); err != nil {
+		fatal("failed to parse xml:", err)
 	}
-	if !w.Exited() || !w.Success() {
-		return errors.New("failed to execute text editor")
+}
+
+// writeComments write the title and the text of comments in the feed.
+func writeComments(w io.Writer, c *Client, feed Feed) error {
+	for _, entry := range feed.Entry {
+		if err := writeIssue(w, c, entry); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -319,156 +451,211 @@ func xmlEscape(s string) string {
 	return b.String()
 }
 
-func createIssue(auth string) {
-	file := ""
-	newf := fmt.Sprintf("%d.txt", rand.Int())
-	if runtime.GOOS == "windows" {
-		file = filepath.Join(os.Getenv("USERPROFILE"), "Application Data", "goissue", newf)
-	} else {
-		file = filepath.Join(os.Getenv("HOME"), ".config", "goissue", newf)
-	}
-	defer os.Remove(file)
-	editor := os.Getenv("EDITOR")
-	if len(editor) == 0 {
-		if runtime.GOOS == "windows" {
-			editor = "notepad"
-		} else {
-			editor = "vim"
+// printDefaults print flags in fs like fs.PrintDefaults except hidden ones.
+func printDefaults(fs *flag.FlagSet, hidden ...string) {
+	fs.VisitAll(func(f *flag.Flag) {
+		for _, name := range hidden {
+			if f.Name == name {
+				return
+			}
 		}
-	}
-	contents := `from: 
-title: 
---------------
-Before filing a bug, please check whether it has been fixed since
-the latest release: run "hg pull -u" and retry what you did to
-reproduce the problem.  Thanks.
-
-What steps will reproduce the problem?
-1.
-2.
-3.
-
-What is the expected output?
-
-
-What do you see instead?
-
-
-Which compiler are you using (5g, 6g, 8g, gccgo)?
-
-
-Which operating system are you using?
-
-
-Which revision are you using?  (hg identify)
-
+		fmt.Fprintf(os.Stderr, "  -%s=%s: %s\n", f.Name, f.DefValue, tr(f.Usage))
+	})
+}
 
-Please provide any additional information below.
-`
-	if runtime.GOOS == "windows" {
-		contents = strings.Replace(contents, "\n", "\r\n", -1)
+// parseFlags parse args with fs and return positional arguments. Unlike
+// fs.Parse, flags may follow positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) []string {
+	var rest []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		rest = append(rest, args[0])
+		args = args[1:]
 	}
-	ioutil.WriteFile(file, []byte(contents), 0600)
+	return rest
+}
 
-	if err := run([]string{editor, file}); err != nil {
-		log.Fatal("failed to create issue:", err)
+func main() {
+	// messages are in the language of LANG until settings are read.
+	setMessageLang(languages(nil))
+	search := flag.String("s", "", "search issues")
+	scope := flag.String("in", "all", "scope of -s: summary, description, comments or all")
+	create := flag.Bool("C", false, "create issue")
+	var view commentView
+	view.register(flag.CommandLine)
+	rpc := flag.Bool("rpc", false, "serve JSON-RPC on stdin and stdout for editor plugins")
+	metrics := flag.String("metrics", "", "serve metrics at the address like localhost:6060 while -rpc runs")
+	start := flag.Int("start", 0, "index of the first issue to list, starting at 1")
+	max := flag.Int("n", 0, "number of issues to list")
+	noCache := flag.Bool("no-cache", false, "don't use cached responses")
+	logLevel := flag.String("log-level", "", "log level: error, warn, info or debug")
+	logFile := flag.String("log-file", "", "append log messages to the file")
+	anonymous := flag.Bool("anonymous", false, "don't log in; only reading public issues is possible")
+	profile := flag.Bool("profile", false, "print timings of requests and phases")
+	cpuprofile := flag.String("cpuprofile", "", "")
+	memprofile := flag.String("memprofile", "", "")
+	var opts Options
+	flag.BoolVar(&opts.Porcelain, "porcelain", false, "print issues in stable tab separated format, without decorative messages")
+	flag.BoolVar(&opts.IdsOnly, "ids", false, "print only numbers of issues in lists")
+	flag.BoolVar(&opts.Print0, "0", false, "end lines of lists with NUL, for xargs -0")
+	flag.BoolVar(&opts.Print0, "print0", false, "same as -0")
+	flag.BoolVar(&opts.ShowSpam, "spam", false, "show issues hidden by the spam filter")
+	flag.BoolVar(&opts.RawBytes, "raw-bytes", false, "render contents of issues without converting encodings")
+	flag.BoolVar(&opts.Plain, "plain", false, "print linear text without indentation and progress, for screen readers")
+	project := flag.String("project", "", "project to use, or all for \"projects\" in settings (list, sync and -s only)")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, tr("Usage: ")+"goissue [-project NAME|all] [-c ID | -s WORDS [-in SCOPE]] [-start N] [-n N]\n")
+		printUsages(os.Stderr)
+		printDefaults(flag.CommandLine, "cpuprofile", "memprofile")
 	}
+	flag.Parse()
+	initMessageLang(nil, opts)
 
-	b, err := ioutil.ReadFile(file)
-	if err != nil {
-		log.Fatal("failed to create issue:", err)
+	args := flag.Args()
+	var cmd func(context.Context, *Config, *Client, []string)
+	if len(args) > 0 {
+		cmd = commands[args[0]]
 	}
-	text := string(b)
-	if runtime.GOOS == "windows" {
-		text = strings.Replace(text, "\r\n", "\n", -1)
+	if cmd == nil && len(args) > 1 && args[0] != "help" && args[0] != "completion" {
+		flag.Usage()
+		exit(exitUsage)
 	}
-	lines := strings.Split(text, "\n")
-	if len(lines) < 4 {
-		log.Fatal("failed to create issue")
+
+	// cancel requests on interrupt so that long running commands can
+	// stop cleanly.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// cleanups are run by fatal and exit too, which skip deferred calls.
+	defer runCleanups()
+
+	if len(args) > 0 && args[0] == "doctor" {
+		// doctor must work even if settings are broken.
+		exit(runDoctor(ctx))
 	}
-	from := lines[0]
-	if len(from) < 7 || from[:6] != "from: " {
-		log.Fatal("failed to create issue")
+	if len(args) > 0 && args[0] == "help" {
+		showHelp(args[1:])
+		return
 	}
-	from = from[6:]
-	title := lines[1]
-	if len(title) < 8 || title[:7] != "title: " {
-		log.Fatal("failed to create issue")
+	if len(args) > 0 && args[0] == "completion" {
+		showCompletion(args[1:])
+		return
 	}
-	title = title[7:]
-	body := strings.Join(lines[3:], "\n")
 
-	/*
-		entry := Entry{XMLNs: "http://www.w3.org/2005/Atom", Title: title, Content: body, Author: []Author{Author{Name: from}}, IssuesSummary: title}
-		buf := bytes.NewBuffer(nil)
-		err = xml.Marshal(buf, entry)
+	config := getConfig(*project)
+	initMessageLang(config, opts)
+	if config.Plain || os.Getenv("TERM") == "dumb" {
+		opts.Plain = true
+	}
+	if len(args) == 0 && !*create && *search == "" && config.DefaultCommand != "" {
+		// bare goissue run "default_command" like "inbox", or
+		// "list -owner me".
+		var err error
+		args, err = splitArgs(config.DefaultCommand)
+		if err == nil && (len(args) == 0 || commands[args[0]] == nil) {
+			err = errors.New("unknown command")
+		}
 		if err != nil {
-			log.Fatal("failed to post issue:", err)
+			fatal("invalid default_command in your settings.json:", err)
+		}
+		cmd = commands[args[0]]
+	}
+	if config.Project == "all" && (len(args) == 0 || !multiProject[args[0]]) && (cmd != nil || *search == "") {
+		fatal("-project all is supported only by list, sync and -s")
+	}
+	if *logLevel == "" {
+		*logLevel = config.LogLevel
+		if *logLevel == "" {
+			*logLevel = "info"
+			if opts.Porcelain {
+				// without decorative messages.
+				*logLevel = "warn"
+			}
 		}
-		str := "<?xml version='1.0' encoding='UTF-8'?>\n" + buf.String()
-		str = strings.Replace(str, "<???", "<entry", 1)
-		str = strings.Replace(str, "</???>", "</entry>", -1)
-	*/
-	str := fmt.Sprintf("<?xml version='1.0' encoding='UTF-8'?>\n"+
-		"<entry xmlns='http://www.w3.org/2005/Atom' xmlns:issues='http://schemas.google.com/projecthosting/issues/2009'>\n"+
-		"<title>%s</title>\n"+
-		"<content type='html'>%s</content>\n"+
-		"<author><name>%s</name></author>\n"+
-		"<issues:updates>\n"+
-		"<issues:summary>%s</issues:summary>\n"+
-		"<issues:status>Started</issues:status>\n"+
-		"<issues:label>-Type-Defect</issues:label>\n"+
-		"<issues:label>-Priority-Medium</issues:label>\n"+
-		"</issues:updates>\n"+
-		"</entry>",
-		xmlEscape(title),
-		xmlEscape(body),
-		xmlEscape(from),
-		xmlEscape(title))
-	req, err := http.NewRequest("POST", "https://code.google.com/feeds/issues/p/"+project+"/issues/full", strings.NewReader(str))
-	if err != nil {
-		log.Fatal("failed to post issue:", err)
 	}
-	req.Header.Set("Authorization", "GoogleLogin "+auth)
-	req.Header.Set("Content-Type", "application/atom+xml")
-	req.ContentLength = int64(len([]byte(str)))
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Fatal("failed to get issue:", err)
+	if *logFile == "" {
+		*logFile = config.LogFile
 	}
-	defer res.Body.Close()
-	fmt.Println(res.Status)
-}
+	setupLog(*logLevel, *logFile)
 
-func main() {
-	search := flag.String("s", "", "search issues")
-	create := flag.Bool("C", false, "create issue")
-	comment := flag.Bool("c", false, "show comments")
-	flag.Usage = func() {
-		fmt.Fprint(os.Stderr, "Usage: goissue [-c ID | -s WORD]\n")
-		flag.PrintDefaults()
+	if *cpuprofile != "" {
+		f, err := os.Create(*cpuprofile)
+		if err != nil {
+			fatal("failed to create cpu profile:", err)
+		}
+		pprof.StartCPUProfile(f)
+		atExit(pprof.StopCPUProfile)
+	}
+	if *memprofile != "" {
+		atExit(func() {
+			f, err := os.Create(*memprofile)
+			if err != nil {
+				warnf("failed to create memory profile: %v", err)
+				return
+			}
+			pprof.WriteHeapProfile(f)
+			f.Close()
+		})
+	}
+	if *profile {
+		prof = &profiler{}
+		atExit(func() { prof.report(os.Stderr) })
+	}
+
+	c := newClient(config, "")
+	c.Options = opts
+	if f := loginFunc(config); f != nil && !*anonymous {
+		// log in only when a request is sent.
+		c.Login = func(ctx context.Context) (string, error) {
+			defer prof.start("auth")()
+			return f(ctx)
+		}
+		c.Expired = func() { forgetToken(config) }
 	}
-	flag.Parse()
-	if flag.NArg() > 1 {
-		flag.Usage()
-		os.Exit(1)
+	if prof != nil {
+		c.Trace = prof.add
+	}
+	c.Cache = &Cache{
+		Dir:     filepath.Join(configDir(), "cache"),
+		TTL:     config.CacheTTL,
+		MaxSize: config.CacheMaxSize,
+	}
+	if *noCache {
+		c.Cache.TTL = 0
 	}
 
-	config := getConfig()
-	auth := authLogin(config)
+	page := url.Values{}
+	if *start > 0 {
+		page.Set("start-index", fmt.Sprint(*start))
+	}
+	if *max > 0 {
+		page.Set("max-results", fmt.Sprint(*max))
+	}
 
-	if *create {
-		createIssue(auth)
+	if *rpc {
+		if *metrics != "" {
+			serveMetrics(c, *metrics)
+		}
+		if err := serveRPC(ctx, config, c, os.Stdin, os.Stdout); err != nil {
+			fatal("failed to serve rpc:", err)
+		}
+	} else if cmd != nil {
+		cmd(ctx, config, c, args[1:])
+	} else if *create {
+		createIssue(ctx, config, c)
+	} else if len(*search) > 0 && c.Project == "all" {
+		searchProjects(ctx, config, c, *search, *scope, page)
 	} else if len(*search) > 0 {
-		searchIssues(auth, *search)
-	} else if flag.NArg() == 0 {
-		showIssues(auth)
+		searchIssues(ctx, c, *search, *scope, page)
+	} else if len(args) == 0 {
+		showIssues(ctx, c, page)
 	} else {
-		for i := 0; i < flag.NArg(); i++ {
-			showIssue(auth, flag.Arg(i))
-			if *comment {
-				showComments(auth, flag.Arg(i))
-			}
+		for _, id := range args {
+			showIssue(ctx, c, id)
+			showComments(ctx, c, id, view)
 		}
 	}
 }
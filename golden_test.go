@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// checkGolden compare got with testdata/name, or write it with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	file := "testdata/" + name
+	if *update {
+		if err := ioutil.WriteFile(file, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs (run go test -update to accept):\n--- got\n%s\n--- want\n%s", name, got, want)
+	}
+}
+
+func TestGoldenShow(t *testing.T) {
+	var entry Entry
+	readFixture(t, "render.xml", &entry)
+	var b bytes.Buffer
+	if err := writeIssue(&b, &Client{}, entry); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "show.golden", b.Bytes())
+}
+
+func TestGoldenShowPlain(t *testing.T) {
+	var entry Entry
+	readFixture(t, "render.xml", &entry)
+	var b bytes.Buffer
+	if err := writeIssue(&b, &Client{Options: Options{Plain: true}}, entry); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "show-plain.golden", b.Bytes())
+}
+
+func TestGoldenHostile(t *testing.T) {
+	var entry Entry
+	readFixture(t, "hostile.xml", &entry)
+	var b bytes.Buffer
+	if err := writeIssue(&b, &Client{}, entry); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "hostile.golden", b.Bytes())
+	if links := contentLinks(entry.Content); len(links) != 0 {
+		t.Errorf("contentLinks = %q, want none", links)
+	}
+}
+
+func TestGoldenComments(t *testing.T) {
+	var feed Feed
+	readFixture(t, "comments.xml", &feed)
+	var b bytes.Buffer
+	if err := writeComments(&b, &Client{}, feed); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "comments.golden", b.Bytes())
+}
+
+func TestGoldenThreaded(t *testing.T) {
+	var feed Feed
+	readFixture(t, "thread.xml", &feed)
+	var b bytes.Buffer
+	if err := writeThreaded(&b, &Client{}, feed); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "thread.golden", b.Bytes())
+
+	b.Reset()
+	if err := writeThreaded(&b, &Client{Options: Options{Plain: true}}, feed); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "thread-plain.golden", b.Bytes())
+}
+
+func TestGoldenList(t *testing.T) {
+	var feed Feed
+	readFixture(t, "feed.xml", &feed)
+	for _, mode := range []struct {
+		name           string
+		porcelain, ids bool
+	}{
+		{"list.golden", false, false},
+		{"list-porcelain.golden", true, false},
+		{"list-ids.golden", false, true},
+	} {
+		c := &Client{Project: "go", Options: Options{Porcelain: mode.porcelain, IdsOnly: mode.ids}}
+		var b bytes.Buffer
+		for _, entry := range feed.Entry {
+			b.WriteString(formatIssue(c, entry, issueId(entry)+": "+entry.Title) + "\n")
+		}
+		checkGolden(t, mode.name, b.Bytes())
+	}
+}
+
+func TestGoldenExport(t *testing.T) {
+	var entry Entry
+	readFixture(t, "render.xml", &entry)
+	var feed Feed
+	readFixture(t, "comments.xml", &feed)
+	th := thread{entry, feed.Entry, webLink(entry)}
+
+	var b bytes.Buffer
+	if err := th.writeText(&b, &Client{}); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "export.md.golden", b.Bytes())
+
+	j, err := json.MarshalIndent(th, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "export.json.golden", append(j, '\n'))
+}
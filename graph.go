@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// graphFlags is flags of graph.
+type graphFlags struct {
+	format string
+	filter filter
+}
+
+// register add flags of graph to the flag set.
+func (o *graphFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.format, "format", "dot", "output format: dot or text")
+	o.filter.register(fs)
+}
+
+// showGraph print blocked-on and duplicate relationships of issues.
+func showGraph(ctx context.Context, config *Config, c *Client, args []string) {
+	var o graphFlags
+	fs := newFlagSet("graph")
+	o.register(fs)
+	fs.Parse(args)
+	if o.format != "dot" && o.format != "text" {
+		fatal("-format must be dot or text")
+	}
+
+	entries, err := c.Entries(ctx, o.filter.values("all"))
+	if err != nil {
+		fatal("failed to get issues:", err)
+	}
+	entries = o.filter.narrow(entries)
+	if o.format == "dot" {
+		fmt.Println("digraph issues {")
+		for _, entry := range entries {
+			attr := ""
+			if entryState(entry) == "closed" {
+				attr = ", style=dashed"
+			}
+			fmt.Printf("\t%q [label=%q%s];\n", issueId(entry), issueId(entry)+": "+entry.Title, attr)
+		}
+	}
+	for _, entry := range entries {
+		id := issueId(entry)
+		for _, ref := range entry.IssuesBlockedOn {
+			printEdge(o.format, id, refName(c.Project, ref), "blocked on")
+		}
+		for _, ref := range entry.IssuesMergedInto {
+			printEdge(o.format, id, refName(c.Project, ref), "duplicate of")
+		}
+	}
+	if o.format == "dot" {
+		fmt.Println("}")
+	}
+}
+
+// refName return node name of the referenced issue. Issues of another
+// project are prefixed with the project name.
+func refName(project string, ref IssuesRef) string {
+	if ref.IssuesProject != "" && ref.IssuesProject != project {
+		return ref.IssuesProject + ":" + strings.TrimSpace(ref.IssuesId)
+	}
+	return strings.TrimSpace(ref.IssuesId)
+}
+
+func printEdge(format, from, to, kind string) {
+	if format == "dot" {
+		style := ""
+		if kind == "duplicate of" {
+			style = ", style=dotted"
+		}
+		fmt.Printf("\t%q -> %q [label=%q%s];\n", from, to, kind, style)
+	} else {
+		fmt.Println(from + " " + kind + " " + to)
+	}
+}
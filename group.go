@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// noGroup is the group of issues without the field grouped by.
+const noGroup = "(none)"
+
+// validGroupBy return true if by is a field -group-by accepts: label,
+// status, owner, or label:PREFIX for values of labels like Priority-High.
+func validGroupBy(by string) bool {
+	switch by {
+	case "label", "status", "owner":
+		return true
+	}
+	return strings.HasPrefix(by, "label:") && len(by) > len("label:")
+}
+
+// groupKeys return groups the entry belong to. An issue is in the group of
+// each of its labels.
+func groupKeys(entry Entry, by string) []string {
+	var keys []string
+	switch {
+	case by == "status":
+		if len(entry.IssuesStatus) > 0 {
+			keys = entry.IssuesStatus[:1]
+		}
+	case by == "owner":
+		if ownerName(entry) != "" {
+			keys = []string{ownerName(entry)}
+		}
+	case by == "label":
+		keys = entry.IssuesLabel
+	case strings.HasPrefix(by, "label:"):
+		prefix := by[len("label:"):] + "-"
+		for _, l := range entry.IssuesLabel {
+			if len(l) > len(prefix) && strings.EqualFold(l[:len(prefix)], prefix) {
+				keys = append(keys, l[len(prefix):])
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return []string{noGroup}
+	}
+	return keys
+}
+
+// listedIssue is an issue to print by list.
+type listedIssue struct {
+	c     *Client
+	entry Entry
+	line  string
+}
+
+// printGroups print issues in groups of the field, with headers counting
+// them. Larger groups come first, and issues without the field last.
+// Headers are left out with -porcelain and -ids of c.
+func printGroups(c *Client, issues []listedIssue, by string) {
+	groups := map[string][]listedIssue{}
+	var names []string
+	for _, issue := range issues {
+		for _, key := range groupKeys(issue.entry, by) {
+			if _, ok := groups[key]; !ok {
+				names = append(names, key)
+			}
+			groups[key] = append(groups[key], issue)
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		if (names[i] == noGroup) != (names[j] == noGroup) {
+			return names[j] == noGroup
+		}
+		if len(groups[names[i]]) != len(groups[names[j]]) {
+			return len(groups[names[i]]) > len(groups[names[j]])
+		}
+		return names[i] < names[j]
+	})
+	headers := !c.Options.Porcelain && !c.Options.IdsOnly
+	for i, name := range names {
+		if headers {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s (%d)\n", name, len(groups[name]))
+		}
+		for _, issue := range groups[name] {
+			line := issue.line
+			if headers {
+				line = indent(c) + line
+			}
+			printIssue(issue.c, issue.entry, line)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupKeys(t *testing.T) {
+	entry := Entry{
+		IssuesStatus: []string{"Accepted"},
+		IssuesLabel:  []string{"Type-Defect", "Priority-High", "OS-Windows"},
+	}
+	for _, tt := range []struct {
+		by   string
+		want []string
+	}{
+		{"status", []string{"Accepted"}},
+		{"owner", []string{noGroup}},
+		{"label", []string{"Type-Defect", "Priority-High", "OS-Windows"}},
+		{"label:priority", []string{"High"}},
+		{"label:Milestone", []string{noGroup}},
+	} {
+		if got := groupKeys(entry, tt.by); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("groupKeys(%s) = %q, want %q", tt.by, got, tt.want)
+		}
+	}
+	for by, want := range map[string]bool{"label": true, "label:OS": true, "label:": false, "stars": false} {
+		if got := validGroupBy(by); got != want {
+			t.Errorf("validGroupBy(%q) = %v, want %v", by, got, want)
+		}
+	}
+}
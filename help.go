@@ -0,0 +1,513 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// command is definition of a sub command. The usage of goissue and help
+// of each command are made from it. flags define flags of the command; the
+// command itself define its flags by the same function, so that help and
+// documents don't drift from the code.
+type command struct {
+	name     string
+	run      func(ctx context.Context, config *Config, c *Client, args []string)
+	flags    func(fs *flag.FlagSet) // nil if the command has no flags
+	usage    []string               // arguments after the name, one line per form
+	summary  string
+	examples []string
+	settings []string // keys of settings.json used by the command
+}
+
+// commandTable is all sub commands in the order shown in the usage. run
+// of doctor, completion and help is nil since main run them before
+// reading settings.
+var commandTable = []command{
+	{
+		name:     "list",
+		run:      listIssues,
+		flags:    new(listFlags).register,
+		usage:    []string{"[-sla] [-group-by FIELD] [filters]"},
+		summary:  "list issues matched to filters.",
+		examples: []string{"goissue list -is open -has-label Priority -stars-min 5", "goissue -project all list -label Go1.1", "goissue list -sla -owner me", "goissue list -group-by label:Priority"},
+		settings: []string{"projects", "sla"},
+	},
+	{
+		name:     "dashboard",
+		run:      showDashboard,
+		flags:    new(dashboardFlags).register,
+		usage:    []string{"[-top N]"},
+		summary:  "show sections of \"dashboard\" in settings with counts and top issues.",
+		examples: []string{"goissue dashboard", "goissue dashboard -top 10"},
+		settings: []string{"dashboard"},
+	},
+	{
+		name:     "show",
+		run:      showIssuesCommand,
+		flags:    new(showFlags).register,
+		usage:    []string{"[-c] [-threaded] [-comments N] [-no-comments] [-fuzzy WORDS] [ID...]"},
+		summary:  "show issues, or the issue looked up by words in title from the local store.",
+		examples: []string{"goissue show -c 123", "goissue show -threaded 123", "goissue show -comments 5 123", "goissue show -fuzzy \"sche dead\""},
+	},
+	{
+		name:     "diff",
+		run:      diffIssue,
+		flags:    new(diffFlags).register,
+		usage:    []string{"[-U N] [-keep] [-side-by-side] ID", "[-U N] [-side-by-side] ID1 ID2"},
+		summary:  "show what changed in the issue since the copy in the local store, or differences of two issues.",
+		examples: []string{"goissue diff 123", "goissue diff -side-by-side 123 456"},
+	},
+	{
+		name:     "create",
+		run:      fileIssue,
+		flags:    new(createFlags).register,
+		usage:    []string{"[-type TYPE] [-status STATUS] [-labels L1,L2] [-owner USER] [-title TITLE] [-from-cmd CMD] [-preview] [-offline] [-prompt] [-set NAME=VALUE]..."},
+		summary:  "create issue written in the editor from the template.",
+		examples: []string{"goissue create -type enhancement", "goissue create -from-cmd \"go build ./...\"", "goissue create -offline", "goissue create -prompt", "goissue create -title \"crash in net/http\" -set version=go1.0.1 -set os=linux"},
+		settings: []string{"new_issue", "statuses", "labels", "members", "routes", "lint", "secret_patterns", "spell_command", "tmpdir", "lang"},
+	},
+	{
+		name:     "split",
+		run:      linkIssue("split"),
+		flags:    new(linkFlags).register,
+		usage:    []string{"[-copy PREFIXES] [-status STATUS] [-preview] ID"},
+		summary:  "create issue split from the issue, linking each other.",
+		examples: []string{"goissue split 123"},
+		settings: []string{"new_issue"},
+	},
+	{
+		name:     "followup",
+		run:      linkIssue("followup"),
+		flags:    new(linkFlags).register,
+		usage:    []string{"[-copy PREFIXES] [-status STATUS] [-preview] ID"},
+		summary:  "create issue following up the issue, linking each other.",
+		examples: []string{"goissue followup -copy OS-,Priority- 123"},
+		settings: []string{"new_issue"},
+	},
+	{
+		name:     "relabel",
+		run:      relabelIssues,
+		flags:    new(relabelFlags).register,
+		usage:    []string{"-from LABEL -to LABEL [-wait 2s] [-dry-run] [filters]"},
+		summary:  "rename label of matched issues.",
+		examples: []string{"goissue relabel -from Priority-Triage -to Priority-Soon -dry-run"},
+	},
+	{
+		name:     "broadcast",
+		run:      broadcastComment,
+		flags:    new(broadcastFlags).register,
+		usage:    []string{"-m MESSAGE [-wait 2s] [-dry-run] [-y] [filters]"},
+		summary:  "post the same comment to matched issues, after confirming the list.",
+		examples: []string{"goissue broadcast -label Go1.1 -status Started -m \"This is fixed at tip, please retest.\""},
+		settings: []string{"secret_patterns"},
+	},
+	{
+		name:     "escalate",
+		run:      escalateIssue,
+		flags:    new(escalateFlags).register,
+		usage:    []string{"-m REASON [-to LABEL] [-dry-run] ID"},
+		summary:  "raise the priority of the issue, cc the owners of its areas, and tell why.",
+		examples: []string{"goissue escalate -m \"Breaks the build on all arm builders.\" 123", "goissue escalate -to Priority-Critical -m \"Data loss.\" 123"},
+		settings: []string{"priorities", "area_owners", "escalate_comment"},
+	},
+	{
+		name:     "triage",
+		run:      triageIssue,
+		flags:    new(triageFlags).register,
+		usage:    []string{"[-auto] [-dry-run] ID"},
+		summary:  "add labels, the owner and cc of routes matched to the issue.",
+		examples: []string{"goissue triage 123", "goissue triage -auto 123"},
+		settings: []string{"routes", "labels"},
+	},
+	{
+		name:     "activity",
+		run:      showActivity,
+		flags:    new(activityFlags).register,
+		usage:    []string{"[-since 1d] [-user NAME]"},
+		summary:  "show recent updates and comments of the project in time order.",
+		examples: []string{"goissue activity -since 12h", "goissue activity -user rsc -since 30d"},
+	},
+	{
+		name:     "inbox",
+		run:      showInbox,
+		flags:    new(inboxFlags).register,
+		usage:    []string{"[-all]"},
+		summary:  "list open issues starred, owned or cc'd by you updated since shown last.",
+		examples: []string{"goissue inbox"},
+		settings: []string{"email"},
+	},
+	{
+		name:     "mute",
+		run:      muteIssues,
+		flags:    new(muteFlags).register,
+		usage:    []string{"[-undo] [ID...]"},
+		summary:  "mute issues locally in watch, inbox and activity.",
+		examples: []string{"goissue mute 123", "goissue mute -undo 123"},
+	},
+	{
+		name:     "state",
+		run:      syncState,
+		flags:    new(stateFlags).register,
+		usage:    []string{"[-file FILE] [-dry-run]"},
+		summary:  "merge read marks, muted and taken issues with the state file shared between machines.",
+		examples: []string{"goissue state -file ~/Dropbox/goissue-state.json"},
+		settings: []string{"state_file"},
+	},
+	{
+		name:     "spam",
+		run:      markSpam,
+		flags:    new(spamFlags).register,
+		usage:    []string{"[-undo] [-author USER] [-title REGEXP] [ID...]"},
+		summary:  "hide issues by the authors or with the titles from list, search and watch.",
+		examples: []string{"goissue spam 123", "goissue spam -title \"(?i)cheap watches\"", "goissue spam -undo -author spammer"},
+	},
+	{
+		name:     "export",
+		run:      exportIssue,
+		flags:    new(exportFlags).register,
+		usage:    []string{"[-format md|json|eml] [-o FILE] ID", "-mbox FILE [filters]"},
+		summary:  "export issue with all comments and links into one file.",
+		examples: []string{"goissue export -format md -o issue-123.md 123", "goissue export -mbox go.mbox -label Go1.1"},
+	},
+	{
+		name:     "mirror",
+		run:      mirrorIssues,
+		flags:    new(mirrorFlags).register,
+		usage:    []string{"-git DIR [-full]"},
+		summary:  "write issues with comments into a git repository as markdown files, and commit changes.",
+		examples: []string{"goissue mirror -git ~/go-issues"},
+	},
+	{
+		name:     "batch",
+		run:      runBatch,
+		flags:    new(batchFlags).register,
+		usage:    []string{"[-continue-on-error] [-dry-run] FILE"},
+		summary:  "run updates written in the file, one per line.",
+		examples: []string{"goissue batch -dry-run triage.txt"},
+	},
+	{
+		name:     "doctor",
+		summary:  "check settings, login, the project, the editor and the cache.",
+		examples: []string{"goissue doctor"},
+		settings: []string{"email", "password", "project", "base_url", "login_url", "max_response_size"},
+	},
+	{
+		name:     "credentials",
+		run:      manageCredentials,
+		usage:    []string{"encrypt|decrypt"},
+		summary:  "encrypt the password in settings with a passphrase, or print it decrypted.",
+		examples: []string{"goissue credentials encrypt", "GOISSUE_PASSPHRASE=... goissue list -owner me"},
+		settings: []string{"email", "password", "save_token"},
+	},
+	{
+		name:     "selfupdate",
+		run:      selfUpdate,
+		flags:    new(selfupdateFlags).register,
+		usage:    []string{"[-check] [-force]"},
+		summary:  "update goissue to the latest release.",
+		examples: []string{"goissue selfupdate -check"},
+		settings: []string{"update_url"},
+	},
+	{
+		name:     "version",
+		run:      showVersion,
+		flags:    new(versionFlags).register,
+		usage:    []string{"[-check]"},
+		summary:  "show the version, the commit and the API version.",
+		examples: []string{"goissue version -check"},
+	},
+	{
+		name:     "take",
+		run:      takeIssue,
+		flags:    new(takeFlags).register,
+		usage:    []string{"[-status STATUS] [-m MESSAGE] ID", "-release [-status STATUS] [-m MESSAGE] ID"},
+		summary:  "assign the issue to you with status Started and cc, and list it in wip.",
+		examples: []string{"goissue take 123", "goissue take -release -m \"Busy with Go1.1; anyone?\" 123"},
+		settings: []string{"email"},
+	},
+	{
+		name:     "wip",
+		run:      showWip,
+		summary:  "list issues you took, oldest first.",
+		examples: []string{"goissue wip"},
+	},
+	{
+		name:     "spend",
+		run:      spendTime,
+		flags:    new(spendFlags).register,
+		usage:    []string{"[-date DATE] ID DURATION [NOTE...]"},
+		summary:  "record time spent on the issue.",
+		examples: []string{"goissue spend 123 2h bisecting", "goissue spend -date 2012-05-01 123 30m review"},
+	},
+	{
+		name:     "timesheet",
+		run:      showTimesheet,
+		flags:    new(timesheetFlags).register,
+		usage:    []string{"[-since 1w] [-until DATE] [-format text|csv]"},
+		summary:  "show time spent per issue, recorded by spend.",
+		examples: []string{"goissue timesheet -since 1w", "goissue timesheet -since 2012-05-01 -until 2012-06-01 -format csv"},
+	},
+	{
+		name:     "stale",
+		run:      staleIssues,
+		flags:    new(staleFlags).register,
+		usage:    []string{"[-days N] [-ping] [filters]"},
+		summary:  "list open issues not updated for days, and ask for an update.",
+		examples: []string{"goissue stale -days 90 -label Priority-Low -ping"},
+	},
+	{
+		name:     "sync",
+		run:      syncIssues,
+		flags:    new(syncFlags).register,
+		usage:    []string{"[-full] [-comments] [-all-projects]"},
+		summary:  "copy issues into the local store, and post the outbox.",
+		examples: []string{"goissue sync", "goissue sync -comments -all-projects"},
+		settings: []string{"projects"},
+	},
+	{
+		name:     "trend",
+		run:      showTrend,
+		flags:    new(trendFlags).register,
+		usage:    []string{"[-step DAYS] [-format text|csv] [filters]"},
+		summary:  "show open/closed counts over time, from the local store.",
+		examples: []string{"goissue trend -label Go1.1 -format csv"},
+	},
+	{
+		name:     "milestone",
+		run:      showMilestone,
+		flags:    new(milestoneFlags).register,
+		usage:    []string{"[-move-to LABEL] [filters] LABEL [ID...]"},
+		summary:  "show status and blockers of a milestone, and move open issues to next one.",
+		examples: []string{"goissue milestone Go1.1", "goissue milestone Go1.1 -move-to Go1.2 123 124"},
+		settings: []string{"blocker_label"},
+	},
+	{
+		name:     "stats",
+		run:      showStats,
+		flags:    new(statsFlags).register,
+		usage:    []string{"[-by owner|author] [-since DATE] [-until DATE] [filters]"},
+		summary:  "show filed/closed issues per owner or author, from the local store.",
+		examples: []string{"goissue stats -by author -since 2012-01-01 -until 2012-04-01"},
+	},
+	{
+		name:     "graph",
+		run:      showGraph,
+		flags:    new(graphFlags).register,
+		usage:    []string{"[-format dot|text] [filters]"},
+		summary:  "draw blocked-on and duplicate relationships.",
+		examples: []string{"goissue graph -label Go1.1 -format dot | dot -Tpng > go1.1.png"},
+	},
+	{
+		name:     "changelog",
+		run:      showChangelog,
+		flags:    new(changelogFlags).register,
+		usage:    []string{"[-since DATE] [-until DATE] [-format md|text] [filters]"},
+		summary:  "make release notes from issues closed in the period.",
+		examples: []string{"goissue changelog -since 2012-03-28 -label Go1.1"},
+	},
+	{
+		name:     "cache",
+		run:      manageCache,
+		flags:    new(cacheFlags).register,
+		usage:    []string{"stats|clear|prune [-older-than 30d]"},
+		summary:  "show, clear or prune the response cache.",
+		examples: []string{"goissue cache stats", "goissue cache prune -older-than 30d"},
+		settings: []string{"cache_ttl", "cache_max_size"},
+	},
+	{
+		name:     "watch",
+		run:      watchIssues,
+		flags:    new(watchFlags).register,
+		usage:    []string{"[-interval 5m] [-metrics ADDR] [-exec COMMAND] [filters]"},
+		summary:  "watch issues updated and serve counters at /debug/vars.",
+		examples: []string{"goissue watch -interval 1m -metrics localhost:6060", "goissue watch -exec ./notify.sh -label Priority-Critical"},
+		settings: []string{"watch_command"},
+	},
+	{
+		name:     "acme",
+		run:      acmeIssues,
+		flags:    new(acmeFlags).register,
+		usage:    []string{"[-close-status STATUS] [filters]"},
+		summary:  "open issues in acme windows, with Get, Comment and Close in the tag.",
+		examples: []string{"goissue acme -owner me"},
+	},
+	{
+		name:     "members",
+		run:      showMembers,
+		flags:    new(membersFlags).register,
+		usage:    []string{"[-prefix PREFIX]"},
+		summary:  "list project members.",
+		examples: []string{"goissue members -prefix br"},
+		settings: []string{"members"},
+	},
+	{
+		name:     "completion",
+		usage:    []string{"bash|zsh"},
+		summary:  "write the shell completion script of commands and user names from members.",
+		examples: []string{"eval \"$(goissue completion bash)\"", "goissue completion zsh > ~/.zsh/functions/_goissue"},
+	},
+	{
+		name:     "comment",
+		run:      commentIssue,
+		flags:    new(commentFlags).register,
+		usage:    []string{"[-m MESSAGE] [-split] ID"},
+		summary:  "comment on issue, written in the editor without -m.",
+		examples: []string{"goissue comment 123", "goissue comment -m \"Fixed at tip, please retest.\" 123"},
+		settings: []string{"max_comment_size", "secret_patterns", "spell_command", "tmpdir"},
+	},
+	{
+		name:     "drafts",
+		run:      showDrafts,
+		flags:    new(draftsFlags).register,
+		usage:    []string{"[-discard ID]"},
+		summary:  "list or discard drafts of comments.",
+		examples: []string{"goissue drafts", "goissue drafts -discard 123"},
+		settings: []string{"tmpdir"},
+	},
+	{
+		name:     "outbox",
+		run:      runOutbox,
+		usage:    []string{"[list|push|drop NAME]"},
+		summary:  "list, retry or drop issues and comments failed to post.",
+		examples: []string{"goissue outbox push"},
+	},
+	{
+		name:     "help",
+		flags:    new(helpFlags).register,
+		usage:    []string{"[COMMAND]", "-man | -cheatsheet"},
+		summary:  "show help of the command, or write the man page or the cheatsheet of all commands.",
+		examples: []string{"goissue help create", "goissue help -man > goissue.1"},
+	},
+}
+
+// commands is the sub commands by name. Each command parse rest of
+// arguments by itself.
+var commands = map[string]func(ctx context.Context, config *Config, c *Client, args []string){}
+
+// commandDefs is definitions in commandTable by name. Commands look up
+// their help through it, since commandTable refer to the commands.
+var commandDefs = map[string]*command{}
+
+func init() {
+	for i, d := range commandTable {
+		commandDefs[d.name] = &commandTable[i]
+		if d.run != nil {
+			commands[d.name] = d.run
+		}
+	}
+}
+
+// lookupCommand return definition of the command, or nil.
+func lookupCommand(name string) *command {
+	return commandDefs[name]
+}
+
+// printUsages print usage lines of all commands.
+func printUsages(w io.Writer) {
+	for _, d := range commandTable {
+		for _, u := range d.usage {
+			fmt.Fprint(w, "       goissue "+strings.TrimSpace(d.name+" "+u)+"\n")
+		}
+		if len(d.usage) == 0 {
+			fmt.Fprint(w, "       goissue "+d.name+"\n")
+		}
+	}
+}
+
+// newFlagSet return flag set of the command, which print help of the
+// command for -h.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		printHelp(os.Stderr, name, fs)
+	}
+	return fs
+}
+
+// printHelp print usage, flags of fs, examples and settings of the command.
+func printHelp(w io.Writer, name string, fs *flag.FlagSet) {
+	d := lookupCommand(name)
+	if d == nil {
+		fmt.Fprintf(w, tr("Usage: ")+"goissue %s\n", name)
+		if fs != nil {
+			fs.SetOutput(w)
+			fs.PrintDefaults()
+		}
+		return
+	}
+	for i, u := range d.usage {
+		prefix := tr("Usage: ")
+		if i > 0 {
+			prefix = strings.Repeat(" ", len("Usage: "))
+		}
+		fmt.Fprint(w, prefix+strings.TrimSpace("goissue "+d.name+" "+u)+"\n")
+	}
+	if len(d.usage) == 0 {
+		fmt.Fprint(w, tr("Usage: ")+"goissue "+d.name+"\n")
+	}
+	fmt.Fprint(w, "\n"+tr(d.summary)+"\n")
+	if fs != nil && hasFlags(fs) {
+		fmt.Fprint(w, "\n"+tr("Flags:")+"\n")
+		fs.SetOutput(w)
+		fs.PrintDefaults()
+	}
+	if len(d.examples) > 0 {
+		fmt.Fprint(w, "\n"+tr("Examples:")+"\n")
+		for _, e := range d.examples {
+			fmt.Fprint(w, "  # "+e+"\n")
+		}
+	}
+	if len(d.settings) > 0 {
+		fmt.Fprint(w, "\n"+tr("Settings:")+" "+strings.Join(d.settings, ", ")+"\n")
+	}
+}
+
+// hasFlags return true if any flag is defined in fs.
+func hasFlags(fs *flag.FlagSet) bool {
+	found := false
+	fs.VisitAll(func(*flag.Flag) { found = true })
+	return found
+}
+
+// showHelp print help of the command: goissue help [COMMAND]. With -man or
+// -cheatsheet, the document of all commands is written.
+func showHelp(args []string) {
+	var o helpFlags
+	fs := newFlagSet("help")
+	o.register(fs)
+	args = parseFlags(fs, args)
+	switch {
+	case o.man:
+		writeMan(os.Stdout)
+		return
+	case o.cheatsheet:
+		writeCheatsheet(os.Stdout)
+		return
+	case len(args) == 0:
+		flag.Usage()
+		return
+	}
+	d := lookupCommand(args[0])
+	if d == nil {
+		fmt.Fprint(os.Stderr, "unknown command: "+args[0]+"\n")
+		fmt.Fprint(os.Stderr, "Usage: goissue help [-man | -cheatsheet | COMMAND]\n")
+		exit(exitUsage)
+	}
+	printHelp(os.Stdout, d.name, commandFlags(d))
+}
+
+// helpFlags is flags of help.
+type helpFlags struct {
+	man        bool
+	cheatsheet bool
+}
+
+// register add flags of help to the flag set.
+func (o *helpFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.man, "man", false, "write the man page in roff")
+	fs.BoolVar(&o.cheatsheet, "cheatsheet", false, "write usage of all commands in plain text")
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCommandTable(t *testing.T) {
+	seen := map[string]bool{}
+	for _, d := range commandTable {
+		if seen[d.name] {
+			t.Errorf("%s is defined twice", d.name)
+		}
+		seen[d.name] = true
+		if d.summary == "" {
+			t.Errorf("%s has no summary", d.name)
+		}
+		if len(d.examples) == 0 {
+			t.Errorf("%s has no examples", d.name)
+		}
+		if catalog["ja"][d.summary] == "" {
+			t.Errorf("summary of %s is not translated", d.name)
+		}
+	}
+	for name := range commands {
+		if lookupCommand(name) == nil {
+			t.Errorf("%s has no definition", name)
+		}
+	}
+}
+
+func TestWriteCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh"} {
+		var b bytes.Buffer
+		if err := writeCompletion(&b, shell); err != nil {
+			t.Fatal(err)
+		}
+		script := b.String()
+		if !strings.Contains(script, "goissue members -prefix") {
+			t.Errorf("%s: user names are not completed with members", shell)
+		}
+		if !strings.Contains(script, " acme members ") {
+			t.Errorf("%s: commands are not completed", shell)
+		}
+	}
+	if err := writeCompletion(io.Discard, "fish"); err == nil {
+		t.Error("unknown shell is accepted")
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+// messageLang is the language of messages, like "ja". Empty means English.
+var messageLang string
+
+// setMessageLang choose the language of messages from the preferred
+// languages, like ["ja_JP", "ja"].
+func setMessageLang(langs []string) {
+	messageLang = ""
+	for _, lang := range langs {
+		if _, ok := catalog[lang]; ok {
+			messageLang = lang
+			return
+		}
+	}
+}
+
+// initMessageLang set the message language from config or LANG. With
+// -porcelain, messages are kept in English for scripts.
+func initMessageLang(config *Config, o Options) {
+	if o.Porcelain {
+		setMessageLang(nil)
+		return
+	}
+	setMessageLang(languages(config))
+}
+
+// tr return s translated to the message language. Messages are keyed by
+// the English text, and s is returned as is if it has no translation.
+// messageLang is not set with -porcelain, so messages are in English.
+func tr(s string) string {
+	if messageLang == "" {
+		return s
+	}
+	if t, ok := catalog[messageLang][s]; ok {
+		return t
+	}
+	return s
+}
+
+// catalog is translations of messages by language. Format verbs must be
+// kept in the same order, or indexed like %[2]d.
+var catalog = map[string]map[string]string{
+	"ja": {
+		// usage
+		"Usage: ":       "使い方: ",
+		"search issues": "issue を検索する",
+		"scope of -s: summary, description, comments or all": "-s の検索範囲: summary、description、comments または all",
+		"create issue":                                                             "issue を作成する",
+		"show comments":                                                            "コメントを表示する",
+		"show comments as trees of replies":                                        "コメントを返信のツリーとして表示する",
+		"index of the first issue to list, starting at 1":                          "一覧の最初の issue の位置 (1 から)",
+		"number of issues to list":                                                 "一覧する issue の数",
+		"don't use cached responses":                                               "キャッシュされた応答を使わない",
+		"log level: error, warn, info or debug":                                    "ログレベル: error、warn、info または debug",
+		"append log messages to the file":                                          "ログをファイルに追記する",
+		"don't log in; only reading public issues is possible":                     "ログインしない。公開されている issue の閲覧のみ可能",
+		"print timings of requests and phases":                                     "リクエストと各処理の所要時間を表示する",
+		"print issues in stable tab separated format, without decorative messages": "issue を安定したタブ区切り形式で表示し、装飾的なメッセージを出さない",
+		"print only numbers of issues in lists":                                    "一覧で issue の番号のみ表示する",
+		"end lines of lists with NUL, for xargs -0":                                "一覧の行末を NUL にする (xargs -0 用)",
+		"same as -0": "-0 と同じ",
+		"print linear text without indentation and progress, for screen readers":       "スクリーンリーダー向けに、字下げや進捗を含まない線形のテキストを表示する",
+		"project to use, or all for \"projects\" in settings (list, sync and -s only)": "使用するプロジェクト。all で settings の \"projects\" すべて (list、sync、-s のみ)",
+
+		// help
+		"Flags:":                          "フラグ:",
+		"Examples:":                       "例:",
+		"Settings:":                       "設定:",
+		"list issues matched to filters.": "フィルタに一致する issue を一覧する。",
+		"show issues, or the issue looked up by words in title from the local store.":                     "issue を表示する。ローカルストアからタイトルの単語で探すこともできる。",
+		"show what changed in the issue since the copy in the local store, or differences of two issues.": "ローカルストアのコピー以降に issue で変わったこと、または二つの issue の違いを表示する。",
+		"create issue written in the editor from the template.":                                           "テンプレートからエディタで書いた issue を作成する。",
+		"create issue split from the issue, linking each other.":                                          "issue から分割した issue を作成し、相互にリンクする。",
+		"create issue following up the issue, linking each other.":                                        "issue のフォローアップを作成し、相互にリンクする。",
+		"post the same comment to matched issues, after confirming the list.":                             "一致した issue に同じコメントを投稿する。一覧を確認してから投稿する。",
+		"raise the priority of the issue, cc the owners of its areas, and tell why.":                      "issue の優先度を上げ、領域の担当者を cc に入れ、理由を伝える。",
+		"add labels, the owner and cc of routes matched to the issue.":                                    "issue に一致したルートのラベル、担当者、cc を加える。",
+		"rename label of matched issues.":                                                                 "一致した issue のラベルを付け替える。",
+		"show recent updates and comments of the project in time order.":                                  "プロジェクトの最近の更新とコメントを時系列で表示する。",
+		"list open issues starred, owned or cc'd by you updated since shown last.":                        "スター、担当、cc している open な issue のうち、前回表示以降に更新されたものを一覧する。",
+		"mute issues locally in watch, inbox and activity.":                                               "watch、inbox、activity で issue をローカルにミュートする。",
+		"export issue with all comments and links into one file.":                                         "issue をすべてのコメントとリンクとともに一つのファイルにエクスポートする。",
+		"run updates written in the file, one per line.":                                                  "ファイルに一行ずつ書かれた更新を実行する。",
+		"check settings, login, the project, the editor and the cache.":                                   "設定、ログイン、プロジェクト、エディタ、キャッシュを確認する。",
+		"update goissue to the latest release.":                                                           "goissue を最新のリリースに更新する。",
+		"show the version, the commit and the API version.":                                               "バージョン、コミット、API バージョンを表示する。",
+		"assign the issue to you with status Started and cc, and list it in wip.":                         "issue を自分に割り当てて Started にし、cc に入れ、wip に載せる。",
+		"list issues you took, oldest first.":                                                             "take した issue を古い順に一覧する。",
+		"record time spent on the issue.":                                                                 "issue に費やした時間を記録する。",
+		"show time spent per issue, recorded by spend.":                                                   "spend で記録した issue ごとの時間を表示する。",
+		"list open issues not updated for days, and ask for an update.":                                   "一定期間更新のない open な issue を一覧し、状況を尋ねる。",
+		"copy issues into the local store, and post the outbox.":                                          "issue をローカルストアにコピーし、送信箱を投稿する。",
+		"show open/closed counts over time, from the local store.":                                        "ローカルストアから open/closed の件数の推移を表示する。",
+		"show status and blockers of a milestone, and move open issues to next one.":                      "マイルストーンの状況とブロッカーを表示し、open な issue を次に移す。",
+		"show filed/closed issues per owner or author, from the local store.":                             "ローカルストアから担当者または報告者ごとの起票/クローズ数を表示する。",
+		"draw blocked-on and duplicate relationships.":                                                    "blocked-on と重複の関係を描く。",
+		"make release notes from issues closed in the period.":                                            "期間内にクローズされた issue からリリースノートを作る。",
+		"show, clear or prune the response cache.":                                                        "応答キャッシュを表示、消去、整理する。",
+		"watch issues updated and serve counters at /debug/vars.":                                         "issue の更新を監視し、/debug/vars でカウンタを提供する。",
+		"write the shell completion script of commands and user names from members.":                      "コマンドとメンバーのユーザー名を補完するシェルの補完スクリプトを書き出す。",
+		"list project members.":                                                                           "プロジェクトのメンバーを一覧する。",
+		"comment on issue, written in the editor without -m.":                                             "issue にコメントする。-m がなければエディタで書く。",
+		"list or discard drafts of comments.":                                                             "コメントの下書きを一覧または破棄する。",
+		"list, retry or drop issues and comments failed to post.":                                         "投稿に失敗した issue とコメントを一覧、再送、破棄する。",
+		"encrypt the password in settings with a passphrase, or print it decrypted.":                      "settings のパスワードをパスフレーズで暗号化する。復号して表示することもできる。",
+		"show help of the command, or write the man page or the cheatsheet of all commands.":              "コマンドのヘルプを表示する。全コマンドの man ページやチートシートも書き出せる。",
+
+		"write the man page in roff":                "man ページを roff で書き出す",
+		"write usage of all commands in plain text": "全コマンドの使い方をテキストで書き出す",
+
+		// prompts
+		"post the comment to these %d issues?":                                                    "これら %d 件の issue にコメントを投稿しますか?",
+		"hide issues by the authors or with the titles from list, search and watch.":              "作成者やタイトルでスパムの issue を list や検索、watch で表示しないようにします。",
+		"%d issues hidden as spam; show them with -spam\n":                                        "%d 件の issue をスパムとして隠しました。-spam で表示します\n",
+		"merge read marks, muted and taken issues with the state file shared between machines.":   "既読や mute、take した issue を複数のマシンで共有する状態ファイルとマージします。",
+		"open issues in acme windows, with Get, Comment and Close in the tag.":                    "issue を acme のウィンドウで開きます。タグで Get、Comment、Close を実行できます。",
+		"show sections of \"dashboard\" in settings with counts and top issues.":                  "設定の \"dashboard\" の各セクションを件数と上位の issue で表示します。",
+		"showing the latest %d of %d comments\n":                                                  "最新の %d 件のコメントを表示しています (全 %d 件)\n",
+		"write issues with comments into a git repository as markdown files, and commit changes.": "コメント付きの issue を Markdown ファイルとして git リポジトリに書き出し、変更をコミットする。",
+		"committed %d changed issues\n":                                                           "変更された %d 件の issue をコミットしました\n",
+		"no issues changed\n":                                                                     "変更された issue はありません\n",
+		"add them?":                                                                               "追加しますか?",
+		"apply these updates?":                                                                    "これらの更新を適用しますか?",
+		"%d of %d issues over SLA\n":                                                              "%[2]d 件中 %[1]d 件の issue が SLA を超えています\n",
+		"post anyway?":                                                                            "それでも投稿しますか?",
+		"update anyway?":                                                                          "それでも更新しますか?",
+		"send it anyway?":                                                                         "それでも送信しますか?",
+		"post this issue?":                                                                        "この issue を投稿しますか?",
+		"post the comment now? (no keeps it as a draft)":                                          "コメントを今投稿しますか? (no で下書きとして保存)",
+
+		// errors
+		"authentication required; set email and password in your settings.json": "認証が必要です。settings.json に email と password を設定してください",
+		"canceled":                                            "キャンセルしました",
+		"canceled:":                                           "キャンセルしました:",
+		"comment is empty":                                    "コメントが空です",
+		"failed to get issue:":                                "issue の取得に失敗しました:",
+		"failed to get issues:":                               "issue 一覧の取得に失敗しました:",
+		"failed to get issues: %v":                            "issue 一覧の取得に失敗しました: %v",
+		"failed to get comments:":                             "コメントの取得に失敗しました:",
+		"failed to get inbox:":                                "受信箱の取得に失敗しました:",
+		"failed to get activity:":                             "アクティビティの取得に失敗しました:",
+		"failed to parse xml:":                                "XML の解析に失敗しました:",
+		"failed to parse search words:":                       "検索語の解析に失敗しました:",
+		"failed to post comment:":                             "コメントの投稿に失敗しました:",
+		"failed to post issue:":                               "issue の投稿に失敗しました:",
+		"failed to update:":                                   "更新に失敗しました:",
+		"failed to export issue:":                             "issue のエクスポートに失敗しました:",
+		"failed to export issues:":                            "issue のエクスポートに失敗しました:",
+		"failed to read store:":                               "ストアの読み込みに失敗しました:",
+		"failed to write store:":                              "ストアの書き込みに失敗しました:",
+		"failed to save draft:":                               "下書きの保存に失敗しました:",
+		"failed to save to outbox:":                           "送信箱への保存に失敗しました:",
+		"failed to render issue:":                             "issue の表示に失敗しました:",
+		"failed to check update:":                             "更新の確認に失敗しました:",
+		"failed to check API version:":                        "API バージョンの確認に失敗しました:",
+		"failed to post comment: %v (draft is kept in %s)":    "コメントの投稿に失敗しました: %v (下書きは %s に保存されています)",
+		"failed to save comment: %v (draft is kept in %s)":    "コメントの保存に失敗しました: %v (下書きは %s に保存されています)",
+		"invalid issue number:":                               "issue 番号が不正です:",
+		"no issue matched:":                                   "一致する issue がありません:",
+		"no issues in the store; run goissue sync first":      "ストアに issue がありません。先に goissue sync を実行してください",
+		"unknown format:":                                     "不明な形式です:",
+		"unknown issue type:":                                 "不明な issue の種類です:",
+		"invalid default_command in your settings.json:":      "settings.json の default_command が不正です:",
+		"-project all is supported only by list, sync and -s": "-project all は list、sync、-s でのみ使えます",
+
+		// warnings and notes
+		"issue %s was changed upstream at %s:":                     "issue %s は %s に他の人によって変更されています:",
+		"the text may contain secrets or private paths:":           "本文にパスワードや非公開のパスが含まれている可能性があります:",
+		"not answered: %s":                                         "未回答: %s",
+		"misspelled: %s":                                           "スペルミス: %s",
+		"comment is %d bytes, over the limit of %d bytes":          "コメントは %d バイトで、上限の %d バイトを超えています",
+		"comment posted to issue %s":                               "issue %s にコメントを投稿しました",
+		"issue %s created":                                         "issue %s を作成しました",
+		"draft saved in %s":                                        "下書きを %s に保存しました",
+		"resuming draft %s":                                        "下書き %s を再開します",
+		"%d items are left in outbox":                              "送信箱に %d 件残っています",
+		"no comments in the store; run sync -comments to see them": "ストアにコメントがありません。sync -comments を実行してください",
+		"the tracker API is deprecated (%s); update goissue with: goissue selfupdate": "トラッカーの API は非推奨です (%s)。goissue selfupdate で goissue を更新してください",
+	},
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTr(t *testing.T) {
+	defer setMessageLang(nil)
+	setMessageLang([]string{"ja_JP", "ja"})
+	if got := tr("comment is empty"); got != "コメントが空です" {
+		t.Errorf("tr = %q", got)
+	}
+	if got := tr("no such message"); got != "no such message" {
+		t.Errorf("tr of unknown message = %q", got)
+	}
+}
+
+func TestPorcelainEnglish(t *testing.T) {
+	defer setMessageLang(nil)
+	defer os.Setenv("LANG", os.Getenv("LANG"))
+	os.Setenv("LANG", "ja_JP.UTF-8")
+	initMessageLang(nil, Options{})
+	if got := tr("comment is empty"); got != "コメントが空です" {
+		t.Errorf("tr with LANG=ja = %q", got)
+	}
+	initMessageLang(&Config{Lang: "ja"}, Options{Porcelain: true})
+	if got := tr("comment is empty"); got != "comment is empty" {
+		t.Errorf("tr with -porcelain = %q", got)
+	}
+}
+
+func TestCatalogVerbs(t *testing.T) {
+	verb := regexp.MustCompile(`%(?:\[(\d+)\])?([a-z])`)
+	// verbs return the verbs of s in the order of their arguments; indexed
+	// verbs like %[2]d may be in another order for the language.
+	verbs := func(s string) string {
+		var v []string
+		arg := 0
+		for _, m := range verb.FindAllStringSubmatch(s, -1) {
+			if m[1] != "" {
+				n, _ := strconv.Atoi(m[1])
+				arg = n - 1
+			}
+			for len(v) <= arg {
+				v = append(v, "")
+			}
+			v[arg] = "%" + m[2]
+			arg++
+		}
+		return strings.Join(v, "")
+	}
+	for lang, messages := range catalog {
+		for en, s := range messages {
+			want := verbs(en)
+			if got := verbs(s); got != want {
+				t.Errorf("%s: verbs of %q are %q, want %q", lang, s, got, want)
+			}
+		}
+	}
+}
+
+func TestCatalogUsed(t *testing.T) {
+	files, _ := filepath.Glob("*.go")
+	var src bytes.Buffer
+	for _, file := range files {
+		if file == "i18n.go" || strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		src.Write(b)
+	}
+	for lang, messages := range catalog {
+		for en := range messages {
+			if !strings.Contains(src.String(), strconv.Quote(en)) && !strings.Contains(src.String(), "`"+en+"`") {
+				t.Errorf("%s: %q is not a message of goissue", lang, en)
+			}
+		}
+	}
+}
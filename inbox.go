@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"sort"
+)
+
+// loadSeen return updated time of issues when they were shown last, keyed
+// by issue number.
+func loadSeen(project string) map[string]string {
+	seen := map[string]string{}
+	if err := readLocal(project, "seen", &seen); err != nil {
+		warnf("failed to read seen issues: %v", err)
+	}
+	return seen
+}
+
+// saveSeen write updated time of seen issues.
+func saveSeen(project string, seen map[string]string) {
+	if err := writeLocal(project, "seen", seen); err != nil {
+		warnf("failed to write seen issues: %v", err)
+	}
+}
+
+// markSeen record that the entry is read as of its last update.
+func markSeen(project string, entry Entry) {
+	seen := loadSeen(project)
+	if seen[issueId(entry)] == entry.Updated {
+		return
+	}
+	seen[issueId(entry)] = entry.Updated
+	saveSeen(project, seen)
+}
+
+// inboxQueries is searches for issues the user is involved in.
+var inboxQueries = []string{"is:starred", "owner:me", "cc:me"}
+
+// inboxFlags is flags of inbox.
+type inboxFlags struct {
+	all bool
+}
+
+// register add flags of inbox to the flag set.
+func (o *inboxFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.all, "all", false, "list read issues too")
+}
+
+// showInbox print open issues starred, owned or cc'd by the user that are
+// updated since they were shown last.
+func showInbox(ctx context.Context, config *Config, c *Client, args []string) {
+	var o inboxFlags
+	fs := newFlagSet("inbox")
+	o.register(fs)
+	fs.Parse(args)
+	if !c.LoggedIn() {
+		fatal("failed to get inbox:", errAuthRequired)
+	}
+
+	// search results depend on the user's state, so don't cache them.
+	ic := *c
+	ic.Cache = nil
+	found := map[string]Entry{}
+	for _, q := range inboxQueries {
+		f := filter{query: q}
+		entries, err := ic.Entries(ctx, f.values("open"))
+		if err != nil {
+			fatal("failed to get issues:", err)
+		}
+		for _, entry := range f.narrow(entries) {
+			found[issueId(entry)] = entry
+		}
+	}
+	var entries []Entry
+	for _, entry := range found {
+		entries = append(entries, entry)
+	}
+	sort.Sort(sort.Reverse(byUpdated(entries)))
+
+	seen := loadSeen(c.Project)
+	muted := loadMuted(c.Project)
+	for _, entry := range entries {
+		if muted[issueId(entry)] {
+			continue
+		}
+		unread := seen[issueId(entry)] != entry.Updated
+		if !unread && !o.all {
+			continue
+		}
+		mark := "  "
+		if unread {
+			mark = "* "
+		}
+		if c.Options.Plain {
+			// a word is read out, a column of marks isn't.
+			mark = ""
+			if unread {
+				mark = "unread "
+			}
+		}
+		printIssue(c, entry, mark+issueId(entry)+": "+entry.Title)
+	}
+}
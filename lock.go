@@ -0,0 +1,156 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// lockTimeout is how long lockFile wait for other processes.
+var lockTimeout = 30 * time.Second
+
+// staleLock is age of a lock file after which it is taken as left by a
+// crashed process.
+const staleLock = 2 * time.Minute
+
+// lockFile take the lock of file between processes, by creating
+// file+".lock" exclusively. It waits while another process hold it, and
+// return function to release it. Creating a file exclusively works the
+// same on all platforms, unlike flock. While held, the lock is touched
+// every staleLock/4, so that long syncs aren't taken as crashed; the lock
+// has the pid and a random token, and is removed only if it is still ours.
+// A stale lock is broken by breakLock.
+func lockFile(file string) (unlock func(), err error) {
+	lock := file + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lock), 0700); err != nil {
+		return nil, err
+	}
+	var nonce [8]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	owner := fmt.Sprintf("%d %x\n", os.Getpid(), nonce)
+	deadline := time.Now().Add(lockTimeout)
+	for wait := 10 * time.Millisecond; ; {
+		f, err := os.OpenFile(lock, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			_, err = f.WriteString(owner)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lock)
+				return nil, err
+			}
+			return holdLock(lock, owner), nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if fi, err := os.Stat(lock); err == nil && time.Since(fi.ModTime()) > staleLock {
+			breakLock(lock, lock+fmt.Sprintf(".%x.stale", nonce))
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New("timed out waiting for " + lock + "; remove it if no goissue is running")
+		}
+		time.Sleep(wait)
+		if wait < 200*time.Millisecond {
+			wait *= 2
+		}
+	}
+}
+
+// breakLock remove the stale lock. Removing it in place could remove a
+// lock another process has just taken over it, so it is renamed aside
+// first, which only one process can do for the file, and checked again
+// there. If it turns out to be fresh, it is put back unless another lock
+// is taken meanwhile.
+func breakLock(lock, aside string) {
+	if err := os.Rename(lock, aside); err != nil {
+		return
+	}
+	defer os.Remove(aside)
+	if fi, err := os.Stat(aside); err == nil && time.Since(fi.ModTime()) <= staleLock {
+		os.Link(aside, lock)
+		return
+	}
+	warnf("removing stale lock %s", lock)
+}
+
+// ownsLock return true if the lock file has the owner.
+func ownsLock(lock, owner string) bool {
+	b, err := ioutil.ReadFile(lock)
+	return err == nil && string(b) == owner
+}
+
+// holdLock keep the lock fresh until the returned function release it.
+func holdLock(lock, owner string) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		t := time.NewTicker(staleLock / 4)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-t.C:
+				if !ownsLock(lock, owner) {
+					warnf("lock %s was taken by another process", lock)
+					return
+				}
+				os.Chtimes(lock, now, now)
+			}
+		}
+	}()
+	var once sync.Once
+	var cancel func()
+	release := func() {
+		once.Do(func() {
+			cancel()
+			close(done)
+			<-stopped
+			if ownsLock(lock, owner) {
+				os.Remove(lock)
+			}
+		})
+	}
+	// released by exit too, so that fatal doesn't leave the lock.
+	cancel = atExit(release)
+	return release
+}
+
+// replaceFile write b to the file through a temporary file renamed over it,
+// so that a crash while writing leave the old file or the new one, never a
+// part of it. The temporary file start with "." to be skipped by readers of
+// the directory.
+func replaceFile(file string, b []byte, perm os.FileMode) error {
+	f, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+".tmp*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), file)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
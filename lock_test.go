@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goissue-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "store")
+
+	var wg sync.WaitGroup
+	held, max := 0, 0
+	var mu sync.Mutex
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := lockFile(file)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			held++
+			if held > max {
+				max = held
+			}
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			held--
+			mu.Unlock()
+			unlock()
+		}()
+	}
+	wg.Wait()
+	if max != 1 {
+		t.Errorf("lock was held by %d at once", max)
+	}
+
+	// a lock left by a crashed process is taken over.
+	if err := ioutil.WriteFile(file+".lock", nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleLock)
+	os.Chtimes(file+".lock", old, old)
+	unlock, err := lockFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a fresh lock taken over the stale one meanwhile is not broken.
+	if err := ioutil.WriteFile(file+".lock2", []byte("1 other\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	breakLock(file+".lock2", file+".lock2.stale")
+	if b, err := ioutil.ReadFile(file + ".lock2"); err != nil || string(b) != "1 other\n" {
+		t.Errorf("fresh lock was broken: %q, %v", b, err)
+	}
+	if _, err := os.Stat(file + ".lock2.stale"); !os.IsNotExist(err) {
+		t.Errorf("lock renamed aside is left: %v", err)
+	}
+
+	// a lock taken over by another process is not removed by unlock.
+	if err := ioutil.WriteFile(file+".lock", []byte("1 other\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if _, err := os.Stat(file + ".lock"); err != nil {
+		t.Errorf("unlock removed the lock of another process: %v", err)
+	}
+}
+
+func TestLockCleanup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goissue-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "store")
+
+	// fatal run cleanups before exiting, which release locks held.
+	unlock, err := lockFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runCleanups()
+	if _, err := os.Stat(file + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock is left after cleanups: %v", err)
+	}
+	unlock()
+
+	// locks released already are not released again.
+	unlock, err = lockFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if len(cleanups) != 0 {
+		t.Errorf("%d cleanups are left after unlock", len(cleanups))
+	}
+}
+
+func TestCachedLogin(t *testing.T) {
+	home, err := ioutil.TempDir("", "goissue-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+
+	var mu sync.Mutex
+	logins := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		logins++
+		mu.Unlock()
+		fmt.Fprint(w, "SID=x\nLSID=y\nAuth=token\n")
+	}))
+	defer ts.Close()
+	config := defaultConfig()
+	config.Email, config.Password, config.LoginURL = "a@example.com", "p", ts.URL
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if auth, err := cachedLogin(context.Background(), config); err != nil || auth != "Auth=token" {
+				t.Errorf("cachedLogin = %q, %v", auth, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if logins != 1 {
+		t.Errorf("logged in %d times, want once", logins)
+	}
+	forgetToken(config)
+	cachedLogin(context.Background(), config)
+	if logins != 2 {
+		t.Errorf("logged in %d times after forgetToken, want twice", logins)
+	}
+}
+
+func TestReplaceFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goissue-replace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "store")
+
+	for _, s := range []string{"old", "new"} {
+		if err := replaceFile(file, []byte(s), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if b, _ := ioutil.ReadFile(file); string(b) != "new" {
+		t.Errorf("file has %q, want %q", b, "new")
+	}
+	if fis, _ := ioutil.ReadDir(dir); len(fis) != 1 {
+		t.Errorf("temporary files are left: %d files", len(fis))
+	}
+}
+
+func TestLoadStoreBroken(t *testing.T) {
+	home, err := ioutil.TempDir("", "goissue-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+
+	file := storeFile("go")
+	os.MkdirAll(filepath.Dir(file), 0700)
+	if err := ioutil.WriteFile(file, compress([]byte(`{"synced":"2012-01-01T00:00:00Z","entries":{`)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s := loadStore("go")
+	if s.Synced != "" || len(s.Entries) != 0 {
+		t.Errorf("broken store is loaded: %+v", s)
+	}
+	if _, err := os.Stat(file + ".corrupt"); err != nil {
+		t.Errorf("broken store is not moved aside: %v", err)
+	}
+	s.save()
+	if s := loadStore("go"); s.Entries == nil {
+		t.Error("saved store can't be loaded")
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// logLevel is severity of log messages.
+type logLevel int
+
+const (
+	levelError logLevel = iota
+	levelWarn
+	levelInfo
+	levelDebug
+)
+
+var levelNames = []string{"error", "warn", "info", "debug"}
+
+// verbosity is the most verbose level of messages to be written. It is set
+// once at startup by setupLog.
+var verbosity = levelInfo
+
+// setupLog set log level and log file. Log messages are written to stderr
+// and, if file is not empty, appended to the file. stdout is left for
+// results of commands.
+func setupLog(level, file string) {
+	found := false
+	for i, name := range levelNames {
+		if name == level {
+			verbosity = logLevel(i)
+			found = true
+		}
+	}
+	if !found {
+		fatal("unknown log level: " + level)
+	}
+	if file != "" {
+		f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			fatal("failed to open log file:", err)
+		}
+		log.SetOutput(io.MultiWriter(os.Stderr, f))
+	}
+}
+
+func logf(level logLevel, format string, args ...interface{}) {
+	if level > verbosity {
+		return
+	}
+	log.Print(levelNames[level] + ": " + fmt.Sprintf(tr(format), args...))
+}
+
+func warnf(format string, args ...interface{})  { logf(levelWarn, format, args...) }
+func infof(format string, args ...interface{})  { logf(levelInfo, format, args...) }
+func debugf(format string, args ...interface{}) { logf(levelDebug, format, args...) }
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// commandFlags return flag set of the command with its flags defined, or
+// nil if the command has no flags.
+func commandFlags(d *command) *flag.FlagSet {
+	if d.flags == nil {
+		return nil
+	}
+	fs := flag.NewFlagSet(d.name, flag.ContinueOnError)
+	d.flags(fs)
+	return fs
+}
+
+// flagLines return flags of fs as pairs of "-name value" and the usage.
+func flagLines(fs *flag.FlagSet, hidden ...string) (lines [][2]string) {
+	if fs == nil {
+		return nil
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		for _, name := range hidden {
+			if f.Name == name {
+				return
+			}
+		}
+		name, usage := flag.UnquoteUsage(f)
+		arg := "-" + f.Name
+		if name != "" {
+			arg += " " + name
+		}
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+			usage += " (default " + f.DefValue + ")"
+		}
+		lines = append(lines, [2]string{arg, usage})
+	})
+	return lines
+}
+
+// roff escape s for a line of roff text.
+func roff(s string) string {
+	s = strings.Replace(s, `\`, `\e`, -1)
+	s = strings.Replace(s, "-", `\-`, -1)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+// writeMan write man page of goissue in roff, from the command table and
+// the flags of each command.
+func writeMan(w io.Writer) {
+	fmt.Fprintf(w, ".TH GOISSUE 1 \"\" \"goissue %s\" \"User Commands\"\n", version)
+	fmt.Fprint(w, ".SH NAME\ngoissue \\- issue tool for googlecode.com\n")
+	fmt.Fprint(w, ".SH SYNOPSIS\n.B goissue\n")
+	fmt.Fprint(w, roff("[-project NAME|all] [-c ID | -s WORDS [-in SCOPE]] [-start N] [-n N]")+"\n")
+	for _, d := range commandTable {
+		for _, u := range d.usage {
+			fmt.Fprint(w, ".br\n.B goissue "+d.name+"\n"+roff(u)+"\n")
+		}
+		if len(d.usage) == 0 {
+			fmt.Fprint(w, ".br\n.B goissue "+d.name+"\n")
+		}
+	}
+	fmt.Fprint(w, ".SH DESCRIPTION\n")
+	fmt.Fprint(w, "Without command, goissue lists issues of the project, or shows the issues given by number.\n")
+	fmt.Fprint(w, ".SH OPTIONS\n")
+	for _, l := range flagLines(flag.CommandLine, "cpuprofile", "memprofile") {
+		fmt.Fprint(w, ".TP\n.B "+roff(l[0])+"\n"+roff(l[1])+"\n")
+	}
+	fmt.Fprint(w, ".SH COMMANDS\n")
+	for i := range commandTable {
+		d := &commandTable[i]
+		fmt.Fprint(w, ".SS "+d.name+"\n"+roff(d.summary)+"\n")
+		for _, l := range flagLines(commandFlags(d)) {
+			fmt.Fprint(w, ".TP\n.B "+roff(l[0])+"\n"+roff(l[1])+"\n")
+		}
+		if len(d.examples) > 0 {
+			fmt.Fprint(w, ".PP\n.nf\n")
+			for _, e := range d.examples {
+				fmt.Fprint(w, roff("$ "+e)+"\n")
+			}
+			fmt.Fprint(w, ".fi\n")
+		}
+		if len(d.settings) > 0 {
+			fmt.Fprint(w, ".PP\nSettings: "+roff(strings.Join(d.settings, ", "))+"\n")
+		}
+	}
+	fmt.Fprint(w, ".SH SETTINGS\n")
+	for _, s := range settingDefs {
+		usage := s.usage
+		if s.def != "" {
+			usage += " (default " + s.def + ")"
+		}
+		fmt.Fprint(w, ".TP\n.B "+roff(s.key)+" \\fI"+s.kind+"\\fR\n"+roff(usage)+"\n")
+	}
+	fmt.Fprint(w, ".SH EXIT STATUS\n")
+	fmt.Fprint(w, "0 success, 1 error, 2 invalid command line, 3 authentication failed or required, ")
+	fmt.Fprint(w, "4 issue not found or no issues matched, 5 network error.\n")
+	fmt.Fprint(w, ".SH FILES\n.TP\n.I ~/.config/goissue/settings.json\nsettings; see settings.json.example.\n")
+	fmt.Fprint(w, ".SH AUTHOR\nYasuhiro Matsumoto <mattn.jp@gmail.com>\n")
+}
+
+// writeCheatsheet write usage and flags of all commands in plain text.
+func writeCheatsheet(w io.Writer) {
+	fmt.Fprint(w, "goissue [-project NAME|all] [-c ID | -s WORDS [-in SCOPE]] [-start N] [-n N]\n")
+	for _, l := range flagLines(flag.CommandLine, "cpuprofile", "memprofile") {
+		fmt.Fprintf(w, "    %-20s %s\n", l[0], l[1])
+	}
+	for i := range commandTable {
+		d := &commandTable[i]
+		fmt.Fprint(w, "\n")
+		for _, u := range d.usage {
+			fmt.Fprint(w, strings.TrimSpace("goissue "+d.name+" "+u)+"\n")
+		}
+		if len(d.usage) == 0 {
+			fmt.Fprint(w, "goissue "+d.name+"\n")
+		}
+		fmt.Fprint(w, "    "+d.summary+"\n")
+		for _, l := range flagLines(commandFlags(d)) {
+			fmt.Fprintf(w, "    %-20s %s\n", l[0], l[1])
+		}
+	}
+}
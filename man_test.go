@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCommandFlags(t *testing.T) {
+	fs := commandFlags(lookupCommand("create"))
+	if fs == nil || fs.Lookup("type") == nil || fs.Lookup("set") == nil {
+		t.Fatal("flags of create are not defined")
+	}
+	if fs := commandFlags(lookupCommand("wip")); fs != nil {
+		t.Error("flags of wip, which has no flags, are defined")
+	}
+}
+
+func TestWriteMan(t *testing.T) {
+	var b bytes.Buffer
+	writeMan(&b)
+	man := b.String()
+	for _, d := range commandTable {
+		if !strings.Contains(man, "\n.SS "+d.name+"\n") {
+			t.Errorf("no section of %s", d.name)
+		}
+	}
+	if !strings.Contains(man, `.B \-from\-cmd string`) {
+		t.Error("flags of create are not written")
+	}
+}
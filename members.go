@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// projectMembers return user names of project members. They are given by
+// "members" in config as comma separated list, or collected from owners and
+// cc of issues in the local store.
+func projectMembers(config *Config, project string) []string {
+	if len(config.Members) > 0 {
+		return config.Members
+	}
+	seen := map[string]bool{}
+	for _, entry := range loadStore(project).Entries {
+		for _, owner := range entry.IssuesOwner {
+			seen[owner.IssuesUsername] = true
+		}
+		for _, cc := range entry.IssuesCc {
+			seen[cc.IssuesUsername] = true
+		}
+	}
+	var members []string
+	for name := range seen {
+		if name != "" {
+			members = append(members, name)
+		}
+	}
+	sort.Strings(members)
+	return members
+}
+
+// validateUsers return error if some of users are not members of the
+// project. If no members are known, any users are allowed.
+func validateUsers(config *Config, project string, users []string) error {
+	members := projectMembers(config, project)
+	if len(members) == 0 {
+		return nil
+	}
+	for _, user := range users {
+		if !contains(members, strings.TrimPrefix(user, "-")) {
+			return errors.New("unknown member: " + user)
+		}
+	}
+	return nil
+}
+
+// membersFlags is flags of members.
+type membersFlags struct {
+	prefix string
+}
+
+// register add flags of members to the flag set.
+func (o *membersFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.prefix, "prefix", "", "print only members starting with the prefix")
+}
+
+// showMembers print project members one per line, which is also used for
+// shell completion of user names.
+func showMembers(ctx context.Context, config *Config, c *Client, args []string) {
+	var o membersFlags
+	fs := newFlagSet("members")
+	o.register(fs)
+	fs.Parse(args)
+
+	for _, member := range projectMembers(config, c.Project) {
+		if strings.HasPrefix(member, o.prefix) {
+			fmt.Println(member)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"expvar"
+	"net"
+	"net/http"
+)
+
+// counters exported by -metrics. They are served at /debug/vars as JSON.
+// Cache hits and misses are counted by Cache.get; watch polls without the
+// cache, so they are counted with -rpc.
+var (
+	metricRequests    = expvar.NewInt("requests")
+	metricErrors      = expvar.NewInt("errors")
+	metricRateLimited = expvar.NewInt("rate_limited")
+	metricCacheHits   = expvar.NewInt("cache_hits")
+	metricCacheMisses = expvar.NewInt("cache_misses")
+)
+
+func init() {
+	expvar.Publish("cache_hit_ratio", expvar.Func(cacheHitRatio))
+}
+
+// cacheHitRatio return the ratio of responses taken from the cache.
+func cacheHitRatio() interface{} {
+	hits, misses := metricCacheHits.Value(), metricCacheMisses.Value()
+	if hits+misses == 0 {
+		return 0.0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// countingTransport count requests and errors for metrics.
+type countingTransport struct {
+	base http.RoundTripper
+}
+
+func (t countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	metricRequests.Add(1)
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		metricErrors.Add(1)
+		return nil, err
+	}
+	if res.StatusCode >= 400 {
+		metricErrors.Add(1)
+	}
+	if res.StatusCode == 429 || res.StatusCode == 503 {
+		metricRateLimited.Add(1)
+	}
+	return res, nil
+}
+
+// serveMetrics start serving metrics at addr and make c count its
+// requests.
+func serveMetrics(c *Client, addr string) {
+	base := http.DefaultTransport
+	if c.HTTP != nil && c.HTTP.Transport != nil {
+		base = c.HTTP.Transport
+	}
+	c.HTTP = &http.Client{Transport: countingTransport{base}}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		fatal("failed to listen metrics:", err)
+	}
+	infof("serving metrics at http://%s/debug/vars", l.Addr())
+	go http.Serve(l, nil)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// milestoneFlags is flags of milestone.
+type milestoneFlags struct {
+	moveTo string
+	filter filter
+}
+
+// register add flags of milestone to the flag set.
+func (o *milestoneFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.moveTo, "move-to", "", "retarget open issues to the label")
+	o.filter.register(fs)
+}
+
+// showMilestone print summary of issues that target the label like
+// "Go1.1". With -move-to, open issues are retargeted to another label.
+func showMilestone(ctx context.Context, config *Config, c *Client, args []string) {
+	var o milestoneFlags
+	fs := newFlagSet("milestone")
+	o.register(fs)
+	rest := parseFlags(fs, args)
+	if len(rest) == 0 {
+		fmt.Fprint(os.Stderr, "Usage: goissue milestone [-move-to LABEL] [filters] LABEL [ID...]\n")
+		fs.PrintDefaults()
+		exit(exitUsage)
+	}
+	milestone := rest[0]
+	if o.moveTo != "" {
+		if err := validateLabels(config, c.Project, []string{o.moveTo}); err != nil {
+			fatal(err)
+		}
+	}
+	ids := map[string]bool{}
+	for _, id := range rest[1:] {
+		ids[id] = true
+	}
+
+	blocker := "Priority-Critical"
+	if b := config.BlockerLabel; b != "" {
+		blocker = b
+	}
+
+	o.filter.label = milestone
+	var open, blockers []Entry
+	count := map[string]int{}
+	entries, err := c.Entries(ctx, o.filter.values("all"))
+	if err != nil {
+		fatal("failed to get issues:", err)
+	}
+	entries = o.filter.narrow(entries)
+	for _, entry := range entries {
+		status := "(none)"
+		if len(entry.IssuesStatus) > 0 {
+			status = entry.IssuesStatus[0]
+		}
+		count[status]++
+		if entryState(entry) == "closed" {
+			continue
+		}
+		open = append(open, entry)
+		if hasLabel(entry, blocker) {
+			blockers = append(blockers, entry)
+		}
+	}
+
+	total := 0
+	var statuses []string
+	for status, n := range count {
+		statuses = append(statuses, status)
+		total += n
+	}
+	sort.Strings(statuses)
+	fmt.Printf("%s: %d issues, %d open\n", milestone, total, len(open))
+	for _, status := range statuses {
+		fmt.Printf("  %-12s %5d\n", status, count[status])
+	}
+	fmt.Println("Blockers:")
+	for _, entry := range blockers {
+		fmt.Println("  " + issueId(entry) + ": " + entry.Title)
+	}
+	fmt.Println("Open:")
+	for _, entry := range open {
+		fmt.Println("  " + issueId(entry) + ": " + entry.Title)
+	}
+
+	if o.moveTo == "" {
+		return
+	}
+	u := &Updates{Labels: []string{"-" + milestone, o.moveTo}}
+	for _, entry := range open {
+		id := issueId(entry)
+		if len(ids) > 0 && !ids[id] {
+			continue
+		}
+		err := updateIssue(ctx, c, config.Email, entry, "Moving to "+o.moveTo+".", u)
+		if err != nil {
+			fatal("failed to update issue "+id+":", err)
+		}
+		infof("moved %s to %s", id, o.moveTo)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// mirrorMarker is the first line of files written by mirror. Only files
+// with it are removed when their issues are gone, so that files of the
+// user in the directory are left.
+const mirrorMarker = "<!-- written by goissue mirror -->\n"
+
+// mirrorFiles write the threads into dir as markdown files named by the
+// issue numbers, like 123.md, and remove files written for issues not in
+// threads. Files are written only if changed, so that their times tell
+// when issues were updated.
+func mirrorFiles(c *Client, dir string, threads []thread) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	names := map[string]bool{}
+	for _, t := range threads {
+		name := issueId(t.Issue) + ".md"
+		names[name] = true
+		var b bytes.Buffer
+		b.WriteString(mirrorMarker)
+		if err := t.writeText(&b, c); err != nil {
+			return err
+		}
+		file := filepath.Join(dir, name)
+		if old, err := ioutil.ReadFile(file); err == nil && bytes.Equal(old, b.Bytes()) {
+			continue
+		}
+		if err := replaceFile(file, b.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		name := filepath.Base(file)
+		if _, err := strconv.Atoi(strings.TrimSuffix(name, ".md")); err != nil || names[name] {
+			continue
+		}
+		if b, err := ioutil.ReadFile(file); err != nil || !bytes.HasPrefix(b, []byte(mirrorMarker)) {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// git run git in dir, and return its output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// gitWorkTree return true if dir is in the work tree of a git repository.
+func gitWorkTree(dir string) bool {
+	out, err := git(dir, "rev-parse", "--is-inside-work-tree")
+	return err == nil && strings.TrimSpace(out) == "true"
+}
+
+// gitIdentity return error telling how to set the identity if git can't
+// tell the author of commits in dir.
+func gitIdentity(dir string) error {
+	for _, v := range []string{"GIT_AUTHOR_IDENT", "GIT_COMMITTER_IDENT"} {
+		if _, err := git(dir, "var", v); err != nil {
+			return fmt.Errorf("git doesn't know who commits; set user.name and user.email, like: git -C %s config user.email you@example.com", dir)
+		}
+	}
+	return nil
+}
+
+// gitCommit commit all changes in dir of the repository with the message,
+// and return the number of files changed; 0 means nothing was committed.
+func gitCommit(dir, message string) (int, error) {
+	if _, err := git(dir, "add", "-A", "."); err != nil {
+		return 0, err
+	}
+	status, err := git(dir, "status", "--porcelain", ".")
+	if err != nil {
+		return 0, err
+	}
+	if status == "" {
+		return 0, nil
+	}
+	// only the directory is committed; the repository may have other
+	// changes staged by the user.
+	if _, err := git(dir, "commit", "-q", "-m", message, "--", "."); err != nil {
+		return 0, err
+	}
+	return strings.Count(status, "\n"), nil
+}
+
+// mirrorFlags is flags of mirror.
+type mirrorFlags struct {
+	dir  string
+	full bool
+}
+
+// register add flags of mirror to the flag set.
+func (o *mirrorFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.dir, "git", "", "git repository that issues are written into")
+	fs.BoolVar(&o.full, "full", false, "fetch all issues instead of updated ones")
+}
+
+// mirrorIssues sync the store with comments, write every issue in it into
+// the git repository as a markdown file, and commit the changes. The
+// repository is created if the directory isn't in a work tree of git;
+// first mirror fetch all issues.
+func mirrorIssues(ctx context.Context, config *Config, c *Client, args []string) {
+	var o mirrorFlags
+	fs := newFlagSet("mirror")
+	o.register(fs)
+	parseFlags(fs, args)
+	if o.dir == "" {
+		fs.Usage()
+		exit(exitUsage)
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		fatal("failed to mirror issues: git is required:", err)
+	}
+
+	if !gitWorkTree(o.dir) {
+		if err := os.MkdirAll(o.dir, 0755); err != nil {
+			fatal("failed to create repository:", err)
+		}
+		if _, err := git(o.dir, "init", "-q"); err != nil {
+			fatal("failed to create repository:", err)
+		}
+		o.full = true
+	}
+	if err := gitIdentity(o.dir); err != nil {
+		fatal("failed to mirror issues:", err)
+	}
+	if err := syncProject(ctx, c, o.full, true); err != nil {
+		fatal(err)
+	}
+
+	s := loadStore(c.Project)
+	var ids []string
+	for id := range s.Entries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var threads []thread
+	for _, id := range ids {
+		entry := s.Entries[id]
+		link := webLink(entry)
+		if link == "" {
+			link = c.IssueWebURL(id)
+		}
+		threads = append(threads, thread{entry, s.Comments[id], link})
+	}
+	if err := mirrorFiles(c, o.dir, threads); err != nil {
+		fatal("failed to write issues:", err)
+	}
+	n, err := gitCommit(o.dir, fmt.Sprintf("Mirror issues of %s as of %s", c.Project, s.Synced))
+	if err != nil {
+		fatal("failed to commit issues:", err)
+	}
+	if n == 0 {
+		notef("no issues changed\n")
+		return
+	}
+	notef("committed %d changed issues\n", n)
+}
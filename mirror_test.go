@@ -0,0 +1,110 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMirror(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "goissue-mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if gitWorkTree(dir) {
+		t.Fatal("gitWorkTree is true before git init")
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"config", "user.name", "gopher"}, {"config", "user.email", "gopher@example.com"}} {
+		if _, err := git(dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !gitWorkTree(dir) {
+		t.Error("gitWorkTree is false after git init")
+	}
+	if err := gitIdentity(dir); err != nil {
+		t.Errorf("gitIdentity with user.name and user.email = %v", err)
+	}
+	issue := func(id, title string) thread {
+		return thread{Issue: Entry{Id: "http://code.google.com/feeds/issues/p/go/issues/full/" + id, Title: title}}
+	}
+	// not an issue; left as is.
+	if err := ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("issues of go\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// named like an issue, but not written by mirror; left as is.
+	if err := ioutil.WriteFile(filepath.Join(dir, "2012.md"), []byte("notes of 2012\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mirrorFiles(&Client{}, dir, []thread{issue("1", "first"), issue("2", "second")}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := gitCommit(dir, "first"); err != nil || n != 4 {
+		t.Errorf("gitCommit = %d, %v; want 4 files committed", n, err)
+	}
+	if n, err := gitCommit(dir, "nothing"); err != nil || n != 0 {
+		t.Errorf("gitCommit without changes = %d, %v; want 0", n, err)
+	}
+
+	if err := mirrorFiles(&Client{}, dir, []thread{issue("1", "first, renamed")}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := gitCommit(dir, "second"); err != nil || n != 2 {
+		t.Errorf("gitCommit = %d, %v; want 2 files committed", n, err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "1.md"))
+	if err != nil || !strings.HasPrefix(string(b), mirrorMarker+"# Issue 1: first, renamed\n") {
+		t.Errorf("1.md = %q, %v", b, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2.md")); !os.IsNotExist(err) {
+		t.Errorf("2.md of the issue gone is left: %v", err)
+	}
+	for _, name := range []string{"README.md", "2012.md"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s is removed: %v", name, err)
+		}
+	}
+}
+
+func TestMirrorSubdir(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo, err := ioutil.TempDir("", "goissue-mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repo)
+	for _, args := range [][]string{{"init", "-q"}, {"config", "user.name", "gopher"}, {"config", "user.email", "gopher@example.com"}} {
+		if _, err := git(repo, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a change the user staged outside of the mirror.
+	if err := ioutil.WriteFile(filepath.Join(repo, "notes.txt"), []byte("wip\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := git(repo, "add", "notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(repo, "issues")
+	issue := thread{Issue: Entry{Id: "http://code.google.com/feeds/issues/p/go/issues/full/1", Title: "first"}}
+	if err := mirrorFiles(&Client{}, dir, []thread{issue}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := gitCommit(dir, "mirror"); err != nil || n != 1 {
+		t.Errorf("gitCommit = %d, %v; want 1 file committed", n, err)
+	}
+	if status, err := git(repo, "status", "--porcelain"); err != nil || status != "A  notes.txt\n" {
+		t.Errorf("status after mirror = %q, %v; want notes.txt still staged", status, err)
+	}
+}
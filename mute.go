@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// loadMuted return issue numbers muted in the project.
+func loadMuted(project string) map[string]bool {
+	muted := map[string]bool{}
+	if err := readLocal(project, "muted", &muted); err != nil {
+		warnf("failed to read muted issues: %v", err)
+	}
+	return muted
+}
+
+// updateId return issue number of the entry in the updates feed, whose
+// title is like "Issue 123 (summary) commented on by foo".
+func updateId(entry Entry) string {
+	f := strings.Fields(entry.Title)
+	if len(f) < 2 || f[0] != "Issue" {
+		return ""
+	}
+	return f[1]
+}
+
+// muteFlags is flags of mute.
+type muteFlags struct {
+	undo bool
+}
+
+// register add flags of mute to the flag set.
+func (o *muteFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.undo, "undo", false, "unmute the issues")
+}
+
+// muteIssues mute issues not to be shown in watch, inbox and activity.
+// With -undo, they are unmuted. Without issues, muted ones are listed.
+func muteIssues(ctx context.Context, config *Config, c *Client, args []string) {
+	var o muteFlags
+	fs := newFlagSet("mute")
+	o.register(fs)
+	rest := parseFlags(fs, args)
+
+	muted := loadMuted(c.Project)
+	if len(rest) == 0 {
+		var ids []int
+		for id := range muted {
+			n, _ := strconv.Atoi(id)
+			ids = append(ids, n)
+		}
+		sort.Ints(ids)
+		for _, id := range ids {
+			fmt.Println(id)
+		}
+		return
+	}
+	for _, id := range rest {
+		if _, err := strconv.Atoi(id); err != nil {
+			fatal("invalid issue number:", id)
+		}
+		if o.undo {
+			delete(muted, id)
+		} else {
+			muted[id] = true
+		}
+	}
+	if err := writeLocal(c.Project, "muted", muted); err != nil {
+		fatal("failed to write muted issues:", err)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+)
+
+// toLF convert CRLF and CR newlines into LF. Text is kept with LF inside
+// goissue, and converted with toNative only when written for users.
+func toLF(s string) string {
+	s = strings.Replace(s, "\r\n", "\n", -1)
+	return strings.Replace(s, "\r", "\n", -1)
+}
+
+// toNative convert LF newlines into the ones of the platform, which is
+// CRLF on windows.
+func toNative(s string) string {
+	if runtime.GOOS == "windows" {
+		return strings.Replace(toLF(s), "\n", "\r\n", -1)
+	}
+	return s
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// outboxDir return directory that keep submissions failed by transient
+// errors, to be retried by outbox push.
+func outboxDir() string {
+	return filepath.Join(configDir(), "outbox")
+}
+
+// outboxItem is a submission in the outbox.
+type outboxItem struct {
+	Kind        string    `json:"kind"` // "issue" or "comment"
+	Project     string    `json:"project"`
+	Issue       *NewIssue `json:"issue,omitempty"`
+	Provisional string    `json:"provisional,omitempty"` // ID of issue created offline
+	Id          string    `json:"id,omitempty"`          // issue to comment on
+	From        string    `json:"from,omitempty"`
+	Body        string    `json:"body,omitempty"`
+	Created     string    `json:"created"`
+}
+
+// provisionalPrefix is the prefix of IDs given to issues created offline,
+// like "new-1". They can be referred from comments and other issues in the
+// outbox, and are rewritten to the real IDs when submitted.
+const provisionalPrefix = "new-"
+
+// provisionalFile return file that keep the last number of provisional
+// IDs, so that an ID is never given twice even after its issue is posted.
+func provisionalFile() string {
+	return filepath.Join(outboxDir(), "provisional")
+}
+
+// nextProvisional return provisional ID for a new issue created offline.
+func nextProvisional() (string, error) {
+	unlock, err := lockFile(provisionalFile())
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	n := 0
+	if b, err := ioutil.ReadFile(provisionalFile()); err == nil {
+		n, _ = strconv.Atoi(strings.TrimSpace(string(b)))
+	}
+	// items may be queued before the number is kept.
+	for _, file := range outboxFiles() {
+		item, err := readOutboxItem(file)
+		if err != nil || !strings.HasPrefix(item.Provisional, provisionalPrefix) {
+			continue
+		}
+		if m, err := strconv.Atoi(item.Provisional[len(provisionalPrefix):]); err == nil && m > n {
+			n = m
+		}
+	}
+	n++
+	if err := replaceFile(provisionalFile(), []byte(strconv.Itoa(n)+"\n"), 0600); err != nil {
+		return "", err
+	}
+	return provisionalPrefix + strconv.Itoa(n), nil
+}
+
+// replaceId return v, decoded JSON, with strings and keys equal to the
+// provisional ID replaced with the real ID.
+func replaceId(v interface{}, provisional, id string) interface{} {
+	switch v := v.(type) {
+	case string:
+		if v == provisional {
+			return id
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = replaceId(v[i], provisional, id)
+		}
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for k, x := range v {
+			if k == provisional {
+				k = id
+			}
+			m[k] = replaceId(x, provisional, id)
+		}
+		return m
+	}
+	return v
+}
+
+// rewriteLocal replace the provisional ID with the real ID in local state
+// of the project, like muted issues and time spent, and move the comment
+// draft of the issue to the real ID.
+func rewriteLocal(config *Config, project, provisional, id string, re *regexp.Regexp) {
+	files, _ := filepath.Glob(localFile(project, "*"))
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil || !bytes.Contains(b, []byte(strconv.Quote(provisional))) {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(b, &v); err != nil {
+			warnf("failed to rewrite %s: %v", file, err)
+			continue
+		}
+		if b, err = json.Marshal(replaceId(v, provisional, id)); err == nil {
+			err = replaceFile(file, b, 0600)
+		}
+		if err != nil {
+			warnf("failed to rewrite %s: %v", file, err)
+		}
+	}
+
+	drafts, _ := filepath.Glob(filepath.Join(draftDir(config), project+"-*.txt"))
+	for _, file := range drafts {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		if r := re.ReplaceAll(b, []byte(id)); !bytes.Equal(r, b) {
+			if err := replaceFile(file, r, 0600); err != nil {
+				warnf("failed to rewrite %s: %v", file, err)
+			}
+		}
+	}
+	draft := draftFile(config, project, provisional)
+	if _, err := os.Stat(draft); err == nil {
+		if err := os.Rename(draft, draftFile(config, project, id)); err != nil {
+			warnf("failed to move draft of %s: %v", provisional, err)
+		}
+	}
+}
+
+// rewriteRefs replace the provisional ID with the real ID in items in the
+// outbox, and in local state and drafts.
+func rewriteRefs(config *Config, project, provisional, id string) {
+	re := regexp.MustCompile(`\b` + regexp.QuoteMeta(provisional) + `\b`)
+	rewriteLocal(config, project, provisional, id, re)
+	for _, file := range outboxFiles() {
+		item, err := readOutboxItem(file)
+		if err != nil || item.Project != project {
+			continue
+		}
+		changed := false
+		replace := func(s *string) {
+			if r := re.ReplaceAllString(*s, id); r != *s {
+				*s, changed = r, true
+			}
+		}
+		if item.Id == provisional {
+			item.Id, changed = id, true
+		}
+		replace(&item.Body)
+		if item.Issue != nil {
+			replace(&item.Issue.Title)
+			replace(&item.Issue.Body)
+		}
+		if !changed {
+			continue
+		}
+		b, err := json.MarshalIndent(item, "", "  ")
+		if err == nil {
+			err = replaceFile(file, b, 0600)
+		}
+		if err != nil {
+			warnf("failed to rewrite %s: %v", file, err)
+		}
+	}
+}
+
+// transient return true if the request may succeed when retried later.
+func transient(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+	return exitCode(err) == exitNetwork
+}
+
+// queue save the item in the outbox, and return the file.
+func (item *outboxItem) queue() (string, error) {
+	item.Created = time.Now().UTC().Format(time.RFC3339)
+	b, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(outboxDir(), 0700); err != nil {
+		return "", err
+	}
+	// names start with the time to be sorted in order of submission.
+	f, err := ioutil.TempFile(outboxDir(), fmt.Sprintf("%d-%s-%s-*.json", time.Now().UnixNano(), item.Project, item.Kind))
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(b)
+	f.Close()
+	return f.Name(), err
+}
+
+// queueOnFailure put the item into the outbox if err is transient, and
+// return the message to tell where it is.
+func queueOnFailure(item *outboxItem, err error) string {
+	if !transient(err) {
+		return ""
+	}
+	file, qerr := item.queue()
+	if qerr != nil {
+		warnf("failed to save to outbox: %v", qerr)
+		return ""
+	}
+	return " (saved in outbox as " + filepath.Base(file) + "; retry with: goissue outbox push)"
+}
+
+// send submit the item.
+func (item *outboxItem) send(ctx context.Context, config *Config, c *Client) (string, error) {
+	pc := *c
+	pc.Project = item.Project
+	switch item.Kind {
+	case "issue":
+		entry, err := pc.CreateIssue(ctx, item.Issue)
+		if err != nil {
+			return "", err
+		}
+		if item.Provisional != "" {
+			rewriteRefs(config, item.Project, item.Provisional, issueId(entry))
+			return "issue " + issueId(entry) + " created from " + item.Provisional, nil
+		}
+		return "issue " + issueId(entry) + " created", nil
+	case "comment":
+		if err := pc.PostComment(ctx, item.Id, item.From, "", item.Body, nil); err != nil {
+			return "", err
+		}
+		return "comment posted to issue " + item.Id, nil
+	}
+	return "", errors.New("unknown kind: " + item.Kind)
+}
+
+// outboxFiles return files in the outbox, older first.
+func outboxFiles() []string {
+	files, _ := filepath.Glob(filepath.Join(outboxDir(), "*.json"))
+	sort.Strings(files)
+	return files
+}
+
+// readOutboxItem read the item in the file.
+func readOutboxItem(file string) (*outboxItem, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var item outboxItem
+	if err := json.Unmarshal(b, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// describe return one line description of the item.
+func (item *outboxItem) describe() string {
+	if item.Kind == "issue" && item.Provisional != "" {
+		return item.Project + ": new issue " + item.Provisional + ": " + item.Issue.Title
+	} else if item.Kind == "issue" {
+		return item.Project + ": new issue: " + item.Issue.Title
+	}
+	first := strings.SplitN(strings.TrimSpace(item.Body), "\n", 2)[0]
+	return item.Project + ": comment on " + item.Id + ": " + first
+}
+
+// pushOutbox retry submissions in the outbox, and return the number of
+// failures. Comments on issues whose provisional IDs are not resolved yet,
+// because the issues are not posted, are kept for later. The outbox is
+// locked while pushing, so that processes run at once don't post an item
+// twice.
+func pushOutbox(ctx context.Context, config *Config, c *Client) int {
+	unlock, err := lockFile(outboxDir())
+	if err != nil {
+		warnf("failed to lock outbox: %v", err)
+		return len(outboxFiles())
+	}
+	defer unlock()
+	failed := 0
+	for _, file := range outboxFiles() {
+		item, err := readOutboxItem(file)
+		if err != nil {
+			warnf("failed to read %s: %v", file, err)
+			failed++
+			continue
+		}
+		if item.Kind == "comment" && strings.HasPrefix(item.Id, provisionalPrefix) {
+			warnf("kept %s: issue %s is not posted yet", filepath.Base(file), item.Id)
+			failed++
+			continue
+		}
+		msg, err := item.send(ctx, config, c)
+		if err != nil {
+			warnf("failed to send %s: %v", filepath.Base(file), err)
+			failed++
+			continue
+		}
+		os.Remove(file)
+		infof("%s", msg)
+	}
+	return failed
+}
+
+// runOutbox list, push or drop submissions in the outbox.
+func runOutbox(ctx context.Context, config *Config, c *Client, args []string) {
+	fs := newFlagSet("outbox")
+	args = parseFlags(fs, args)
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		for _, file := range outboxFiles() {
+			item, err := readOutboxItem(file)
+			if err != nil {
+				warnf("failed to read %s: %v", file, err)
+				continue
+			}
+			fmt.Println(filepath.Base(file) + "\t" + item.describe())
+		}
+	case args[0] == "push" && len(args) == 1:
+		if pushOutbox(ctx, config, c) > 0 {
+			exit(exitError)
+		}
+	case args[0] == "drop" && len(args) == 2:
+		if err := os.Remove(filepath.Join(outboxDir(), filepath.Base(args[1]))); err != nil {
+			fatal("failed to drop:", err)
+		}
+	default:
+		fmt.Fprint(os.Stderr, "Usage: goissue outbox [list|push|drop NAME]\n")
+		exit(exitUsage)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestNextProvisional(t *testing.T) {
+	home, err := ioutil.TempDir("", "goissue-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+
+	item := &outboxItem{Kind: "issue", Project: "go", Issue: &NewIssue{Title: "a"}, Provisional: "new-3"}
+	file, err := item.queue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := nextProvisional(); err != nil || id != "new-4" {
+		t.Fatalf("nextProvisional() = %q, %v; want new-4", id, err)
+	}
+	// the issue is posted; its ID must not be given again.
+	os.Remove(file)
+	if id, err := nextProvisional(); err != nil || id != "new-5" {
+		t.Fatalf("nextProvisional() = %q, %v; want new-5", id, err)
+	}
+}
+
+func TestRewriteRefs(t *testing.T) {
+	home, err := ioutil.TempDir("", "goissue-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+
+	config := defaultConfig()
+	if err := writeLocal("go", "muted", map[string]bool{"new-1": true, "new-10": true}); err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(draftDir(config), 0700)
+	if err := ioutil.WriteFile(draftFile(config, "go", "new-1"), []byte("dup of new-1, not new-10"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	item := &outboxItem{Kind: "comment", Project: "go", Id: "new-1", Body: "see new-1"}
+	file, err := item.queue()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rewriteRefs(config, "go", "new-1", "123")
+	muted := loadMuted("go")
+	if !muted["123"] || muted["new-1"] || !muted["new-10"] {
+		t.Errorf("muted issues are not rewritten: %v", muted)
+	}
+	b, err := ioutil.ReadFile(draftFile(config, "go", "123"))
+	if err != nil || string(b) != "dup of 123, not new-10" {
+		t.Errorf("draft is not moved and rewritten: %q, %v", b, err)
+	}
+	item, err = readOutboxItem(file)
+	if err != nil || item.Id != "123" || item.Body != "see 123" {
+		t.Errorf("outbox item is not rewritten: %+v, %v", item, err)
+	}
+}
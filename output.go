@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Options is how issues are printed and rendered, given by flags of
+// goissue and settings. It goes with the Client, so copies of the client
+// for other projects print the same way.
+type Options struct {
+	// Porcelain is true with -porcelain. Issues are printed in stable tab
+	// separated format for programs.
+	Porcelain bool
+
+	// IdsOnly is true with -ids; only numbers of issues are printed in
+	// lists.
+	IdsOnly bool
+
+	// Print0 is true with -0 or -print0; lines of lists end with NUL
+	// instead of newline, for xargs -0.
+	Print0 bool
+
+	// Plain is true with -plain, "plain" in settings or TERM=dumb. Output
+	// is strictly linear text for screen readers and dumb terminals: no
+	// indentation of rendered html, no progress counters and no symbols
+	// that mean something only by their position.
+	Plain bool
+
+	// RawBytes is true with -raw-bytes; contents of issues are rendered as
+	// the tracker returned them, without converting encodings.
+	RawBytes bool
+
+	// ShowSpam is true with -spam; issues matched to the spam filter are
+	// shown.
+	ShowSpam bool
+}
+
+// progressf print progress of a long running command to stderr, unless in
+// plain mode of c or notes are suppressed.
+func progressf(c *Client, format string, args ...interface{}) {
+	if c.Options.Plain {
+		return
+	}
+	notef(format, args...)
+}
+
+// notef print a decorative message like progress to stderr, unless the log
+// level is below info, as it is with -porcelain.
+func notef(format string, args ...interface{}) {
+	if verbosity < levelInfo {
+		return
+	}
+	fmt.Fprintf(os.Stderr, tr(format), args...)
+}
+
+// porcelainField return s usable as a field of porcelain output.
+func porcelainField(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// printIssue print the issue in a list. line is the text for humans. In
+// porcelain mode, fields are printed separated with tabs instead: project,
+// number, state, status, owner, updated time and title.
+func printIssue(c *Client, entry Entry, line string) {
+	end := "\n"
+	if c.Options.Print0 {
+		end = "\x00"
+	}
+	fmt.Print(formatIssue(c, entry, line) + end)
+}
+
+// formatIssue return the line of the issue printed by printIssue.
+func formatIssue(c *Client, entry Entry, line string) string {
+	if c.Options.IdsOnly {
+		return issueId(entry)
+	}
+	if !c.Options.Porcelain {
+		return line
+	}
+	fields := []string{
+		c.Project,
+		issueId(entry),
+		entryState(entry),
+		strings.Join(entry.IssuesStatus, ","),
+		ownerName(entry),
+		entry.Updated,
+		entry.Title,
+	}
+	for i, f := range fields {
+		fields[i] = porcelainField(f)
+	}
+	return strings.Join(fields, "\t")
+}
+
+// stripControls return s without terminal escape sequences and control
+// characters other than newline and tab, so that texts from the tracker
+// can't control the terminal.
+func stripControls(s string) string {
+	clean := true
+	for _, r := range s {
+		if isControl(r) {
+			clean = false
+			break
+		}
+	}
+	if clean {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '\x1b' || r == '\u009b':
+			n = escapeLen(s[i:])
+		case !isControl(r):
+			b.WriteRune(r)
+		}
+		i += n
+	}
+	return b.String()
+}
+
+// sanitize strip control characters from the fields of the entry that are
+// printed as they are: titles, names, labels and so on. Content is html and
+// cleaned when rendered.
+func (e *Entry) sanitize() {
+	e.Title = stripControls(e.Title)
+	e.IssuesSummary = stripControls(e.IssuesSummary)
+	for i := range e.Author {
+		e.Author[i].Name = stripControls(e.Author[i].Name)
+		e.Author[i].Email = stripControls(e.Author[i].Email)
+	}
+	for i := range e.IssuesOwner {
+		e.IssuesOwner[i].IssuesUsername = stripControls(e.IssuesOwner[i].IssuesUsername)
+	}
+	for i := range e.IssuesCc {
+		e.IssuesCc[i].IssuesUsername = stripControls(e.IssuesCc[i].IssuesUsername)
+	}
+	stripAll(e.IssuesLabel)
+	stripAll(e.IssuesState)
+	stripAll(e.IssuesStatus)
+	if u := e.IssuesUpdates; u != nil {
+		u.IssuesSummary = stripControls(u.IssuesSummary)
+		u.IssuesStatus = stripControls(u.IssuesStatus)
+		u.IssuesOwnerUpdate = stripControls(u.IssuesOwnerUpdate)
+		stripAll(u.IssuesLabel)
+		stripAll(u.IssuesCcUpdate)
+	}
+}
+
+// stripAll strip control characters from the strings in place.
+func stripAll(list []string) {
+	for i, s := range list {
+		list[i] = stripControls(s)
+	}
+}
+
+// isControl return true if r is a control character which is stripped.
+func isControl(r rune) bool {
+	return (r < 0x20 && r != '\n' && r != '\t') || (r >= 0x7f && r < 0xa0)
+}
+
+// escapeLen return the length of the escape sequence at the start of s:
+// CSI like "\x1b[31m", string sequences like OSC ended with BEL or ST, or an
+// escape and the next character.
+func escapeLen(s string) int {
+	i := 0
+	switch {
+	case strings.HasPrefix(s, "\u009b"):
+		i = len("\u009b")
+	case len(s) < 2:
+		return len(s)
+	case s[1] == '[':
+		i = 2
+	case strings.IndexByte("]P_^X", s[1]) >= 0:
+		for j := 2; j < len(s); j++ {
+			if s[j] == '\a' {
+				return j + 1
+			}
+			if s[j] == '\x1b' && j+1 < len(s) && s[j+1] == '\\' {
+				return j + 2
+			}
+		}
+		return len(s)
+	default:
+		_, n := utf8.DecodeRuneInString(s[1:])
+		return 1 + n
+	}
+	// parameters and intermediates of CSI until the final byte.
+	for ; i < len(s) && s[i] >= 0x20 && s[i] <= 0x7e; i++ {
+		if s[i] >= 0x40 {
+			return i + 1
+		}
+	}
+	return i
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// isTerminal return true if the file is a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// interactive return true if the user can pick an issue from candidates,
+// which isn't for output of c to programs.
+func interactive(c *Client) bool {
+	o := c.Options
+	return !o.Porcelain && !o.IdsOnly && !o.Print0 && isTerminal(os.Stdin) && isTerminal(os.Stdout)
+}
+
+// previewLine return short description of the entry shown in the picker.
+func previewLine(c *Client, entry Entry) string {
+	line := strings.Join(entry.IssuesStatus, "")
+	if len(entry.IssuesLabel) > 0 {
+		line += " [" + strings.Join(entry.IssuesLabel, " ") + "]"
+	}
+	text, err := renderEntry(c, entry)
+	if err == nil {
+		for _, s := range strings.Split(text, "\n") {
+			if s = strings.TrimSpace(s); s != "" {
+				if len(s) > 60 {
+					s = s[:60] + "..."
+				}
+				line += " " + s
+				break
+			}
+		}
+	}
+	return strings.TrimSpace(line)
+}
+
+// pick ask the user to choose one of entries, and return it. false is
+// returned if nothing is chosen.
+func pick(c *Client, entries []Entry) (Entry, bool) {
+	for i, entry := range entries {
+		fmt.Printf("%2d) %s: %s\n", i+1, issueId(entry), entry.Title)
+		if p := previewLine(c, entry); p != "" {
+			fmt.Printf("    %s\n", p)
+		}
+	}
+	for {
+		fmt.Fprintf(os.Stderr, "pick 1-%d (empty to quit): ", len(entries))
+		line, err := answers.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return Entry{}, false
+		}
+		n, perr := strconv.Atoi(line)
+		if perr == nil && 1 <= n && n <= len(entries) {
+			return entries[n-1], true
+		}
+		if err != nil {
+			return Entry{}, false
+		}
+	}
+}
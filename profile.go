@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// profiler collect elapsed time of requests and phases when -profile is
+// given. Methods of nil profiler do nothing.
+type profiler struct {
+	mu       sync.Mutex
+	requests []string
+	phases   map[string]time.Duration
+	order    []string
+}
+
+// prof is set by main when -profile is given.
+var prof *profiler
+
+// add record elapsed time d of the phase. detail is the URL for fetch
+// phase, and each fetch is also recorded as a request.
+func (p *profiler) add(phase, detail string, d time.Duration) {
+	if p == nil || phase == "cache" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.phases == nil {
+		p.phases = map[string]time.Duration{}
+	}
+	if _, ok := p.phases[phase]; !ok {
+		p.order = append(p.order, phase)
+	}
+	p.phases[phase] += d
+	if detail != "" {
+		p.requests = append(p.requests, fmt.Sprintf("%10v  %s %s", d, phase, detail))
+	}
+}
+
+// start begin measuring the phase. Call returned function to end it.
+func (p *profiler) start(phase string) func() {
+	t := time.Now()
+	return func() {
+		p.add(phase, "", time.Since(t))
+	}
+}
+
+// report write collected timings to w.
+func (p *profiler) report(w io.Writer) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintln(w, "requests:")
+	for _, r := range p.requests {
+		fmt.Fprintln(w, "  "+r)
+	}
+	fmt.Fprintln(w, "phases:")
+	for _, phase := range p.order {
+		fmt.Fprintf(w, "  %10v  %s\n", p.phases[phase], phase)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"net/url"
+)
+
+// multiProject is commands which support -project all.
+var multiProject = map[string]bool{
+	"list": true,
+	"sync": true,
+}
+
+// projectList return projects given by "projects" in config as comma
+// separated list, or the project.
+func projectList(config *Config) []string {
+	if len(config.Projects) > 0 {
+		return config.Projects
+	}
+	return []string{config.Project}
+}
+
+// projectClients return clients for each project when the project of c is
+// "all", or c itself. If all is true, projects in config are returned
+// whatever the project of c is.
+func projectClients(config *Config, c *Client, all bool) []*Client {
+	if c.Project != "all" && !all {
+		return []*Client{c}
+	}
+	var clients []*Client
+	for _, p := range projectList(config) {
+		if p == "all" {
+			fatal("invalid projects in your settings.json: all")
+		}
+		pc := *c
+		pc.Project = p
+		clients = append(clients, &pc)
+	}
+	return clients
+}
+
+// projectPrefix return "project/" to put before issue numbers when issues
+// of n projects are shown together.
+func projectPrefix(c *Client, n int) string {
+	if n < 2 {
+		return ""
+	}
+	return c.Project + "/"
+}
+
+// searchProjects search issues of all projects in config. Spam of each
+// project is hidden, unless -spam.
+func searchProjects(ctx context.Context, config *Config, c *Client, expr, scope string, page url.Values) {
+	q, err := buildQuery(expr, scope)
+	if err != nil {
+		fatal("failed to parse search words:", err)
+	}
+	page.Set("q", q)
+	clients := projectClients(config, c, false)
+	found := 0
+	for _, pc := range clients {
+		feed, err := pc.Feed(ctx, pc.IssuesURL()+"?"+page.Encode())
+		if err != nil {
+			fatal("failed to get issues:", err)
+		}
+		entries := dropSpam(pc, feed.Entry)
+		for _, entry := range entries {
+			printIssue(pc, entry, projectPrefix(pc, len(clients))+issueId(entry)+": "+entry.Title)
+		}
+		found += len(entries)
+	}
+	if found == 0 {
+		exit(exitNotFound)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// relabelFlags is flags of relabel.
+type relabelFlags struct {
+	from   string
+	to     string
+	wait   time.Duration
+	dryRun bool
+	filter filter
+}
+
+// register add flags of relabel to the flag set.
+func (o *relabelFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.from, "from", "", "label to remove")
+	fs.StringVar(&o.to, "to", "", "label to add instead")
+	fs.DurationVar(&o.wait, "wait", 2*time.Second, "wait between updates")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "print issues to update without updating them")
+	o.filter.register(fs)
+}
+
+// relabelIssues replace the label of matched issues with another one. Issues
+// are updated one by one with wait between them, not to hit the rate limit
+// of the tracker.
+func relabelIssues(ctx context.Context, config *Config, c *Client, args []string) {
+	var o relabelFlags
+	fs := newFlagSet("relabel")
+	o.register(fs)
+	parseFlags(fs, args)
+	if o.from == "" || o.to == "" {
+		fmt.Fprint(os.Stderr, "Usage: goissue relabel -from LABEL -to LABEL [-wait 2s] [-dry-run] [filters]\n")
+		fs.PrintDefaults()
+		exit(exitUsage)
+	}
+	if err := validateLabels(config, c.Project, []string{o.to}); err != nil {
+		fatal(err)
+	}
+
+	o.filter.label = o.from
+	entries, err := c.Entries(ctx, o.filter.values("all"))
+	if err != nil {
+		fatal("failed to get issues:", err)
+	}
+	entries = o.filter.narrow(entries)
+	if o.dryRun {
+		for _, entry := range entries {
+			fmt.Printf("%s: %s (%s -> %s)\n", issueId(entry), entry.Title, o.from, o.to)
+		}
+		notef("%d issues would be relabeled\n", len(entries))
+		return
+	}
+
+	u := &Updates{Labels: []string{"-" + o.from, o.to}}
+	failed := 0
+	for i, entry := range entries {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				fatal("canceled:", ctx.Err())
+			case <-time.After(o.wait):
+			}
+		}
+		id := issueId(entry)
+		err := updateIssue(ctx, c, config.Email, entry, "Relabeling "+o.from+" to "+o.to+".", u)
+		if err != nil {
+			warnf("failed to update issue %s: %v", id, err)
+			failed++
+			continue
+		}
+		progressf(c, "[%d/%d] relabeled %s\n", i+1, len(entries), id)
+	}
+	notef("%d relabeled, %d failed\n", len(entries)-failed, failed)
+	if failed > 0 {
+		exit(1)
+	}
+}
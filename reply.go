@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// commentRef match references to comments in text, like "Comment 3",
+// "comment #3" or "#c3" at the end of links to comments.
+var commentRef = regexp.MustCompile(`(?i)(?:\bcomment\s*#?|#c)(\d+)\b`)
+
+// minQuote is the length of quoted text that is looked up in earlier
+// comments; shorter quotes like "> yes" match too many.
+const minQuote = 8
+
+// reply is a comment in the tree of replies.
+type reply struct {
+	entry   Entry
+	n       int    // number of the comment
+	own     string // text not quoted, with spaces collapsed
+	parent  *reply
+	replies []*reply
+}
+
+// squeeze collapse spaces in s into one.
+func squeeze(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// replyTo return the earlier comment that the text replies to: the one
+// referred as "Comment N", or else the latest one written the quoted text.
+// nil is returned for the reply to the issue.
+func replyTo(text string, earlier []*reply) *reply {
+	for _, m := range commentRef.FindAllStringSubmatch(text, -1) {
+		n, _ := strconv.Atoi(m[1])
+		for _, r := range earlier {
+			if r.n == n {
+				return r
+			}
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, ">") {
+			continue
+		}
+		quote := squeeze(strings.TrimLeft(line, "> "))
+		if len(quote) < minQuote {
+			continue
+		}
+		for i := len(earlier) - 1; i >= 0; i-- {
+			if strings.Contains(earlier[i].own, quote) {
+				return earlier[i]
+			}
+		}
+	}
+	return nil
+}
+
+// threadReplies return trees of replies of the comments in order of
+// posting.
+func threadReplies(c *Client, entries []Entry) ([]*reply, error) {
+	var all, roots []*reply
+	for _, entry := range entries {
+		text, err := renderEntry(c, entry)
+		if err != nil {
+			return nil, err
+		}
+		r := &reply{entry: entry, parent: replyTo(text, all)}
+		r.n, _ = strconv.Atoi(issueId(entry))
+		var own []string
+		for _, line := range strings.Split(text, "\n") {
+			if !strings.HasPrefix(strings.TrimSpace(line), ">") {
+				own = append(own, line)
+			}
+		}
+		r.own = squeeze(strings.Join(own, " "))
+		if r.parent != nil {
+			r.parent.replies = append(r.parent.replies, r)
+		} else {
+			roots = append(roots, r)
+		}
+		all = append(all, r)
+	}
+	return roots, nil
+}
+
+// writeThreaded write comments in the feed as trees, replies indented under
+// the comment they reply to. Without indentation for -plain, what a
+// comment replies to is told after its title.
+func writeThreaded(w io.Writer, c *Client, feed Feed) error {
+	roots, err := threadReplies(c, feed.Entry)
+	if err != nil {
+		return err
+	}
+	var write func(rs []*reply, depth int) error
+	write = func(rs []*reply, depth int) error {
+		for _, r := range rs {
+			entry := r.entry
+			if c.Options.Plain && r.parent != nil {
+				entry.Title += ", reply to comment " + strconv.Itoa(r.parent.n)
+			}
+			var b bytes.Buffer
+			if err := writeIssue(&b, c, entry); err != nil {
+				return err
+			}
+			indent := ""
+			if !c.Options.Plain {
+				indent = strings.Repeat("    ", depth)
+			}
+			for _, line := range strings.SplitAfter(b.String(), "\n") {
+				if line != "" {
+					io.WriteString(w, indent+line)
+				}
+			}
+			if err := write(r.replies, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return write(roots, 0)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// route is a rule in "routes" of config. Issues mentioning any of Match,
+// keywords or paths like "net/http", get Labels, Owner and Cc.
+type route struct {
+	Match  []string `json:"match"`
+	Labels []string `json:"labels"`
+	Owner  string   `json:"owner"`
+	Cc     []string `json:"cc"`
+}
+
+// loadRoutes return "routes" in config.
+func loadRoutes(config *Config) []route {
+	var routes []route
+	if config.Routes != nil {
+		if err := json.Unmarshal(config.Routes, &routes); err != nil {
+			fatal("invalid routes in your settings.json:", err)
+		}
+	}
+	return routes
+}
+
+// wordChar return true if r is a part of words, which can't be next to a
+// keyword matched.
+func wordChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// mentions return true if text has the keyword as a word, ignoring case.
+// "net/http" is found in "net/http: crash" and "net/http/httputil", but
+// "http" is not in "https".
+func mentions(text, keyword string) bool {
+	text, keyword = strings.ToLower(text), strings.ToLower(keyword)
+	if keyword == "" {
+		return false
+	}
+	for i := 0; ; {
+		j := strings.Index(text[i:], keyword)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(keyword)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if (start == 0 || !wordChar(before)) && (end == len(text) || !wordChar(after)) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+// routeUpdates return updates by routes matched to the text, and keywords
+// which matched. The owner is of the first route having one.
+func routeUpdates(routes []route, text string) (*Updates, []string) {
+	u := &Updates{}
+	var keywords []string
+	for _, r := range routes {
+		for _, keyword := range r.Match {
+			if !mentions(text, keyword) {
+				continue
+			}
+			keywords = append(keywords, keyword)
+			for _, label := range r.Labels {
+				if !contains(u.Labels, label) {
+					u.Labels = append(u.Labels, label)
+				}
+			}
+			for _, cc := range r.Cc {
+				if !contains(u.Cc, cc) {
+					u.Cc = append(u.Cc, cc)
+				}
+			}
+			if u.Owner == "" {
+				u.Owner = r.Owner
+			}
+			break
+		}
+	}
+	return u, keywords
+}
+
+// suggestRoutes tell labels and the owner from routes matched to the new
+// issue, and add them if the user want.
+func suggestRoutes(config *Config, c *Client, issue *NewIssue) {
+	u, keywords := routeUpdates(loadRoutes(config), issue.Title+"\n"+issue.Body)
+	var labels []string
+	for _, label := range u.Labels {
+		if !contains(issue.Labels, label) {
+			labels = append(labels, label)
+		}
+	}
+	owner := ""
+	if issue.Owner == "" {
+		owner = u.Owner
+	}
+	if (len(labels) == 0 && owner == "") || !interactive(c) {
+		return
+	}
+	suggested := strings.Join(labels, ", ")
+	if owner != "" {
+		suggested += " (owner: " + owner + ")"
+	}
+	notef("the issue mentions %s; suggested: %s\n", strings.Join(keywords, ", "), suggested)
+	if confirm("add them?") {
+		issue.Labels = append(issue.Labels, labels...)
+		if owner != "" {
+			issue.Owner = owner
+		}
+	}
+}
+
+// triageFlags is flags of triage.
+type triageFlags struct {
+	auto   bool
+	dryRun bool
+}
+
+// register add flags of triage to the flag set.
+func (o *triageFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.auto, "auto", false, "apply the matched rules without confirmation")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "print the updates without applying them")
+}
+
+// triageIssue apply routes matched to the issue: add labels, set the owner
+// if it has none, and cc people. Without -auto, the updates are confirmed.
+func triageIssue(ctx context.Context, config *Config, c *Client, args []string) {
+	var o triageFlags
+	fs := newFlagSet("triage")
+	o.register(fs)
+	rest := parseFlags(fs, args)
+	if len(rest) != 1 {
+		fmt.Fprint(os.Stderr, "Usage: goissue triage [-auto] [-dry-run] ID\n")
+		fs.PrintDefaults()
+		exit(exitUsage)
+	}
+
+	id := rest[0]
+	entry, err := c.Entry(ctx, id)
+	if err != nil {
+		fatal("failed to get issue:", err)
+	}
+	text, err := renderEntry(c, entry)
+	if err != nil {
+		fatal("failed to parse xml:", err)
+	}
+	routed, keywords := routeUpdates(loadRoutes(config), entry.Title+"\n"+text)
+	// what the issue has already is not updated.
+	u := &Updates{}
+	for _, label := range routed.Labels {
+		if !hasLabel(entry, label) {
+			u.Labels = append(u.Labels, label)
+		}
+	}
+	if ownerName(entry) == "" {
+		u.Owner = routed.Owner
+	}
+	for _, cc := range routed.Cc {
+		on := cc == ownerName(entry) || cc == u.Owner
+		for _, other := range entry.IssuesCc {
+			on = on || other.IssuesUsername == cc
+		}
+		if !on {
+			u.Cc = append(u.Cc, cc)
+		}
+	}
+	if len(u.Labels) == 0 && u.Owner == "" && len(u.Cc) == 0 {
+		notef("no routes to apply to issue %s\n", id)
+		return
+	}
+	fmt.Printf("matched: %s\n", strings.Join(keywords, ", "))
+	if len(u.Labels) > 0 {
+		fmt.Printf("labels: %s\n", strings.Join(u.Labels, " "))
+	}
+	if u.Owner != "" {
+		fmt.Printf("owner: %s\n", u.Owner)
+	}
+	if len(u.Cc) > 0 {
+		fmt.Printf("cc: %s\n", strings.Join(u.Cc, ", "))
+	}
+	if o.dryRun {
+		return
+	}
+	if err := validateLabels(config, c.Project, u.Labels); err != nil {
+		fatal(err)
+	}
+	if !o.auto && !confirm("apply these updates?") {
+		fatal("canceled")
+	}
+	body := "Routed by rules for " + strings.Join(keywords, ", ") + "."
+	if err := updateIssue(ctx, c, config.Email, entry, body, u); err != nil {
+		fatal("failed to triage issue:", err)
+	}
+	infof("issue %s triaged", id)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMentions(t *testing.T) {
+	for _, tt := range []struct {
+		text, keyword string
+		want          bool
+	}{
+		{"net/http: crash in Transport", "net/http", true},
+		{"see net/http/httputil", "net/http", true},
+		{"NET/HTTP panics", "net/http", true},
+		{"https is slow", "http", false},
+		{"fmthttp", "http", false},
+		{"a http b", "http", true},
+		{"anything", "", false},
+	} {
+		if got := mentions(tt.text, tt.keyword); got != tt.want {
+			t.Errorf("mentions(%q, %q) = %v, want %v", tt.text, tt.keyword, got, tt.want)
+		}
+	}
+}
+
+func TestRouteUpdates(t *testing.T) {
+	routes := []route{
+		{Match: []string{"net/http"}, Labels: []string{"Pkg-net"}, Cc: []string{"bradfitz"}},
+		{Match: []string{"windows"}, Labels: []string{"OS-Windows"}, Owner: "alex", Cc: []string{"bradfitz"}},
+		{Match: []string{"arm"}, Labels: []string{"Arch-ARM"}, Owner: "dave"},
+	}
+	u, keywords := routeUpdates(routes, "net/http: Transport leaks on Windows")
+	want := &Updates{Labels: []string{"Pkg-net", "OS-Windows"}, Owner: "alex", Cc: []string{"bradfitz"}}
+	if !reflect.DeepEqual(u, want) {
+		t.Errorf("got %+v, want %+v", u, want)
+	}
+	if !reflect.DeepEqual(keywords, []string{"net/http", "windows"}) {
+		t.Errorf("keywords = %q", keywords)
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// issueJSON is an issue in JSON given to commands and editor plugins.
+type issueJSON struct {
+	Project string   `json:"project"`
+	Id      string   `json:"id"`
+	Title   string   `json:"title"`
+	State   string   `json:"state"`
+	Status  string   `json:"status"`
+	Owner   string   `json:"owner"`
+	Labels  []string `json:"labels"`
+	Updated string   `json:"updated"`
+	URL     string   `json:"url"`
+}
+
+// commentJSON is a comment in JSON.
+type commentJSON struct {
+	Author    string `json:"author"`
+	Published string `json:"published"`
+	Text      string `json:"text"`
+}
+
+// issueData return the issue in JSON.
+func issueData(c *Client, entry Entry) issueJSON {
+	issue := issueJSON{
+		Project: c.Project,
+		Id:      issueId(entry),
+		Title:   entry.Title,
+		State:   entryState(entry),
+		Status:  strings.Join(entry.IssuesStatus, ","),
+		Owner:   ownerName(entry),
+		Labels:  entry.IssuesLabel,
+		Updated: entry.Updated,
+		URL:     webLink(entry),
+	}
+	if issue.URL == "" {
+		issue.URL = c.IssueWebURL(issue.Id)
+	}
+	return issue
+}
+
+// commentData return the comment in JSON with the rendered text.
+func commentData(c *Client, entry Entry) (commentJSON, error) {
+	text, err := renderEntry(c, entry)
+	if err != nil {
+		return commentJSON{}, err
+	}
+	return commentJSON{authorName(entry), entry.Published, strings.TrimSpace(text)}, nil
+}
+
+// rpcRequest is a request of JSON-RPC 2.0.
+type rpcRequest struct {
+	Version string           `json:"jsonrpc"`
+	Id      *json.RawMessage `json:"id"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params"`
+}
+
+// rpcError is an error of JSON-RPC 2.0.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// rpcResponse is a response of JSON-RPC 2.0.
+type rpcResponse struct {
+	Version string           `json:"jsonrpc"`
+	Id      *json.RawMessage `json:"id"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *rpcError        `json:"error,omitempty"`
+}
+
+// error codes of JSON-RPC 2.0, and of failed methods.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcNoMethod       = -32601
+	rpcInvalidParams  = -32602
+	rpcFailed         = 1
+)
+
+// rpcParams is parameters of all methods; each method use some of them.
+type rpcParams struct {
+	Id       string   `json:"id"`
+	Query    string   `json:"q"`
+	Scope    string   `json:"scope"`
+	Label    string   `json:"label"`
+	Owner    string   `json:"owner"`
+	Status   string   `json:"status"`
+	Is       string   `json:"is"`
+	Comments bool     `json:"comments"`
+	Title    string   `json:"title"`
+	Body     string   `json:"body"`
+	Labels   []string `json:"labels"`
+	Force    bool     `json:"force"`
+}
+
+// rpcIssue is the result of get: the issue with the text, and comments if
+// asked.
+type rpcIssue struct {
+	issueJSON
+	Text     string        `json:"text"`
+	Comments []commentJSON `json:"comments,omitempty"`
+}
+
+// rpcMethods is methods served by -rpc.
+var rpcMethods = map[string]func(ctx context.Context, config *Config, c *Client, p rpcParams) (interface{}, error){
+	"list":    rpcList,
+	"search":  rpcSearch,
+	"get":     rpcGet,
+	"comment": rpcComment,
+	"create":  rpcCreate,
+}
+
+// serveRPC serve JSON-RPC 2.0 on r and w for editor plugins, until r is
+// closed. Requests and responses are JSON values, one per line.
+// Confirmations can't be asked since r is the channel; texts which may
+// contain secrets are refused unless "force" is given.
+func serveRPC(ctx context.Context, config *Config, c *Client, r io.Reader, w io.Writer) error {
+	dec := json.NewDecoder(r)
+	enc := json.NewEncoder(w)
+	for {
+		var req rpcRequest
+		if err := dec.Decode(&req); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			if _, ok := err.(*json.UnmarshalTypeError); ok {
+				enc.Encode(rpcResponse{Version: "2.0", Error: &rpcError{rpcInvalidRequest, err.Error()}})
+				continue
+			}
+			// the rest of the stream can't be read.
+			enc.Encode(rpcResponse{Version: "2.0", Error: &rpcError{rpcParseError, err.Error()}})
+			return err
+		}
+		res := handleRPC(ctx, config, c, req)
+		if req.Id == nil {
+			// notifications have no responses.
+			continue
+		}
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+	}
+}
+
+// handleRPC call the method of the request.
+func handleRPC(ctx context.Context, config *Config, c *Client, req rpcRequest) rpcResponse {
+	res := rpcResponse{Version: "2.0", Id: req.Id}
+	method, ok := rpcMethods[req.Method]
+	if !ok {
+		res.Error = &rpcError{rpcNoMethod, "unknown method: " + req.Method}
+		return res
+	}
+	var p rpcParams
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			res.Error = &rpcError{rpcInvalidParams, err.Error()}
+			return res
+		}
+	}
+	result, err := method(ctx, config, c, p)
+	if err != nil {
+		res.Error = &rpcError{rpcFailed, err.Error()}
+		return res
+	}
+	res.Result = result
+	return res
+}
+
+// issueList return issues in JSON.
+func issueList(c *Client, entries []Entry) []issueJSON {
+	issues := []issueJSON{}
+	for _, entry := range entries {
+		issues = append(issues, issueData(c, entry))
+	}
+	return issues
+}
+
+// rpcList return open issues matched to q, label, owner, status and is.
+func rpcList(ctx context.Context, config *Config, c *Client, p rpcParams) (interface{}, error) {
+	f := filter{query: p.Query, label: p.Label, owner: p.Owner, status: p.Status, is: p.Is}
+	entries, err := c.Entries(ctx, f.values("open"))
+	if err != nil {
+		return nil, err
+	}
+	return issueList(c, dropSpam(c, entries)), nil
+}
+
+// rpcSearch return issues matched to q in scope, like -s.
+func rpcSearch(ctx context.Context, config *Config, c *Client, p rpcParams) (interface{}, error) {
+	if p.Scope == "" {
+		p.Scope = "all"
+	}
+	q, err := buildQuery(p.Query, p.Scope)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := c.Entries(ctx, url.Values{"q": {q}})
+	if err != nil {
+		return nil, err
+	}
+	return issueList(c, dropSpam(c, entries)), nil
+}
+
+// rpcGet return the issue id with the text, and comments with comments.
+func rpcGet(ctx context.Context, config *Config, c *Client, p rpcParams) (interface{}, error) {
+	if p.Id == "" {
+		return nil, errors.New("no id")
+	}
+	t := thread{}
+	var err error
+	if p.Comments {
+		t, err = fetchThread(ctx, c, p.Id)
+	} else {
+		t.Issue, err = c.Entry(ctx, p.Id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	text, err := renderEntry(c, t.Issue)
+	if err != nil {
+		return nil, err
+	}
+	markSeen(c.Project, t.Issue)
+	issue := rpcIssue{issueJSON: issueData(c, t.Issue), Text: strings.TrimSpace(text)}
+	for _, e := range t.Comments {
+		comment, err := commentData(c, e)
+		if err != nil {
+			return nil, err
+		}
+		issue.Comments = append(issue.Comments, comment)
+	}
+	return issue, nil
+}
+
+// checkRPCText return an error if the text may contain secrets, unless
+// force is given.
+func checkRPCText(config *Config, text string, force bool) error {
+	if found := findSecrets(config, text); len(found) > 0 && !force {
+		return errors.New("the text may contain secrets or private paths: " + strings.Join(found, ", ") + "; give force to send it anyway")
+	}
+	return nil
+}
+
+// rpcComment post body as a comment to the issue id, and return the issue.
+func rpcComment(ctx context.Context, config *Config, c *Client, p rpcParams) (interface{}, error) {
+	if p.Id == "" || strings.TrimSpace(p.Body) == "" {
+		return nil, errors.New("id and body are required")
+	}
+	if !c.LoggedIn() {
+		return nil, errAuthRequired
+	}
+	if err := checkRPCText(config, p.Body, p.Force); err != nil {
+		return nil, err
+	}
+	if err := c.PostComment(ctx, p.Id, config.Email, "", p.Body, nil); err != nil {
+		return nil, err
+	}
+	entry, err := c.Entry(ctx, p.Id)
+	if err != nil {
+		return nil, err
+	}
+	return issueData(c, entry), nil
+}
+
+// rpcCreate create the issue of title, body, status, owner and labels, and
+// return it.
+func rpcCreate(ctx context.Context, config *Config, c *Client, p rpcParams) (interface{}, error) {
+	if strings.TrimSpace(p.Title) == "" {
+		return nil, errors.New("title is required")
+	}
+	if !c.LoggedIn() {
+		return nil, errAuthRequired
+	}
+	issue := &NewIssue{From: config.Email, Title: p.Title, Body: formatTraces(p.Body), Status: p.Status, Owner: p.Owner, Labels: p.Labels}
+	if err := validateIssue(config, c.Project, issue); err != nil {
+		return nil, err
+	}
+	if _, failures := lintIssue(loadLintRules(config), issue.Body); len(failures) > 0 {
+		return nil, errors.New(strings.Join(failures, "; "))
+	}
+	if err := checkRPCText(config, issue.Title+"\n"+issue.Body, p.Force); err != nil {
+		return nil, err
+	}
+	entry, err := c.CreateIssue(ctx, issue)
+	if err != nil {
+		return nil, err
+	}
+	return issueData(c, entry), nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestServeRPC(t *testing.T) {
+	in := strings.Join([]string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "frobnicate"}`,
+		`{"jsonrpc": "2.0", "id": "a", "method": "get", "params": {}}`,
+		`{"jsonrpc": "2.0", "method": "get"}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "get", "params": {"id": 123}}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "comment", "params": {"id": "123"}}`,
+	}, "\n")
+	var out bytes.Buffer
+	if err := serveRPC(context.Background(), defaultConfig(), &Client{Project: "go"}, strings.NewReader(in), &out); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"unknown method: frobnicate"}}`,
+		`{"jsonrpc":"2.0","id":"a","error":{"code":1,"message":"no id"}}`,
+		`{"jsonrpc":"2.0","id":2,"error":{"code":-32602,"message":"json: cannot unmarshal number into Go struct field rpcParams.id of type string"}}`,
+		`{"jsonrpc":"2.0","id":3,"error":{"code":1,"message":"id and body are required"}}`,
+	}, "\n") + "\n"
+	if out.String() != want {
+		t.Errorf("serveRPC wrote\n%s\nwant\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	if err := serveRPC(context.Background(), defaultConfig(), &Client{}, strings.NewReader(`{"id": 1,`), &out); err == nil {
+		t.Error("serveRPC must fail on broken json")
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// defaultSecretPatterns match text that look like secrets or private paths.
+// "secret_patterns" in config replace them.
+var defaultSecretPatterns = []string{
+	`(?i)pass(word|wd)?\s*[:=]\s*\S+`,
+	`(?i)(api|access|secret|auth)[_-]?(key|token)\s*[:=]\s*\S+`,
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----`,
+	`AKIA[0-9A-Z]{16}`,
+	`ya29\.[\w-]+`,
+	`/home/[^/\s]+`,
+	`/Users/[^/\s]+`,
+	`(?i)[A-Z]:\\Users\\[^\\\s]+`,
+}
+
+// findSecrets return parts of text that look like secrets, masked by
+// redact so that they can be shown and logged. The password in config is
+// always searched.
+func findSecrets(config *Config, text string) []string {
+	patterns := defaultSecretPatterns
+	if config.SecretPatterns != nil {
+		if err := json.Unmarshal(config.SecretPatterns, &patterns); err != nil {
+			fatal("invalid secret_patterns in your settings.json:", err)
+		}
+	}
+	var found []string
+	if p := config.Password; p != "" && strings.Contains(text, p) {
+		found = append(found, "your password")
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			fatal("invalid secret pattern "+pattern+":", err)
+		}
+		for _, m := range re.FindAllString(text, -1) {
+			found = append(found, redact(m))
+		}
+	}
+	return found
+}
+
+// redact return s with all but a few leading characters masked, enough to
+// find it in the text.
+func redact(s string) string {
+	r := []rune(s)
+	n := len(r) / 4
+	if n > 4 {
+		n = 4
+	}
+	return string(r[:n]) + "****"
+}
+
+// checkSecrets warn if text to be sent to the public tracker look like it
+// contain secrets, and return true if the user want to send it anyway.
+func checkSecrets(config *Config, text string) bool {
+	found := findSecrets(config, text)
+	if len(found) == 0 {
+		return true
+	}
+	warnf("the text may contain secrets or private paths:")
+	for _, s := range found {
+		warnf("  %s", s)
+	}
+	return confirm("send it anyway?")
+}
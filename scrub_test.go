@@ -0,0 +1,21 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindSecrets(t *testing.T) {
+	config := &Config{Password: "hunter2"}
+	text := "login with hunter2 or api_key=0123456789abcdef, see /home/gopher/src"
+	found := findSecrets(config, text)
+	want := []string{"your password", "api_****", "/ho****"}
+	if strings.Join(found, ",") != strings.Join(want, ",") {
+		t.Errorf("findSecrets = %q, want %q", found, want)
+	}
+	for _, s := range found {
+		if strings.Contains(s, "0123456789") || strings.Contains(s, "hunter2") {
+			t.Errorf("secret is shown: %q", s)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// searchScopes map -in values to the operator of Google Code search. An
+// empty operator means full text search.
+var searchScopes = map[string]string{
+	"all":         "",
+	"summary":     "summary:",
+	"description": "description:",
+	"comments":    "comment:",
+}
+
+// splitTerms split the expression into words. Words quoted with double
+// quotes are kept as one word including the quotes.
+func splitTerms(expr string) []string {
+	var terms []string
+	var cur []rune
+	quoted := false
+	for _, r := range expr {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			cur = append(cur, r)
+		case !quoted && (r == ' ' || r == '\t'):
+			if len(cur) > 0 {
+				terms = append(terms, string(cur))
+				cur = nil
+			}
+		default:
+			cur = append(cur, r)
+		}
+	}
+	if len(cur) > 0 {
+		terms = append(terms, string(cur))
+	}
+	return terms
+}
+
+// buildQuery translate the search expression into q= parameter. Words are
+// combined with AND implicitly, and AND, OR and NOT can be written
+// explicitly. Each word is searched in the scope.
+func buildQuery(expr, scope string) (string, error) {
+	op, ok := searchScopes[scope]
+	if !ok {
+		return "", errors.New("unknown scope: " + scope)
+	}
+	var q []string
+	not := false
+	prev := "" // last operator, or "" after a word
+	for _, term := range splitTerms(expr) {
+		switch strings.ToUpper(term) {
+		case "AND":
+			if prev != "" || len(q) == 0 {
+				return "", errors.New("AND must be between words")
+			}
+			prev = "AND"
+			continue
+		case "OR":
+			if prev != "" || len(q) == 0 {
+				return "", errors.New("OR must be between words")
+			}
+			q = append(q, "OR")
+			prev = "OR"
+			continue
+		case "NOT":
+			not = !not
+			continue
+		}
+		if strings.HasPrefix(term, "-") && len(term) > 1 {
+			not = !not
+			term = term[1:]
+		}
+		// words having operator like label:Priority-High are kept as is.
+		if !strings.Contains(strings.Trim(term, `"`), ":") {
+			term = op + term
+		}
+		if not {
+			term = "-" + term
+		}
+		q = append(q, term)
+		not = false
+		prev = ""
+	}
+	if prev != "" || not {
+		return "", errors.New("operator must be followed by a word")
+	}
+	return strings.Join(q, " "), nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// defaultUpdateURL is the release endpoint checked by selfupdate. It can be
+// changed with "update_url" in config.
+const defaultUpdateURL = "https://api.github.com/repos/mattn/goissue/releases/latest"
+
+// release is the latest release returned from the endpoint.
+type release struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// asset return download URL of the file in the release.
+func (r *release) asset(name string) (string, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a.URL, true
+		}
+	}
+	return "", false
+}
+
+// download return body of the uri.
+func download(ctx context.Context, uri string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return nil, errors.New(uri + ": " + res.Status)
+	}
+	return ioutil.ReadAll(res.Body)
+}
+
+// checksum return SHA-256 of the file in SHA256SUMS, which has lines like
+// "<hex>  <name>".
+func checksum(sums []byte, name string) (string, bool) {
+	s := bufio.NewScanner(bytes.NewReader(sums))
+	for s.Scan() {
+		f := strings.Fields(s.Text())
+		if len(f) == 2 && strings.TrimPrefix(f[1], "*") == name {
+			return f[0], true
+		}
+	}
+	return "", false
+}
+
+// replaceExecutable replace the running binary with b.
+func replaceExecutable(b []byte) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	tmp := exe + ".new"
+	if err := ioutil.WriteFile(tmp, b, 0755); err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		// running binary can't be overwritten, but can be renamed.
+		os.Remove(exe + ".old")
+		if err := os.Rename(exe, exe+".old"); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if err := os.Rename(tmp, exe); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// selfupdateFlags is flags of selfupdate.
+type selfupdateFlags struct {
+	check bool
+	force bool
+}
+
+// register add flags of selfupdate to the flag set.
+func (o *selfupdateFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.check, "check", false, "only check whether an update is available")
+	fs.BoolVar(&o.force, "force", false, "update even if the version is same")
+}
+
+// selfUpdate replace goissue with the latest release for the platform,
+// after verifying the checksum of it.
+func selfUpdate(ctx context.Context, config *Config, c *Client, args []string) {
+	var o selfupdateFlags
+	fs := newFlagSet("selfupdate")
+	o.register(fs)
+	fs.Parse(args)
+
+	updateURL := defaultUpdateURL
+	if u := config.UpdateURL; u != "" {
+		updateURL = u
+	}
+	b, err := download(ctx, updateURL)
+	if err != nil {
+		fatal("failed to check update:", err)
+	}
+	var r release
+	if err := json.Unmarshal(b, &r); err != nil {
+		fatal("failed to check update:", err)
+	}
+	latest := strings.TrimPrefix(r.TagName, "v")
+	if latest == version && !o.force {
+		fmt.Println("goissue " + version + " is up to date")
+		return
+	}
+	fmt.Printf("goissue %s is available (current %s)\n", latest, version)
+	if o.check {
+		return
+	}
+
+	name := "goissue_" + runtime.GOOS + "_" + runtime.GOARCH
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	binURL, ok := r.asset(name)
+	if !ok {
+		fatal("failed to update: no binary for ", runtime.GOOS+"/"+runtime.GOARCH)
+	}
+	sumsURL, ok := r.asset("SHA256SUMS")
+	if !ok {
+		fatal("failed to update: no checksums in the release")
+	}
+	sums, err := download(ctx, sumsURL)
+	if err != nil {
+		fatal("failed to update:", err)
+	}
+	want, ok := checksum(sums, name)
+	if !ok {
+		fatal("failed to update: no checksum of ", name)
+	}
+	bin, err := download(ctx, binURL)
+	if err != nil {
+		fatal("failed to update:", err)
+	}
+	sum := sha256.Sum256(bin)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, want) {
+		fatalf("failed to update: checksum mismatch of %s: got %s, want %s", name, got, want)
+	}
+	if err := replaceExecutable(bin); err != nil {
+		fatal("failed to update:", err)
+	}
+	fmt.Println("updated to goissue " + latest)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxCommentSize return the size of comments in bytes over which the
+// tracker may reject or truncate them; "max_comment_size" in config, 50000
+// by default.
+func maxCommentSize(config *Config) int {
+	return config.MaxCommentSize
+}
+
+// cutAt return the position to cut s not over max bytes; at the end of a
+// paragraph or a line if possible, and never inside a UTF-8 sequence.
+func cutAt(s string, max int) int {
+	if len(s) <= max {
+		return len(s)
+	}
+	if i := strings.LastIndex(s[:max], "\n\n"); i > max/2 {
+		return i + 2
+	}
+	if i := strings.LastIndex(s[:max], "\n"); i > max/2 {
+		return i + 1
+	}
+	i := max
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	if i == 0 {
+		return max
+	}
+	return i
+}
+
+// splitComment split text into parts not over max bytes each, including the
+// "(part i/n)" header. <pre> blocks cut at a part are closed and reopened
+// in the next part.
+func splitComment(text string, max int) []string {
+	const room = 64 // for the header and reopened <pre>
+	if max <= room {
+		max = room + 1
+	}
+	var parts []string
+	open := false
+	for text != "" {
+		part := ""
+		if open {
+			part = "<pre>\n"
+		}
+		i := cutAt(text, max-room)
+		part += text[:i]
+		text = text[i:]
+		open = strings.Count(part, "<pre>") > strings.Count(part, "</pre>")
+		if open && text != "" {
+			part += "\n</pre>"
+		}
+		parts = append(parts, part)
+	}
+	for i := range parts {
+		parts[i] = fmt.Sprintf("(part %d/%d)\n\n", i+1, len(parts)) + parts[i]
+	}
+	return parts
+}
+
+// guardSize return comments to post for text. If text is too long, it is
+// split into sequential comments, or the overflow is saved to a file to be
+// attached on the web, as the user choose. With split, it is split without
+// asking.
+func guardSize(config *Config, text string, split bool) ([]string, error) {
+	max := maxCommentSize(config)
+	if len(text) <= max {
+		return []string{text}, nil
+	}
+	parts := splitComment(text, max)
+	if split {
+		return parts, nil
+	}
+	if !isTerminal(os.Stdin) {
+		return nil, fmt.Errorf("comment is %d bytes, over %d; give -split to post it in %d comments", len(text), max, len(parts))
+	}
+	warnf("comment is %d bytes, over the limit of %d bytes", len(text), max)
+	fmt.Fprintf(os.Stderr, "[s]plit into %d comments, save the [r]est to a file to attach, or [c]ancel? ", len(parts))
+	line, _ := answers.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "s", "split":
+		return parts, nil
+	case "r", "rest":
+		i := cutAt(text, max-200)
+		f, err := ioutil.TempFile(tempDir(config), "goissue-rest-*.txt")
+		if err != nil {
+			return nil, err
+		}
+		_, err = f.WriteString(toNative(text[i:]))
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		infof("the rest of the comment is saved in %s; attach it on the web", f.Name())
+		return []string{text[:i] + "\n\n(the rest is in the attached file.)"}, nil
+	}
+	return nil, errors.New("canceled")
+}
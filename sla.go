@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// slaRules return "sla" in config: how long open issues with the label may
+// go without an update, like {"Priority-Critical": "3d"}. "*" is for
+// issues without any of the labels.
+func slaRules(config *Config) map[string]time.Duration {
+	if config.SLA == nil {
+		fatal(`no "sla" in your settings.json, like {"Priority-Critical": "3d"}`)
+	}
+	var m map[string]string
+	if err := json.Unmarshal(config.SLA, &m); err != nil {
+		fatal("invalid sla in your settings.json:", err)
+	}
+	rules := map[string]time.Duration{}
+	for label, age := range m {
+		d, err := parseAge(age)
+		if err != nil {
+			fatal("invalid sla of "+label+" in your settings.json:", err)
+		}
+		rules[label] = d
+	}
+	return rules
+}
+
+// days return d in days, or hours if shorter than a day.
+func days(d time.Duration) string {
+	if d < 24*time.Hour {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+// slaRule return the strictest rule of the labels of the issue, or "*"
+// for issues without any of them.
+func slaRule(rules map[string]time.Duration, entry Entry) (string, time.Duration, bool) {
+	label, limit := "", time.Duration(0)
+	for _, l := range entry.IssuesLabel {
+		if d, ok := rules[l]; ok && (label == "" || d < limit) {
+			label, limit = l, d
+		}
+	}
+	if label == "" {
+		d, ok := rules["*"]
+		if !ok {
+			return "", 0, false
+		}
+		label, limit = "*", d
+	}
+	return label, limit, true
+}
+
+// statusChanged return when the status of the issue was changed last: the
+// time of the last comment that changed it, or when the issue was reported.
+func statusChanged(entry Entry, comments []Entry) (time.Time, error) {
+	changed := entry.Published
+	for _, comment := range comments {
+		if comment.IssuesUpdates != nil && comment.IssuesUpdates.IssuesStatus != "" {
+			changed = comment.Published
+		}
+	}
+	return time.Parse(time.RFC3339, changed)
+}
+
+// overSLA return what the open issue breached, if its status is not changed
+// for longer than the strictest rule of its labels. comments are of the
+// issue, in which status changes are found.
+func overSLA(rules map[string]time.Duration, entry Entry, comments []Entry, now time.Time) (string, bool) {
+	if entryState(entry) == "closed" {
+		return "", false
+	}
+	label, limit, ok := slaRule(rules, entry)
+	if !ok {
+		return "", false
+	}
+	changed, err := statusChanged(entry, comments)
+	if err != nil {
+		return "", false
+	}
+	age := now.Sub(changed)
+	if age <= limit {
+		return "", false
+	}
+	return fmt.Sprintf("%s status not changed for %s, over %s", label, days(age), days(limit)), true
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOverSLA(t *testing.T) {
+	rules := map[string]time.Duration{
+		"Priority-Critical": 3 * 24 * time.Hour,
+		"Priority-High":     14 * 24 * time.Hour,
+	}
+	now, _ := time.Parse(time.RFC3339, "2012-05-10T00:00:00Z")
+	for _, tt := range []struct {
+		labels    []string
+		state     string
+		published string
+		want      string
+	}{
+		{[]string{"Priority-Critical"}, "open", "2012-05-05T00:00:00Z", "Priority-Critical status not changed for 5d, over 3d"},
+		{[]string{"Priority-Critical"}, "open", "2012-05-08T00:00:00Z", ""},
+		{[]string{"Priority-High", "Priority-Critical"}, "open", "2012-05-05T00:00:00Z", "Priority-Critical status not changed for 5d, over 3d"},
+		{[]string{"Priority-Critical"}, "closed", "2012-01-01T00:00:00Z", ""},
+		{[]string{"Priority-Low"}, "open", "2012-01-01T00:00:00Z", ""},
+	} {
+		entry := Entry{IssuesLabel: tt.labels, IssuesState: []string{tt.state}, Published: tt.published}
+		if got, _ := overSLA(rules, entry, nil, now); got != tt.want {
+			t.Errorf("overSLA(%v, %s, %s) = %q, want %q", tt.labels, tt.state, tt.published, got, tt.want)
+		}
+	}
+
+	// comments without status changes don't reset the age.
+	entry := Entry{IssuesLabel: []string{"Priority-Critical"}, IssuesState: []string{"open"}, Published: "2012-05-01T00:00:00Z"}
+	comments := []Entry{
+		{Published: "2012-05-06T00:00:00Z", IssuesUpdates: &IssuesUpdates{IssuesStatus: "Accepted"}},
+		{Published: "2012-05-09T00:00:00Z", IssuesUpdates: &IssuesUpdates{IssuesLabel: []string{"OS-Linux"}}},
+		{Published: "2012-05-09T12:00:00Z"},
+	}
+	if got, _ := overSLA(rules, entry, comments, now); got != "Priority-Critical status not changed for 4d, over 3d" {
+		t.Errorf("overSLA with comments = %q", got)
+	}
+	comments[1].IssuesUpdates.IssuesStatus = "Started"
+	if got, ok := overSLA(rules, entry, comments, now); ok {
+		t.Errorf("overSLA after status change = %q", got)
+	}
+	rules["*"] = 90 * 24 * time.Hour
+	entry = Entry{IssuesLabel: []string{"Priority-Low"}, IssuesState: []string{"open"}, Published: "2012-01-01T00:00:00Z"}
+	if _, ok := overSLA(rules, entry, nil, now); !ok {
+		t.Error("\"*\" is not applied to issues without the labels")
+	}
+}
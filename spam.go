@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// spamFilter is the local list of spam issues hidden from list, search and
+// watch: issues by the authors, or with titles matched to the regular
+// expressions.
+type spamFilter struct {
+	Authors []string `json:"authors"`
+	Titles  []string `json:"titles"`
+
+	titles []*regexp.Regexp
+}
+
+// loadSpam return the spam filter of the project.
+func loadSpam(project string) *spamFilter {
+	s := &spamFilter{}
+	if err := readLocal(project, "spam", s); err != nil {
+		warnf("failed to read spam filter: %v", err)
+	}
+	for _, t := range s.Titles {
+		re, err := regexp.Compile(t)
+		if err != nil {
+			warnf("invalid title in spam filter %s: %v", t, err)
+			continue
+		}
+		s.titles = append(s.titles, re)
+	}
+	return s
+}
+
+// match return true if the entry is spam.
+func (s *spamFilter) match(entry Entry) bool {
+	if authorName(entry) != "" && contains(s.Authors, authorName(entry)) {
+		return true
+	}
+	for _, re := range s.titles {
+		if re.MatchString(entry.Title) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropSpam return entries of the project of c without spam, telling how
+// many are hidden. Nothing is dropped with -spam.
+func dropSpam(c *Client, entries []Entry) []Entry {
+	if c.Options.ShowSpam {
+		return entries
+	}
+	s := loadSpam(c.Project)
+	var kept []Entry
+	for _, entry := range entries {
+		if !s.match(entry) {
+			kept = append(kept, entry)
+		}
+	}
+	if n := len(entries) - len(kept); n > 0 {
+		notef("%d issues hidden as spam; show them with -spam\n", n)
+	}
+	return kept
+}
+
+// spamFlags is flags of spam.
+type spamFlags struct {
+	undo   bool
+	author string
+	title  string
+}
+
+// register add flags of spam to the flag set.
+func (o *spamFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.undo, "undo", false, "remove from the spam filter")
+	fs.StringVar(&o.author, "author", "", "hide issues by the user")
+	fs.StringVar(&o.title, "title", "", "hide issues with titles matched to the regular expression")
+}
+
+// markSpam add authors of the issues, -author and -title to the spam filter.
+// With -undo, they are removed. Without arguments, the filter is listed.
+func markSpam(ctx context.Context, config *Config, c *Client, args []string) {
+	var o spamFlags
+	fs := newFlagSet("spam")
+	o.register(fs)
+	rest := parseFlags(fs, args)
+
+	s := loadSpam(c.Project)
+	if len(rest) == 0 && o.author == "" && o.title == "" {
+		for _, a := range s.Authors {
+			fmt.Println("author:", a)
+		}
+		for _, t := range s.Titles {
+			fmt.Println("title:", t)
+		}
+		return
+	}
+	if o.title != "" {
+		if _, err := regexp.Compile(o.title); err != nil {
+			fatal("invalid title:", err)
+		}
+	}
+	authors := []string{}
+	if o.author != "" {
+		authors = append(authors, o.author)
+	}
+	for _, id := range rest {
+		if _, err := strconv.Atoi(id); err != nil {
+			fatal("invalid issue number:", id)
+		}
+		entry, err := c.Entry(ctx, id)
+		if err != nil {
+			fatal("failed to get issue:", err)
+		}
+		if authorName(entry) == "" {
+			fatal("no author of issue " + id)
+		}
+		authors = append(authors, authorName(entry))
+	}
+	for _, a := range authors {
+		s.Authors = setMember(s.Authors, a, o.undo)
+	}
+	if o.title != "" {
+		s.Titles = setMember(s.Titles, o.title, o.undo)
+	}
+	if err := writeLocal(c.Project, "spam", s); err != nil {
+		fatal("failed to write spam filter:", err)
+	}
+}
+
+// setMember return list with s added, or removed if remove is true.
+func setMember(list []string, s string, remove bool) []string {
+	var out []string
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	if !remove {
+		out = append(out, s)
+	}
+	return out
+}
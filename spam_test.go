@@ -0,0 +1,34 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSpamFilter(t *testing.T) {
+	s := &spamFilter{Authors: []string{"Spammer"}, titles: []*regexp.Regexp{regexp.MustCompile(`(?i)cheap watches`)}}
+	for _, tt := range []struct {
+		author string
+		title  string
+		want   bool
+	}{
+		{"spammer", "crash in net/http", true},
+		{"gopher", "Buy CHEAP watches", true},
+		{"gopher", "crash in net/http", false},
+		{"", "crash in net/http", false},
+	} {
+		entry := Entry{Title: tt.title}
+		if tt.author != "" {
+			entry.Author = []Author{{Name: tt.author}}
+		}
+		if got := s.match(entry); got != tt.want {
+			t.Errorf("match(%q, %q) = %v, want %v", tt.author, tt.title, got, tt.want)
+		}
+	}
+	if got := setMember([]string{"a", "b"}, "a", false); len(got) != 2 || got[1] != "a" {
+		t.Errorf("setMember added a twice: %q", got)
+	}
+	if got := setMember([]string{"a", "b"}, "a", true); len(got) != 1 || got[0] != "b" {
+		t.Errorf("setMember didn't remove a: %q", got)
+	}
+}
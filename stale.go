@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"time"
+)
+
+const stalePing = `This issue has had no activity for a while.
+Is this still reproducible with the latest release?
+If so, please let us know; otherwise it may be closed.`
+
+// staleFlags is flags of stale.
+type staleFlags struct {
+	days   int
+	ping   bool
+	filter filter
+}
+
+// register add flags of stale to the flag set.
+func (o *staleFlags) register(fs *flag.FlagSet) {
+	fs.IntVar(&o.days, "days", 90, "days without update")
+	fs.BoolVar(&o.ping, "ping", false, "post comment asking whether the issue is still reproducible")
+	o.filter.register(fs)
+}
+
+// staleIssues list open issues that have not been updated in N days, like
+// list does; spam is hidden unless -spam, and is never pinged.
+func staleIssues(ctx context.Context, config *Config, c *Client, args []string) {
+	var o staleFlags
+	fs := newFlagSet("stale")
+	o.register(fs)
+	fs.Parse(args)
+
+	query := o.filter.values("open")
+	query.Set("updated-max", time.Now().AddDate(0, 0, -o.days).UTC().Format(time.RFC3339))
+	entries, err := c.Entries(ctx, query)
+	if err != nil {
+		fatal("failed to get issues:", err)
+	}
+	entries = dropSpam(c, o.filter.narrow(entries))
+	for _, entry := range entries {
+		printIssue(c, entry, issueId(entry)+": "+entry.Title+" (updated "+entry.Updated+")")
+		if o.ping {
+			if err := updateIssue(ctx, c, config.Email, entry, stalePing, nil); err != nil {
+				fatal("failed to post comment:", err)
+			}
+		}
+	}
+}
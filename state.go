@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// stateKinds is kinds of local state shared between machines by state, and
+// how to resolve an item changed on both sides since the last sync.
+var stateKinds = []struct {
+	name    string
+	resolve func(a, b json.RawMessage) json.RawMessage
+}{
+	// the later update read wins.
+	{"seen", func(a, b json.RawMessage) json.RawMessage {
+		var sa, sb string
+		json.Unmarshal(a, &sa)
+		json.Unmarshal(b, &sb)
+		if sb > sa {
+			return b
+		}
+		return a
+	}},
+	// both are muted.
+	{"muted", func(a, b json.RawMessage) json.RawMessage { return a }},
+	// the issue taken later wins.
+	{"wip", func(a, b json.RawMessage) json.RawMessage {
+		var wa, wb wipEntry
+		json.Unmarshal(a, &wa)
+		json.Unmarshal(b, &wb)
+		if wb.Taken.After(wa.Taken) {
+			return b
+		}
+		return a
+	}},
+}
+
+// stateItems is items of a kind of local state, keyed by issue number.
+type stateItems map[string]json.RawMessage
+
+// sameItem return true if a and b are the same item; nil is no item.
+func sameItem(a, b json.RawMessage) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+// mergeItems merge items changed locally and remotely since base, the state
+// of the last sync. An item changed on one side takes the change, including
+// removal. Changed on both sides, an item removed on one side is kept, and
+// the others are resolved.
+func mergeItems(base, local, remote stateItems, resolve func(a, b json.RawMessage) json.RawMessage) stateItems {
+	merged := stateItems{}
+	keys := map[string]bool{}
+	for _, m := range []stateItems{base, local, remote} {
+		for k := range m {
+			keys[k] = true
+		}
+	}
+	for k := range keys {
+		b, l, r := base[k], local[k], remote[k]
+		var v json.RawMessage
+		switch {
+		case sameItem(l, r), sameItem(r, b):
+			v = l
+		case sameItem(l, b):
+			v = r
+		case l == nil:
+			v = r
+		case r == nil:
+			v = l
+		default:
+			v = resolve(l, r)
+		}
+		if v != nil {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// changes return the number of items added, removed or changed from a to b.
+func changes(a, b stateItems) int {
+	n := 0
+	for k, v := range b {
+		if !sameItem(a[k], v) {
+			n++
+		}
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			n++
+		}
+	}
+	return n
+}
+
+// readState read the shared state file, which has state of projects keyed
+// by project and kind.
+func readState(file string) (map[string]map[string]stateItems, error) {
+	state := map[string]map[string]stateItems{}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// stateFlags is flags of state.
+type stateFlags struct {
+	file   string
+	dryRun bool
+}
+
+// register add flags of state to the flag set.
+func (o *stateFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.file, "file", "", "shared state file, instead of \"state_file\" in settings")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "print how many items change without writing")
+}
+
+// syncState merge read marks, muted issues and issues taken of the project
+// with the state file, which is shared between machines by Dropbox, a git
+// repository or else. Changes on both sides since the last sync are kept.
+func syncState(ctx context.Context, config *Config, c *Client, args []string) {
+	var o stateFlags
+	fs := newFlagSet("state")
+	o.register(fs)
+	parseFlags(fs, args)
+	if o.file == "" {
+		o.file = config.StateFile
+	}
+	if o.file == "" {
+		fatal(`no state file; give -file or "state_file" in your settings.json`)
+	}
+
+	unlock, err := lockFile(o.file)
+	if err != nil {
+		fatal("failed to lock state file:", err)
+	}
+	err = mergeState(c.Project, o.file, o.dryRun)
+	unlock()
+	if err != nil {
+		fatal("failed to sync state:", err)
+	}
+}
+
+// mergeState merge local state of the project with the state file. The
+// merged state is written to both, and kept as the base of the next sync.
+func mergeState(project, file string, dryRun bool) error {
+	state, err := readState(file)
+	if err != nil {
+		return err
+	}
+	var base map[string]stateItems
+	if err := readLocal(project, "synced", &base); err != nil {
+		return err
+	}
+	merged := map[string]stateItems{}
+	for _, kind := range stateKinds {
+		local := stateItems{}
+		if err := readLocal(project, kind.name, &local); err != nil {
+			return err
+		}
+		remote := state[project][kind.name]
+		m := mergeItems(base[kind.name], local, remote, kind.resolve)
+		fmt.Printf("%s: %d updated here, %d updated in %s\n", kind.name, changes(local, m), changes(remote, m), filepath.Base(file))
+		merged[kind.name] = m
+	}
+	if dryRun {
+		return nil
+	}
+	for kind, m := range merged {
+		if err := writeLocal(project, kind, m); err != nil {
+			return err
+		}
+	}
+	if err := writeLocal(project, "synced", merged); err != nil {
+		return err
+	}
+	state[project] = merged
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	return replaceFile(file, b, 0600)
+}
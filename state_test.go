@@ -0,0 +1,29 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMergeItems(t *testing.T) {
+	items := func(kv ...string) stateItems {
+		m := stateItems{}
+		for i := 0; i < len(kv); i += 2 {
+			m[kv[i]] = json.RawMessage(kv[i+1])
+		}
+		return m
+	}
+	base := items("1", `"2012-05-01"`, "2", `"2012-05-01"`, "3", `"2012-05-01"`, "4", `"2012-05-01"`)
+	// 1 is read again here, 2 is removed there, 3 is read again on both,
+	// 4 is removed here and read again there, 5 is new there.
+	local := items("1", `"2012-05-03"`, "2", `"2012-05-01"`, "3", `"2012-05-02"`)
+	remote := items("1", `"2012-05-01"`, "3", `"2012-05-04"`, "4", `"2012-05-05"`, "5", `"2012-05-01"`)
+	got := mergeItems(base, local, remote, stateKinds[0].resolve)
+	want := items("1", `"2012-05-03"`, "3", `"2012-05-04"`, "4", `"2012-05-05"`, "5", `"2012-05-01"`)
+	if changes(got, want) != 0 {
+		t.Errorf("mergeItems = %s, want %s", got, want)
+	}
+	if n := changes(local, got); n != 4 {
+		t.Errorf("changes(local, merged) = %d, want 4", n)
+	}
+}
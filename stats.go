@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// statsFlags is flags of stats.
+type statsFlags struct {
+	by     string
+	since  string
+	until  string
+	filter filter
+}
+
+// register add flags of stats to the flag set.
+func (o *statsFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.by, "by", "owner", "group by owner or author")
+	fs.StringVar(&o.since, "since", "", "start date (YYYY-MM-DD)")
+	fs.StringVar(&o.until, "until", "", "end date (YYYY-MM-DD)")
+	o.filter.register(fs)
+}
+
+// showStats print number of filed and closed issues per owner or author.
+func showStats(ctx context.Context, config *Config, c *Client, args []string) {
+	var o statsFlags
+	fs := newFlagSet("stats")
+	o.register(fs)
+	fs.Parse(args)
+	if o.by != "owner" && o.by != "author" {
+		fatal("-by must be owner or author")
+	}
+	from, to := parseDate(o.since), parseDate(o.until)
+
+	filed := map[string]int{}
+	closed := map[string]int{}
+	for _, entry := range loadStore(c.Project).Entries {
+		if !o.filter.match(entry) {
+			continue
+		}
+		name := "(none)"
+		if o.by == "owner" && ownerName(entry) != "" {
+			name = ownerName(entry)
+		} else if o.by == "author" && len(entry.Author) > 0 {
+			name = entry.Author[0].Name
+		}
+		if t, err := time.Parse(time.RFC3339, entry.Published); err == nil && inRange(t, from, to) {
+			filed[name]++
+		}
+		if t, ok := closedAt(entry); ok && inRange(t, from, to) {
+			closed[name]++
+		}
+	}
+
+	var names []string
+	for name := range filed {
+		names = append(names, name)
+	}
+	for name := range closed {
+		if _, ok := filed[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	fmt.Printf("%-30s %6s %6s\n", o.by, "filed", "closed")
+	for _, name := range names {
+		fmt.Printf("%-30s %6d %6d\n", name, filed[name], closed[name])
+	}
+}
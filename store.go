@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// store is local copy of issues of the project. It is updated by sync
+// command and used by commands that need whole issues of the project.
+type store struct {
+	Synced   string             `json:"synced"`
+	Entries  map[string]Entry   `json:"entries"`
+	Comments map[string][]Entry `json:"comments,omitempty"`
+
+	project string
+}
+
+// storeFile return path of the store file for the project. The store is
+// saved gzipped.
+func storeFile(project string) string {
+	return filepath.Join(configDir(), "store", project+".json.gz")
+}
+
+// loadStore return store of the project. If no store exists, return empty
+// one. A broken store is moved aside to file+".corrupt" and an empty one
+// is returned, so that next sync fetch all issues again instead of every
+// command failing.
+func loadStore(project string) *store {
+	empty := func() *store {
+		return &store{project: project, Entries: map[string]Entry{}, Comments: map[string][]Entry{}}
+	}
+	s := empty()
+	file := storeFile(s.project)
+	b, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		// store written by older version is not compressed.
+		file = strings.TrimSuffix(file, ".gz")
+		b, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s
+		}
+		fatal("failed to read store:", err)
+	}
+	b, err = decompress(b)
+	if err == nil {
+		err = json.Unmarshal(b, s)
+	}
+	if err != nil {
+		warnf("store of %s is broken (%v); moved to %s.corrupt, run: goissue sync", project, err, file)
+		os.Rename(file, file+".corrupt")
+		return empty()
+	}
+	if s.Comments == nil {
+		s.Comments = map[string][]Entry{}
+	}
+	// stores written by older versions may have control characters.
+	for id, entry := range s.Entries {
+		entry.sanitize()
+		s.Entries[id] = entry
+	}
+	for _, comments := range s.Comments {
+		for i := range comments {
+			comments[i].sanitize()
+		}
+	}
+	return s
+}
+
+// save write the store to the file.
+func (s *store) save() {
+	b, err := json.Marshal(s)
+	if err != nil {
+		fatal("failed to marshal store:", err)
+	}
+	err = os.MkdirAll(filepath.Dir(storeFile(s.project)), 0700)
+	if err != nil {
+		fatal("failed to write store:", err)
+	}
+	err = replaceFile(storeFile(s.project), compress(b), 0600)
+	if err != nil {
+		fatal("failed to write store:", err)
+	}
+}
+
+// localFile return path of the file that keep local state of the project
+// like read issues, besides the store.
+func localFile(project, name string) string {
+	return filepath.Join(configDir(), "store", project+"."+name+".json")
+}
+
+// readLocal read local state of the project into v. v is left as is if
+// the file doesn't exist.
+func readLocal(project, name string, v interface{}) error {
+	b, err := ioutil.ReadFile(localFile(project, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// writeLocal write local state of the project.
+func writeLocal(project, name string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(localFile(project, name)), 0700); err != nil {
+		return err
+	}
+	return replaceFile(localFile(project, name), b, 0600)
+}
+
+// syncFlags is flags of sync.
+type syncFlags struct {
+	full     bool
+	comments bool
+	all      bool
+}
+
+// register add flags of sync to the flag set.
+func (o *syncFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.full, "full", false, "fetch all issues instead of updated ones")
+	fs.BoolVar(&o.comments, "comments", false, "fetch comments of the issues too")
+	fs.BoolVar(&o.all, "all-projects", false, "sync all projects in settings")
+}
+
+// syncIssues fetch issues updated since last sync into the store. With
+// -comments, comments of them are also fetched. With -all-projects, or
+// -project all, projects in config are synced in parallel.
+func syncIssues(ctx context.Context, config *Config, c *Client, args []string) {
+	var o syncFlags
+	fs := newFlagSet("sync")
+	o.register(fs)
+	fs.Parse(args)
+
+	// submissions made offline are posted first.
+	if failed := pushOutbox(ctx, config, c); failed > 0 {
+		warnf("%d items are left in outbox", failed)
+	}
+
+	if !o.all && c.Project != "all" {
+		if err := syncProject(ctx, c, o.full, o.comments); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	clients := projectClients(config, c, true)
+	errs := make([]error, len(clients))
+	var wg sync.WaitGroup
+	for i, pc := range clients {
+		wg.Add(1)
+		go func(i int, pc *Client) {
+			defer wg.Done()
+			errs[i] = syncProject(ctx, pc, o.full, o.comments)
+		}(i, pc)
+	}
+	wg.Wait()
+	failed := false
+	for i, err := range errs {
+		if err != nil {
+			log.Print(clients[i].Project+": ", err)
+			failed = true
+		}
+	}
+	if failed {
+		exit(1)
+	}
+}
+
+// syncProject fetch issues of the project of c into the store.
+func syncProject(ctx context.Context, c *Client, full, comments bool) error {
+	// hold the store while syncing, so that syncs run at once don't
+	// overwrite each other.
+	unlock, err := lockFile(storeFile(c.Project))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	s := loadStore(c.Project)
+	query := (&filter{}).values("all")
+	if s.Synced != "" && !full {
+		query.Set("updated-min", s.Synced)
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	entries, err := c.Entries(ctx, query)
+	for _, entry := range entries {
+		s.Entries[issueId(entry)] = entry
+	}
+	if err == nil && comments {
+		for _, entry := range entries {
+			id := issueId(entry)
+			var comments []Entry
+			comments, err = c.Comments(ctx, id)
+			if err != nil {
+				break
+			}
+			s.Comments[id] = comments
+		}
+	}
+	if err != nil {
+		// keep issues fetched so far. Synced is not updated, so that next
+		// sync fetch the rest.
+		s.save()
+		return fmt.Errorf("failed to get issues: %v (%d issues saved)", err, len(entries))
+	}
+	s.Synced = now
+	s.save()
+	infof("%s: %d issues updated, %d issues stored", c.Project, len(entries), len(s.Entries))
+	return nil
+}
+
+// entryState return state of the issue, "open" or "closed".
+func entryState(entry Entry) string {
+	if len(entry.IssuesState) == 0 {
+		return ""
+	}
+	return entry.IssuesState[0]
+}
+
+// closedAt return the time the issue was closed. If the entry doesn't have
+// closedDate, last updated time is used for it.
+func closedAt(entry Entry) (time.Time, bool) {
+	if entryState(entry) != "closed" {
+		return time.Time{}, false
+	}
+	closed := entry.IssuesClosedDate
+	if closed == "" {
+		closed = entry.Updated
+	}
+	t, err := time.Parse(time.RFC3339, closed)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// parseDate parse date given from command line. It accept both
+// "2006-01-02" and RFC3339 format. Empty string return zero time.
+func parseDate(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		t, err = time.Parse(time.RFC3339, s)
+	}
+	if err != nil {
+		fatal("invalid date "+s+":", err)
+	}
+	return t
+}
+
+// inRange return true if t is between since and until. Zero since or until
+// means no limit.
+func inRange(t, since, until time.Time) bool {
+	if !since.IsZero() && t.Before(since) {
+		return false
+	}
+	if !until.IsZero() && !t.Before(until) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// languages return preferred languages like ["ja_JP", "ja"], from "lang"
+// in config or LANG environment variable. config may be nil before
+// settings are read.
+func languages(config *Config) []string {
+	var lang string
+	if config != nil {
+		lang = config.Lang
+	}
+	if lang == "" {
+		lang = os.Getenv("LANG")
+	}
+	if i := strings.IndexAny(lang, ".@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if lang == "" || lang == "C" || lang == "POSIX" {
+		return nil
+	}
+	langs := []string{lang}
+	if i := strings.Index(lang, "_"); i >= 0 {
+		langs = append(langs, lang[:i])
+	}
+	return langs
+}
+
+// enhancementTemplate is the built-in template for issues of
+// Type-Enhancement.
+const enhancementTemplate = `from: 
+title: 
+status: 
+labels: 
+--------------
+What would you like to be added or changed?
+
+
+Why is it needed? What do you do without it now?
+
+
+Please provide any additional information below.
+`
+
+// docTemplate is the built-in template for issues of Type-Documentation.
+const docTemplate = `from: 
+title: 
+status: 
+labels: 
+--------------
+Which document or page is wrong or missing?
+
+
+What does it say, and what should it say?
+
+
+Please provide any additional information below.
+`
+
+// issueTypes map names given to -type to the built-in template and the
+// label of the type.
+var issueTypes = map[string]struct {
+	template string
+	label    string
+}{
+	"defect":      {issueTemplate, "Type-Defect"},
+	"enhancement": {enhancementTemplate, "Type-Enhancement"},
+	"doc":         {docTemplate, "Type-Documentation"},
+}
+
+// loadTemplate return template of new issue of the type for the project.
+// typ may be empty. It is read from templates/<project>.<type>.<lang>.txt
+// or templates/<project>.<type>.txt in the config directory (or the
+// "templates" directory in config), or the
+// built-in one of the type is used. Defects and issues without type also
+// look at templates/<project>.<lang>.txt and templates/<project>.txt.
+// Localized templates must keep the header lines like "title: " in English.
+func loadTemplate(config *Config, project, typ string) string {
+	dir := filepath.Join(configDir(), "templates")
+	if d := config.Templates; d != "" {
+		dir = d
+	}
+	typ = strings.ToLower(typ)
+	var names []string
+	if typ != "" {
+		for _, lang := range languages(config) {
+			names = append(names, project+"."+typ+"."+lang+".txt")
+		}
+		names = append(names, project+"."+typ+".txt")
+	}
+	if typ == "" || typ == "defect" {
+		for _, lang := range languages(config) {
+			names = append(names, project+"."+lang+".txt")
+		}
+		names = append(names, project+".txt")
+	}
+	for _, name := range names {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return toLF(string(b))
+		}
+	}
+	if t, ok := issueTypes[typ]; ok {
+		return t.template
+	}
+	return issueTemplate
+}
+
+// placeholderRE match placeholders in templates like {{version}}, or
+// {{version: Which version of Go?}} with the question asked by create
+// -prompt.
+var placeholderRE = regexp.MustCompile(`\{\{\s*([\w-]+)\s*(?::([^}]*))?\}\}`)
+
+// placeholderValues is values of placeholders given by -set NAME=VALUE.
+type placeholderValues map[string]string
+
+func (v placeholderValues) String() string { return "" }
+
+func (v placeholderValues) Set(s string) error {
+	kv := strings.SplitN(s, "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return errors.New("want NAME=VALUE")
+	}
+	v[kv[0]] = kv[1]
+	return nil
+}
+
+// placeholders return names of placeholders in the template in order, and
+// questions of them.
+func placeholders(template string) (names []string, questions map[string]string) {
+	questions = map[string]string{}
+	for _, m := range placeholderRE.FindAllStringSubmatch(template, -1) {
+		name, question := m[1], strings.TrimSpace(m[2])
+		if _, ok := questions[name]; !ok {
+			names = append(names, name)
+			questions[name] = name
+		}
+		if question != "" {
+			questions[name] = question
+		}
+	}
+	return names, questions
+}
+
+// fillPlaceholders replace placeholders in the template with values, and
+// return names of those without values, which are left as is.
+func fillPlaceholders(template string, values map[string]string) (string, []string) {
+	var missing []string
+	filled := placeholderRE.ReplaceAllStringFunc(template, func(s string) string {
+		name := placeholderRE.FindStringSubmatch(s)[1]
+		if v, ok := values[name]; ok {
+			return v
+		}
+		if !contains(missing, name) {
+			missing = append(missing, name)
+		}
+		return s
+	})
+	return filled, missing
+}
+
+// askPlaceholders ask values of placeholders in the template not in values,
+// one line each; a line ending with "\" continue to the next line.
+func askPlaceholders(template string, values map[string]string) error {
+	names, questions := placeholders(template)
+	for _, name := range names {
+		if _, ok := values[name]; ok {
+			continue
+		}
+		fmt.Fprint(os.Stderr, questions[name]+" ")
+		var lines []string
+		for {
+			line, err := answers.ReadString('\n')
+			if err != nil && line == "" {
+				return errors.New("no value for {{" + name + "}}; give it with -set " + name + "=VALUE")
+			}
+			line = strings.TrimRight(line, "\r\n")
+			if !strings.HasSuffix(line, "\\") {
+				lines = append(lines, line)
+				break
+			}
+			lines = append(lines, strings.TrimSuffix(line, "\\"))
+			fmt.Fprint(os.Stderr, "> ")
+		}
+		values[name] = strings.Join(lines, "\n")
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFillPlaceholders(t *testing.T) {
+	template := "title: crash on {{os}}\n\nWhich version?\n{{version: Which version of Go do you use?}}\n{{ os }} {{arch}}\n"
+	names, questions := placeholders(template)
+	if want := []string{"os", "version", "arch"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("placeholders = %q, want %q", names, want)
+	}
+	if q := questions["version"]; q != "Which version of Go do you use?" {
+		t.Errorf("question of version = %q", q)
+	}
+	if q := questions["os"]; q != "os" {
+		t.Errorf("question of os = %q, want the name", q)
+	}
+
+	got, missing := fillPlaceholders(template, map[string]string{"os": "linux", "version": "go1.0.1"})
+	want := "title: crash on linux\n\nWhich version?\ngo1.0.1\nlinux {{arch}}\n"
+	if got != want {
+		t.Errorf("fillPlaceholders = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(missing, []string{"arch"}) {
+		t.Errorf("missing = %q, want [arch]", missing)
+	}
+
+	v := placeholderValues{}
+	if err := v.Set("note=a=b"); err != nil || v["note"] != "a=b" {
+		t.Errorf("Set(note=a=b) = %v, %q", err, v["note"])
+	}
+	if err := v.Set("=x"); err == nil {
+		t.Error("Set(=x) must fail")
+	}
+}
+
+func TestFileIssueWithoutEditor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goissue-templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	template := "from: \ntitle: \nstatus: \nlabels: \n--------------\nWhich version?\n{{version}}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.txt"), []byte(template), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var posted string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		posted = string(b)
+		w.Header().Set("Content-Type", "application/atom+xml")
+		w.WriteHeader(201)
+		fmt.Fprint(w, testEntry)
+	}))
+	defer ts.Close()
+	c := NewClient("go", "token")
+	c.BaseURL = ts.URL
+	config := defaultConfig()
+	config.Email, config.Templates = "gopher@example.com", dir
+
+	fileIssue(context.Background(), config, c, []string{"-title", "crash in net/http", "-set", "version=go1.0.1"})
+	for _, want := range []string{"<title>crash in net/http</title>", "<name>gopher@example.com</name>", "go1.0.1"} {
+		if !strings.Contains(posted, want) {
+			t.Errorf("posted issue doesn't contain %q:\n%s", want, posted)
+		}
+	}
+}
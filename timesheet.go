@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeEntry is time spent on an issue, recorded by spend.
+type timeEntry struct {
+	Id    string        `json:"id"`
+	Spent time.Duration `json:"spent"`
+	Note  string        `json:"note,omitempty"`
+	At    time.Time     `json:"at"`
+}
+
+// loadTimes return time entries of the project, in order of recording.
+func loadTimes(project string) []timeEntry {
+	var times []timeEntry
+	if err := readLocal(project, "time", &times); err != nil {
+		fatal("failed to read time entries:", err)
+	}
+	return times
+}
+
+// spendFlags is flags of spend.
+type spendFlags struct {
+	at string
+}
+
+// register add flags of spend to the flag set.
+func (o *spendFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.at, "date", "", "date the time was spent, instead of now")
+}
+
+// spendTime record time spent on the issue, like "spend 123 2h bisecting".
+func spendTime(ctx context.Context, config *Config, c *Client, args []string) {
+	var o spendFlags
+	fs := newFlagSet("spend")
+	o.register(fs)
+	rest := parseFlags(fs, args)
+	if len(rest) < 2 {
+		fmt.Fprint(os.Stderr, "Usage: goissue spend [-date DATE] ID DURATION [NOTE...]\n")
+		fs.PrintDefaults()
+		exit(exitUsage)
+	}
+	id := rest[0]
+	if _, err := strconv.Atoi(id); err != nil {
+		fatal("invalid issue number:", id)
+	}
+	spent, err := time.ParseDuration(rest[1])
+	if err != nil || spent <= 0 {
+		fatal("invalid duration " + rest[1] + `; use like "2h" or "1h30m"`)
+	}
+	e := timeEntry{Id: id, Spent: spent, Note: strings.Join(rest[2:], " "), At: time.Now()}
+	if o.at != "" {
+		e.At = parseDate(o.at)
+	}
+
+	// spend run at once don't lose each other's entries.
+	unlock, err := lockFile(localFile(c.Project, "time"))
+	if err != nil {
+		fatal("failed to write time entries:", err)
+	}
+	var times []timeEntry
+	err = readLocal(c.Project, "time", &times)
+	if err == nil {
+		times = append(times, e)
+		err = writeLocal(c.Project, "time", times)
+	}
+	unlock()
+	if err != nil {
+		fatal("failed to write time entries:", err)
+	}
+	total := time.Duration(0)
+	for _, t := range times {
+		if t.Id == id {
+			total += t.Spent
+		}
+	}
+	infof("%s spent on issue %s (%s in total)", spent, id, total)
+}
+
+// timesheetFlags is flags of timesheet.
+type timesheetFlags struct {
+	since  string
+	until  string
+	format string
+}
+
+// register add flags of timesheet to the flag set.
+func (o *timesheetFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.since, "since", "1w", "show time spent in the duration like 1w, or since the date")
+	fs.StringVar(&o.until, "until", "", "show time spent before the date")
+	fs.StringVar(&o.format, "format", "text", "output format: text or csv")
+}
+
+// showTimesheet print time spent per issue in the period, with titles of
+// issues in the local store. -format csv print every entry instead.
+func showTimesheet(ctx context.Context, config *Config, c *Client, args []string) {
+	var o timesheetFlags
+	fs := newFlagSet("timesheet")
+	o.register(fs)
+	parseFlags(fs, args)
+
+	from := time.Time{}
+	if d, err := parseAge(o.since); err == nil {
+		from = time.Now().Add(-d)
+	} else {
+		from = parseDate(o.since)
+	}
+	to := parseDate(o.until)
+	var times []timeEntry
+	for _, t := range loadTimes(c.Project) {
+		if inRange(t.At, from, to) {
+			times = append(times, t)
+		}
+	}
+
+	switch o.format {
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"date", "issue", "hours", "note"})
+		for _, t := range times {
+			w.Write([]string{t.At.Format("2006-01-02"), t.Id, strconv.FormatFloat(t.Spent.Hours(), 'f', 2, 64), t.Note})
+		}
+		w.Flush()
+	case "text":
+		spent := map[string]time.Duration{}
+		var ids []string
+		var total time.Duration
+		for _, t := range times {
+			if _, ok := spent[t.Id]; !ok {
+				ids = append(ids, t.Id)
+			}
+			spent[t.Id] += t.Spent
+			total += t.Spent
+		}
+		sort.Slice(ids, func(i, j int) bool {
+			a, _ := strconv.Atoi(ids[i])
+			b, _ := strconv.Atoi(ids[j])
+			return a < b
+		})
+		entries := loadStore(c.Project).Entries
+		for _, id := range ids {
+			fmt.Println(strings.TrimRight(fmt.Sprintf("%6s %8s  %s", id, spent[id], entries[id].Title), " "))
+		}
+		fmt.Printf("%6s %8s\n", "total", total)
+	default:
+		fatal("unknown format: " + o.format)
+	}
+}
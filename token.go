@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// tokenTTL is how long an auth token got by logging in is reused by later
+// runs of goissue.
+const tokenTTL = 12 * time.Hour
+
+// savedToken is an auth token of an account kept in tokenFile.
+type savedToken struct {
+	Token string    `json:"token"`
+	Saved time.Time `json:"saved"`
+}
+
+// tokenFile return path of the file that keep auth tokens by email, so
+// that each run doesn't log in.
+func tokenFile() string {
+	return filepath.Join(configDir(), "token.json")
+}
+
+// readTokens return the saved tokens. The tokens are not encrypted, even
+// when the password is in credentials.enc; the file is only readable by
+// the user, and a file readable by others is ignored.
+func readTokens() map[string]savedToken {
+	tokens := map[string]savedToken{}
+	if fi, err := os.Stat(tokenFile()); err == nil && runtime.GOOS != "windows" && fi.Mode().Perm()&0077 != 0 {
+		warnf("ignoring %s, which other users can read; run: chmod 600 %s", tokenFile(), tokenFile())
+		return tokens
+	}
+	if b, err := ioutil.ReadFile(tokenFile()); err == nil {
+		if json.Unmarshal(b, &tokens) != nil {
+			return map[string]savedToken{}
+		}
+	}
+	return tokens
+}
+
+func writeTokens(tokens map[string]savedToken) error {
+	b, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	return replaceFile(tokenFile(), b, 0600)
+}
+
+// cachedLogin return the saved auth token of the account, or log in and
+// save it. The token file is locked while logging in, so that processes
+// run at once log in only once and don't overwrite each other's token.
+// With "save_token": "false", it always log in and save nothing.
+func cachedLogin(ctx context.Context, config *Config) (string, error) {
+	if !config.SaveToken {
+		return login(ctx, config)
+	}
+	unlock, err := lockFile(tokenFile())
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	email := config.Email
+	tokens := readTokens()
+	if t, ok := tokens[email]; ok && time.Since(t.Saved) < tokenTTL {
+		return t.Token, nil
+	}
+	auth, err := login(ctx, config)
+	if err != nil {
+		return "", err
+	}
+	tokens[email] = savedToken{auth, time.Now()}
+	if err := writeTokens(tokens); err != nil {
+		warnf("failed to save auth token: %v", err)
+	}
+	return auth, nil
+}
+
+// forgetToken remove the saved auth token of the account, after the tracker
+// rejected it.
+func forgetToken(config *Config) {
+	unlock, err := lockFile(tokenFile())
+	if err != nil {
+		return
+	}
+	defer unlock()
+	tokens := readTokens()
+	if _, ok := tokens[config.Email]; !ok {
+		return
+	}
+	delete(tokens, config.Email)
+	if len(tokens) == 0 {
+		os.Remove(tokenFile())
+		return
+	}
+	writeTokens(tokens)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// traceStart match the first line of Go panic, runtime error, goroutine
+	// dump and gdb backtrace.
+	traceStart = regexp.MustCompile(`^(panic: |fatal error: |goroutine \d+ \[|#0 +0x[0-9a-f]+ in |#0 +\S+ \()`)
+	// traceLine match lines that continue the trace.
+	traceLine = regexp.MustCompile(`^(\t|goroutine \d+ \[|created by |\[signal |#\d+ |exit status |[\w./*()]+\(.*\)$|panic: |fatal error: |runtime: |PC=)`)
+)
+
+// formatTraces wrap Go panics and gdb backtraces in the body with <pre>,
+// so that the tracker doesn't wrap or mangle them. Traces already in <pre>
+// are left as is.
+func formatTraces(body string) string {
+	lines := strings.Split(body, "\n")
+	var out []string
+	inPre := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if inPre || !traceStart.MatchString(line) {
+			if strings.Contains(line, "<pre>") {
+				inPre = true
+			}
+			if strings.Contains(line, "</pre>") {
+				inPre = false
+			}
+			out = append(out, line)
+			continue
+		}
+		j := i + 1
+		for j < len(lines) {
+			if traceLine.MatchString(lines[j]) {
+				j++
+			} else if lines[j] == "" && j+1 < len(lines) && traceLine.MatchString(lines[j+1]) {
+				j += 2
+			} else {
+				break
+			}
+		}
+		out = append(out, "<pre>")
+		out = append(out, lines[i:j]...)
+		out = append(out, "</pre>")
+		i = j - 1
+	}
+	return strings.Join(out, "\n")
+}
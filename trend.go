@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// trendFlags is flags of trend.
+type trendFlags struct {
+	step   int
+	format string
+	filter filter
+}
+
+// register add flags of trend to the flag set.
+func (o *trendFlags) register(fs *flag.FlagSet) {
+	fs.IntVar(&o.step, "step", 7, "days between each point")
+	fs.StringVar(&o.format, "format", "text", "output format: text or csv")
+	o.filter.register(fs)
+}
+
+// showTrend print time series of open and closed issues from the store.
+func showTrend(ctx context.Context, config *Config, c *Client, args []string) {
+	var o trendFlags
+	fs := newFlagSet("trend")
+	o.register(fs)
+	fs.Parse(args)
+	if o.format != "text" && o.format != "csv" {
+		fmt.Fprint(os.Stderr, "invalid -format: "+o.format+" (valid: text, csv)\n")
+		fs.Usage()
+		exit(exitUsage)
+	}
+	if o.step <= 0 {
+		fatal("step must be positive")
+	}
+
+	var published, closed []time.Time
+	for _, entry := range loadStore(c.Project).Entries {
+		if !o.filter.match(entry) {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, entry.Published)
+		if err != nil {
+			continue
+		}
+		published = append(published, t)
+		if t, ok := closedAt(entry); ok {
+			closed = append(closed, t)
+		}
+	}
+	if len(published) == 0 {
+		fatal("no issues in the store; run goissue sync first")
+	}
+
+	first := published[0]
+	for _, t := range published {
+		if t.Before(first) {
+			first = t
+		}
+	}
+	if o.format == "csv" {
+		fmt.Println("date,open,closed")
+	}
+	now := time.Now()
+	for t := first.Truncate(24 * time.Hour); ; t = t.AddDate(0, 0, o.step) {
+		if t.After(now) {
+			t = now
+		}
+		p, c := countBefore(published, t), countBefore(closed, t)
+		if o.format == "csv" {
+			fmt.Printf("%s,%d,%d\n", t.Format("2006-01-02"), p-c, c)
+		} else {
+			fmt.Printf("%s  open %5d  closed %5d\n", t.Format("2006-01-02"), p-c, c)
+		}
+		if t.Equal(now) {
+			break
+		}
+	}
+}
+
+// countBefore return number of times which are not after t.
+func countBefore(times []time.Time, t time.Time) int {
+	n := 0
+	for _, tt := range times {
+		if !tt.After(t) {
+			n++
+		}
+	}
+	return n
+}
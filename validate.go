@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// defaultStatuses is the statuses that Google Code projects have by default.
+var defaultStatuses = []string{
+	"New", "Accepted", "Started",
+	"Fixed", "Verified", "Invalid", "Duplicate", "WontFix", "Done",
+}
+
+// splitList split comma separated list in config.
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// validStatuses return statuses allowed in the project. They are given by
+// "statuses" in config as comma separated list, or the defaults.
+func validStatuses(config *Config) []string {
+	if len(config.Statuses) > 0 {
+		return config.Statuses
+	}
+	return defaultStatuses
+}
+
+// validLabels return labels known in the project. They are given by
+// "labels" in config, or collected from the local store. nil means any
+// labels are allowed since the project has no list of them.
+func validLabels(config *Config, project string) []string {
+	if len(config.Labels) > 0 {
+		return config.Labels
+	}
+	seen := map[string]bool{}
+	var labels []string
+	for _, entry := range loadStore(project).Entries {
+		for _, label := range entry.IssuesLabel {
+			if !seen[label] {
+				seen[label] = true
+				labels = append(labels, label)
+			}
+		}
+	}
+	return labels
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateLabels return error if some of labels are unknown in the
+// project. Leading "-" which remove the label is ignored.
+func validateLabels(config *Config, project string, labels []string) error {
+	valid := validLabels(config, project)
+	if len(valid) == 0 {
+		return nil
+	}
+	for _, label := range labels {
+		if !contains(valid, strings.TrimPrefix(label, "-")) {
+			return errors.New("unknown label: " + label)
+		}
+	}
+	return nil
+}
+
+// validateIssue return error if status or labels of the issue are not
+// valid in the project.
+func validateIssue(config *Config, project string, issue *NewIssue) error {
+	if issue.Status != "" && !contains(validStatuses(config), issue.Status) {
+		return errors.New("unknown status: " + issue.Status + " (valid: " + strings.Join(validStatuses(config), ", ") + ")")
+	}
+	if issue.Owner != "" {
+		if err := validateUsers(config, project, []string{issue.Owner}); err != nil {
+			return err
+		}
+	}
+	return validateLabels(config, project, issue.Labels)
+}
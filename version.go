@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+// commit is the revision goissue was built from. It is set by
+// -ldflags "-X main.commit=REV", or taken from the build info.
+var commit string
+
+// buildCommit return the revision goissue was built from, or "unknown".
+func buildCommit() string {
+	if commit != "" {
+		return commit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				return s.Value
+			}
+		}
+	}
+	return "unknown"
+}
+
+// versionFlags is flags of version.
+type versionFlags struct {
+	check bool
+}
+
+// register add flags of version to the flag set.
+func (o *versionFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.check, "check", false, "check the API version of the tracker")
+}
+
+// showVersion print version of goissue and the API it speak. With -check,
+// the API version the tracker answered is also shown.
+func showVersion(ctx context.Context, config *Config, c *Client, args []string) {
+	var o versionFlags
+	fs := newFlagSet("version")
+	o.register(fs)
+	fs.Parse(args)
+
+	fmt.Printf("goissue %s (commit %s)\n", version, buildCommit())
+	fmt.Printf("GData API version %s\n", gdataVersion)
+	fmt.Printf("%s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	if !o.check {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.IssuesURL()+"?max-results=1", nil)
+	if err != nil {
+		fatal("failed to check API version:", err)
+	}
+	req.Header.Set("GData-Version", gdataVersion)
+	res, err := c.do(req)
+	if err != nil {
+		fatal("failed to check API version:", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != 200 {
+		fatal("failed to check API version:", res.Status)
+	}
+	v := res.Header.Get("GData-Version")
+	if v == "" {
+		v = "unknown"
+	}
+	fmt.Printf("tracker API version %s\n", v)
+	if v != gdataVersion && v != "unknown" {
+		warnf("the tracker speak API version %s, but goissue speak %s", v, gdataVersion)
+	}
+}
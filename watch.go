@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// watchEvent is a change of an issue found by watch.
+type watchEvent struct {
+	Kind    string // "new", "status", "comment" or "update"
+	Entry   Entry
+	Comment *Entry // the last comment for "comment"
+}
+
+// eventJSON is the event given to "watch_command" on stdin.
+type eventJSON struct {
+	Kind string `json:"kind"`
+	issueJSON
+	Comment *commentJSON `json:"comment,omitempty"`
+}
+
+// eventData return the event in JSON given to "watch_command".
+func eventData(c *Client, ev watchEvent) ([]byte, error) {
+	e := eventJSON{Kind: ev.Kind, issueJSON: issueData(c, ev.Entry)}
+	if ev.Comment != nil {
+		comment, err := commentData(c, *ev.Comment)
+		if err != nil {
+			return nil, err
+		}
+		e.Comment = &comment
+	}
+	return json.Marshal(e)
+}
+
+// lastComment return the last comment of the issue published since the
+// time, or nil.
+func lastComment(ctx context.Context, c *Client, id string, since time.Time) (*Entry, error) {
+	feed, err := commentsFeed(ctx, c, id, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(feed.Entry) == 0 {
+		return nil, nil
+	}
+	last := feed.Entry[len(feed.Entry)-1]
+	if t, err := time.Parse(time.RFC3339, last.Published); err != nil || t.Before(since) {
+		return nil, nil
+	}
+	return &last, nil
+}
+
+// runTrigger run the command with the event in JSON on stdin.
+func runTrigger(ctx context.Context, c *Client, command []string, ev watchEvent) error {
+	b, err := eventData(c, ev)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(b)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// watchFlags is flags of watch.
+type watchFlags struct {
+	interval time.Duration
+	metrics  string
+	command  string
+	filter   filter
+}
+
+// register add flags of watch to the flag set.
+func (o *watchFlags) register(fs *flag.FlagSet) {
+	fs.DurationVar(&o.interval, "interval", 5*time.Minute, "polling interval")
+	fs.StringVar(&o.metrics, "metrics", "", "serve metrics at the address like localhost:6060")
+	fs.StringVar(&o.command, "exec", "", "run the command for each event with the event in JSON on stdin, instead of \"watch_command\" in settings")
+	o.filter.register(fs)
+}
+
+// watchIssues poll issues updated since the last poll and print them
+// until interrupted. With -exec, or "watch_command" in config, the command
+// is run for each event with the event in JSON on stdin.
+func watchIssues(ctx context.Context, config *Config, c *Client, args []string) {
+	var o watchFlags
+	fs := newFlagSet("watch")
+	o.register(fs)
+	fs.Parse(args)
+	if o.command == "" {
+		o.command = config.WatchCommand
+	}
+	trigger := strings.Fields(o.command)
+
+	// every poll has a distinct query and comments must be fresh, so
+	// caching only fill the disk.
+	wc := *c
+	wc.Cache = nil
+	if o.metrics != "" {
+		serveMetrics(&wc, o.metrics)
+	}
+
+	since := time.Now().UTC()
+	seen := map[string]Entry{}
+	muted := loadMuted(c.Project)
+	spam := loadSpam(c.Project)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(o.interval):
+		}
+		now := time.Now().UTC()
+		query := o.filter.values("all")
+		query.Set("updated-min", since.Format(time.RFC3339))
+		entries, err := wc.Entries(ctx, query)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			warnf("failed to get issues: %v", err)
+			continue
+		}
+		entries = o.filter.narrow(entries)
+		prev := since
+		since = now
+		for _, entry := range entries {
+			if muted[issueId(entry)] || (!c.Options.ShowSpam && spam.match(entry)) {
+				continue
+			}
+			ev := watchEvent{Kind: "update", Entry: entry}
+			old, ok := seen[issueId(entry)]
+			if t, err := time.Parse(time.RFC3339, entry.Published); err == nil && !ok && !t.Before(prev) {
+				ev.Kind = "new"
+			} else if ok && strings.Join(old.IssuesStatus, ",") != strings.Join(entry.IssuesStatus, ",") {
+				ev.Kind = "status"
+			} else if len(trigger) > 0 {
+				// comments are told apart only for the command, which
+				// costs a request per update.
+				comment, err := lastComment(ctx, &wc, issueId(entry), prev)
+				if err != nil {
+					warnf("failed to get comments: %v", err)
+				} else if comment != nil {
+					ev.Kind, ev.Comment = "comment", comment
+				}
+			}
+			seen[issueId(entry)] = entry
+			printEvent(ev)
+			if len(trigger) > 0 {
+				if err := runTrigger(ctx, &wc, trigger, ev); err != nil && ctx.Err() == nil {
+					warnf("failed to run %s for issue %s: %v", trigger[0], issueId(entry), err)
+				}
+			}
+		}
+	}
+}
+
+func printEvent(ev watchEvent) {
+	status := strings.Join(ev.Entry.IssuesStatus, ",")
+	fmt.Printf("[%s] %s: %s (%s)\n", ev.Kind, issueId(ev.Entry), ev.Entry.Title, status)
+}
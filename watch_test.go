@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestEventData(t *testing.T) {
+	c := &Client{Project: "go", WebURL: "http://code.google.com"}
+	entry := Entry{
+		Id:           "http://code.google.com/feeds/issues/p/go/issues/full/123",
+		Title:        "crash in net/http",
+		IssuesState:  []string{"open"},
+		IssuesStatus: []string{"Accepted"},
+		IssuesLabel:  []string{"Type-Defect"},
+		Updated:      "2012-05-01T10:00:00Z",
+	}
+	comment := Entry{Author: []Author{{Name: "gopher"}}, Published: "2012-05-01T10:00:00Z", Content: "fixed"}
+	b, err := eventData(c, watchEvent{Kind: "comment", Entry: entry, Comment: &comment})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got eventJSON
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := eventJSON{
+		Kind: "comment",
+		issueJSON: issueJSON{
+			Project: "go",
+			Id:      "123",
+			Title:   "crash in net/http",
+			State:   "open",
+			Status:  "Accepted",
+			Labels:  []string{"Type-Defect"},
+			Updated: "2012-05-01T10:00:00Z",
+			URL:     "http://code.google.com/p/go/issues/detail?id=123",
+		},
+		Comment: &commentJSON{"gopher", "2012-05-01T10:00:00Z", "fixed"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("eventData = %s", b)
+	}
+}
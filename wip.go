@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// wipEntry is an issue in the local list of issues being worked on.
+type wipEntry struct {
+	Title string    `json:"title"`
+	Taken time.Time `json:"taken"`
+}
+
+// loadWip return issues being worked on in the project, by number.
+func loadWip(project string) map[string]wipEntry {
+	wip := map[string]wipEntry{}
+	if err := readLocal(project, "wip", &wip); err != nil {
+		warnf("failed to read issues in progress: %v", err)
+	}
+	return wip
+}
+
+// myUsername return the user name of the account on the tracker. It is the
+// part before "@" for Google accounts, and the whole email for others.
+func myUsername(config *Config) string {
+	email := config.Email
+	if i := strings.LastIndex(email, "@"); i >= 0 {
+		switch strings.ToLower(email[i+1:]) {
+		case "gmail.com", "googlemail.com":
+			return email[:i]
+		}
+	}
+	return email
+}
+
+// takeFlags is flags of take.
+type takeFlags struct {
+	release bool
+	status  string
+	message string
+}
+
+// register add flags of take to the flag set.
+func (o *takeFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.release, "release", false, "stop working on the issue")
+	fs.StringVar(&o.status, "status", "", "status to set; Started, or Accepted with -release")
+	fs.StringVar(&o.message, "m", "", "comment to post with the update")
+}
+
+// takeIssue assign the issue to the user with status Started and cc, and
+// record it in the local list shown by wip. With -release, the issue is
+// put back to -status and removed from the list.
+func takeIssue(ctx context.Context, config *Config, c *Client, args []string) {
+	var o takeFlags
+	fs := newFlagSet("take")
+	o.register(fs)
+	rest := parseFlags(fs, args)
+	if len(rest) != 1 {
+		fmt.Fprint(os.Stderr, "Usage: goissue take [-status STATUS] [-m MESSAGE] ID\n")
+		fmt.Fprint(os.Stderr, "       goissue take -release [-status STATUS] [-m MESSAGE] ID\n")
+		fs.PrintDefaults()
+		exit(exitUsage)
+	}
+	if !c.LoggedIn() {
+		fatal("failed to take issue:", errAuthRequired)
+	}
+
+	id := rest[0]
+	entry, err := c.Entry(ctx, id)
+	if err != nil {
+		fatal("failed to get issue:", err)
+	}
+	me := myUsername(config)
+	u := &Updates{Status: o.status}
+	body := o.message
+	if o.release {
+		if u.Status == "" {
+			u.Status = "Accepted"
+		}
+		if body == "" {
+			body = "I'm not working on this anymore."
+		}
+	} else {
+		if u.Status == "" {
+			u.Status = "Started"
+		}
+		u.Owner = me
+		// the API can't star issues; cc notify the user the same.
+		if ownerName(entry) != me {
+			u.Cc = []string{me}
+		}
+		if body == "" {
+			body = "I'm working on this."
+		}
+	}
+	if err := updateIssue(ctx, c, config.Email, entry, body, u); err != nil {
+		fatal("failed to update issue:", err)
+	}
+
+	unlock, err := lockFile(localFile(c.Project, "wip"))
+	if err != nil {
+		fatal("failed to write issues in progress:", err)
+	}
+	wip := loadWip(c.Project)
+	if o.release {
+		delete(wip, id)
+	} else {
+		wip[id] = wipEntry{Title: entry.Title, Taken: time.Now()}
+	}
+	err = writeLocal(c.Project, "wip", wip)
+	unlock()
+	if err != nil {
+		fatal("failed to write issues in progress:", err)
+	}
+	switch {
+	case o.release:
+		infof("issue %s released", id)
+	case webLink(entry) != "":
+		infof("issue %s taken; star it at %s", id, webLink(entry))
+	default:
+		infof("issue %s taken", id)
+	}
+}
+
+// showWip list issues taken locally, oldest first.
+func showWip(ctx context.Context, config *Config, c *Client, args []string) {
+	fs := newFlagSet("wip")
+	parseFlags(fs, args)
+
+	wip := loadWip(c.Project)
+	var ids []string
+	for id := range wip {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if !wip[ids[i]].Taken.Equal(wip[ids[j]].Taken) {
+			return wip[ids[i]].Taken.Before(wip[ids[j]].Taken)
+		}
+		a, _ := strconv.Atoi(ids[i])
+		b, _ := strconv.Atoi(ids[j])
+		return a < b
+	})
+	for _, id := range ids {
+		w := wip[id]
+		fmt.Printf("%s: %s (since %s)\n", id, w.Title, w.Taken.Format("2006-01-02"))
+	}
+}